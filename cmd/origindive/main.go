@@ -4,14 +4,21 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/netip"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -22,17 +29,11 @@ import (
 	"github.com/jhaxce/origindive/v3/pkg/core"
 	"github.com/jhaxce/origindive/v3/pkg/ip"
 	"github.com/jhaxce/origindive/v3/pkg/output"
-	"github.com/jhaxce/origindive/v3/pkg/passive/censys"
-	"github.com/jhaxce/origindive/v3/pkg/passive/ct"
-	passivedns "github.com/jhaxce/origindive/v3/pkg/passive/dns"
-	"github.com/jhaxce/origindive/v3/pkg/passive/dnsdumpster"
-	"github.com/jhaxce/origindive/v3/pkg/passive/securitytrails"
-	"github.com/jhaxce/origindive/v3/pkg/passive/shodan"
-	"github.com/jhaxce/origindive/v3/pkg/passive/subdomain"
-	"github.com/jhaxce/origindive/v3/pkg/passive/viewdns"
-	"github.com/jhaxce/origindive/v3/pkg/passive/virustotal"
-	"github.com/jhaxce/origindive/v3/pkg/passive/wayback"
-	"github.com/jhaxce/origindive/v3/pkg/passive/zoomeye"
+	"github.com/jhaxce/origindive/v3/pkg/passive"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+	"github.com/jhaxce/origindive/v3/pkg/passive/history"
+	"github.com/jhaxce/origindive/v3/pkg/passive/scoring"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 	"github.com/jhaxce/origindive/v3/pkg/scanner"
 	"github.com/jhaxce/origindive/v3/pkg/update"
 	"github.com/jhaxce/origindive/v3/pkg/waf"
@@ -51,32 +52,66 @@ func main() {
 	// Initialize colors
 	colors.Init(!config.NoColor)
 
+	// Resuming restores the interrupted scan's settings and ranges
+	var checkpoint *scanner.Checkpoint
+	if config.ResumeFile != "" {
+		var err error
+		checkpoint, err = scanner.LoadCheckpoint(config.ResumeFile)
+		if err == nil {
+			config, err = resumeConfig(checkpoint, config)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError: %s%s\n", colors.RED, err, colors.NC)
+			os.Exit(1)
+		}
+	}
+
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
 		fmt.Fprintf(os.Stderr, "%sError: %s%s\n", colors.RED, err, colors.NC)
 		os.Exit(1)
 	}
 
+	// Machine-readable passive results go to stdout when no file is given; status
+	// lines (banner, source progress, notices) then go to stderr instead
+	var status io.Writer = os.Stdout
+	if config.Mode == core.ModePassive && !outputFlagProvided && config.Format != core.FormatText {
+		status = os.Stderr
+	}
+	config.Status = status
+
+	// Route every DNS lookup through the configured resolvers
+	dnsResolver, err := resolver.New(config.Resolvers, config.DNSTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError: %s%s\n", colors.RED, err, colors.NC)
+		os.Exit(1)
+	}
+	resolver.SetDefault(dnsResolver)
+
 	// Set WAF database path (user cache or repo default)
 	config.WAFDatabasePath = getWAFDatabasePath()
 
 	// Print banner once at the start
 	if !config.Quiet {
-		printBanner(config)
+		printBanner(status, config)
 	}
 
 	// Handle passive and auto modes
-	var passiveIPs []string
-	if config.Mode == core.ModePassive || config.Mode == core.ModeAuto {
+	var passiveIPs []core.PassiveIP
+	var hostnames []core.Hostname
+	if checkpoint != nil {
+		// A resumed auto-mode scan reports the passive results it was built from
+		passiveIPs, hostnames = checkpoint.Passive, checkpoint.Hostnames
+	} else if config.Mode == core.ModePassive || config.Mode == core.ModeAuto {
 		// Run passive reconnaissance
 		if !config.Quiet {
-			fmt.Printf("\n%s═══════════════════════════════════════════════════════════════%s\n", colors.CYAN, colors.NC)
-			fmt.Printf("%s  Starting Passive Reconnaissance%s\n", colors.BOLD, colors.NC)
-			fmt.Printf("%s═══════════════════════════════════════════════════════════════%s\n", colors.CYAN, colors.NC)
+			fmt.Fprintf(status, "\n%s═══════════════════════════════════════════════════════════════%s\n", colors.CYAN, colors.NC)
+			fmt.Fprintf(status, "%s  Starting Passive Reconnaissance%s\n", colors.BOLD, colors.NC)
+			fmt.Fprintf(status, "%s═══════════════════════════════════════════════════════════════%s\n", colors.CYAN, colors.NC)
 		}
 
 		var err error
-		passiveIPs, err = runPassiveRecon(config)
+		passiveIPs, hostnames, err = runPassiveRecon(status, config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%sError during passive reconnaissance: %s%s\n", colors.RED, err, colors.NC)
 			if config.Mode == core.ModePassive {
@@ -86,8 +121,8 @@ func main() {
 		}
 
 		if !config.Quiet {
-			fmt.Printf("%s[+] Passive reconnaissance complete: %d IPs discovered%s\n", colors.GREEN, len(passiveIPs), colors.NC)
-			fmt.Printf("%s═══════════════════════════════════════════════════════════════%s\n\n", colors.CYAN, colors.NC)
+			fmt.Fprintf(status, "%s[+] Passive reconnaissance complete: %d IPs discovered%s\n", colors.GREEN, len(passiveIPs), colors.NC)
+			fmt.Fprintf(status, "%s═══════════════════════════════════════════════════════════════%s\n\n", colors.CYAN, colors.NC)
 		}
 
 		// If passive-only mode, we're done
@@ -105,16 +140,23 @@ func main() {
 				if outputFile == "" {
 					outputFile = generatePassiveFilename(config.Domain)
 				}
-				if err := savePassiveResults(outputFile, passiveIPs, config.Domain); err != nil {
+				if err := savePassiveResults(outputFile, passiveIPs, hostnames, config.Domain, config.Format); err != nil {
 					fmt.Fprintf(os.Stderr, "%sError saving results: %s%s\n", colors.RED, err, colors.NC)
 					os.Exit(1)
 				}
-				fmt.Printf("%s[+] Results saved to: %s%s\n", colors.GREEN, outputFile, colors.NC)
+				fmt.Fprintf(status, "%s[+] Results saved to: %s%s\n", colors.GREEN, outputFile, colors.NC)
+			} else if config.Format != core.FormatText {
+				// Machine-readable output goes to stdout when no file is given
+				if err := writePassiveResults(os.Stdout, passiveIPs, hostnames, config.Domain, config.Format); err != nil {
+					fmt.Fprintf(os.Stderr, "%sError writing results: %s%s\n", colors.RED, err, colors.NC)
+					os.Exit(1)
+				}
+				os.Exit(0)
 			}
 			// Always show results on console too
-			fmt.Printf("\n%sDiscovered IPs:%s\n", colors.CYAN, colors.NC)
-			for _, ipAddr := range passiveIPs {
-				fmt.Printf("  %s\n", ipAddr)
+			fmt.Fprintf(status, "\n%sDiscovered IPs (by confidence):%s\n", colors.CYAN, colors.NC)
+			for _, record := range passiveIPs {
+				fmt.Fprintf(status, "  %s\n", formatPassiveIP(record))
 			}
 			os.Exit(0)
 		}
@@ -131,8 +173,11 @@ func main() {
 					if cidrBits[0] != '/' {
 						cidrBits = "/" + cidrBits
 					}
-					for _, ipAddr := range passiveIPs {
-						expandedRange, err := expandIPToCIDR(ipAddr, cidrBits)
+					for _, record := range passiveIPs {
+						if addNetworkRange(config, record) {
+							continue
+						}
+						expandedRange, err := expandIPToCIDR(record.IP, cidrBits)
 						if err == nil {
 							config.IPRanges = append(config.IPRanges, expandedRange)
 						}
@@ -142,10 +187,13 @@ func main() {
 					}
 				} else {
 					// Regular mode: scan discovered IPs only
-					for _, ipAddr := range passiveIPs {
-						ipInt, err := ip.ToUint32(net.ParseIP(ipAddr))
+					for _, record := range passiveIPs {
+						if addNetworkRange(config, record) {
+							continue
+						}
+						addr, err := ip.ParseAddr(record.IP)
 						if err == nil {
-							config.IPRanges = append(config.IPRanges, [2]uint32{ipInt, ipInt})
+							config.IPRanges = append(config.IPRanges, [2]netip.Addr{addr, addr})
 						}
 					}
 					if !config.Quiet {
@@ -185,8 +233,8 @@ func main() {
 		}
 	}
 
-	// Parse IP ranges (only for pure active mode, not auto/passive)
-	if config.Mode == core.ModeActive || (config.Mode == "" && (config.StartIP != "" || config.CIDR != "" || config.InputFile != "" || config.ASN != "")) {
+	// Parse IP ranges (only for pure active mode, not auto/passive; a resumed scan keeps its saved ranges)
+	if checkpoint == nil && (config.Mode == core.ModeActive || (config.Mode == "" && (config.StartIP != "" || config.CIDR != "" || config.InputFile != "" || config.ASN != ""))) {
 		if err := parseIPRanges(config); err != nil {
 			fmt.Fprintf(os.Stderr, "%sError: %s%s\n", colors.RED, err, colors.NC)
 			os.Exit(1)
@@ -205,6 +253,36 @@ func main() {
 			fmt.Fprintf(os.Stderr, "%sError creating scanner: %s%s\n", colors.RED, err, colors.NC)
 			os.Exit(1)
 		}
+
+		if config.CheckpointFile != "" {
+			s.SetCheckpoint(config.CheckpointFile, scanner.DefaultCheckpointInterval)
+		}
+		if config.Mode == core.ModeAuto {
+			s.SetPassive(passiveIPs, hostnames)
+		}
+		if checkpoint != nil {
+			s.Resume(checkpoint)
+			if !config.Quiet {
+				fmt.Printf("%s[*] Resuming scan: %d of %d IPs already completed (checkpoint saved %s)%s\n\n",
+					colors.CYAN, checkpoint.Completed, ip.NewAddrIterator(ip.RangesFromPairs(config.IPRanges)).TotalIPs(),
+					checkpoint.SavedAt.Local().Format("2006-01-02 15:04:05"), colors.NC)
+			}
+		}
+
+		// With --baseline or --verify, compare candidates against the live site as served through its CDN
+		if config.Baseline || config.VerifyContent {
+			reference, err := s.FetchReference(context.Background())
+			if err != nil {
+				if !config.Quiet {
+					fmt.Fprintf(os.Stderr, "%s[!] %s (baseline comparison disabled)%s\n", colors.YELLOW, err, colors.NC)
+				}
+			} else {
+				s.SetReference(reference)
+				if !config.Quiet {
+					printBaseline(reference)
+				}
+			}
+		}
 	}
 
 	// Print active scan header for auto mode
@@ -223,9 +301,14 @@ func main() {
 		}
 	}
 
-	// Create output writer (empty string means console only)
+	// Create output writer (empty string means console only); auto mode writes
+	// its output file as one report after the scan instead
 	formatter := output.NewFormatter(config.Format, !config.NoColor, config.ShowAll)
-	writer, err := output.NewWriter(config.OutputFile, formatter, config.Quiet)
+	writerFile := config.OutputFile
+	if config.Mode == core.ModeAuto {
+		writerFile = ""
+	}
+	writer, err := output.NewWriter(writerFile, formatter, config.Quiet)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError creating output writer: %s%s\n", colors.RED, err, colors.NC)
 		os.Exit(1)
@@ -234,18 +317,19 @@ func main() {
 
 	// Write header
 	// Calculate total IPs from ranges
-	ranges := make([]ip.IPRange, len(config.IPRanges))
-	for i, r := range config.IPRanges {
-		ranges[i] = ip.IPRange{Start: r[0], End: r[1]}
-	}
-	iterator := ip.NewIterator(ranges)
+	iterator := ip.NewAddrIterator(ip.RangesFromPairs(config.IPRanges))
 	totalIPs := iterator.TotalIPs()
 	writer.WriteHeader(config, totalIPs)
 
 	// Create progress tracker
 	var prog *output.Progress
 	if !config.NoProgress && !config.Quiet {
-		prog = output.NewProgress(totalIPs, true, !config.NoColor)
+		// Each IP is probed once per configured port
+		totalProbes := totalIPs
+		if len(config.Ports) > 0 {
+			totalProbes = ip.MulSaturating(totalProbes, uint64(len(config.Ports)))
+		}
+		prog = output.NewProgress(totalProbes, true, !config.NoColor)
 		go prog.Display()
 
 		// Set progress callback
@@ -259,9 +343,24 @@ func main() {
 		})
 	}
 
-	// Perform scan
+	// Perform scan (Ctrl-C stops a checkpointed scan cleanly so it can be resumed)
 	ctx := context.Background()
+	if config.CheckpointFile != "" {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
 	result, err := s.Scan(ctx)
+	if errors.Is(err, core.ErrScanInterrupted) {
+		if prog != nil && prog.IsRunning() {
+			prog.Stop()
+			time.Sleep(150 * time.Millisecond)
+			fmt.Println()
+		}
+		fmt.Fprintf(os.Stderr, "%s[!] %s%s\n", colors.YELLOW, err, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s[*] Continue with: origindive --resume %s%s\n", colors.CYAN, config.CheckpointFile, colors.NC)
+		os.Exit(130)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%sError during scan: %s%s\n", colors.RED, err, colors.NC)
 		os.Exit(1)
@@ -289,6 +388,15 @@ func main() {
 		for _, r := range result.Redirects {
 			writer.WriteResult(*r)
 		}
+	} else {
+		// Non-200 IPs presenting a certificate for the domain are still origin candidates
+		for _, results := range [][]*core.IPResult{result.Other, result.Redirects} {
+			for _, r := range results {
+				if r.CertMatch {
+					writer.WriteResult(*r)
+				}
+			}
+		}
 	}
 	// Write 200 OK last (most important, near summary)
 	for _, r := range result.Success {
@@ -332,6 +440,27 @@ func main() {
 	// Write summary
 	writer.WriteSummary(result.Summary)
 
+	// Auto mode: join each IP's passive provenance with its probe results and verdict
+	if config.Mode == core.ModeAuto {
+		result.AddPassive(passiveIPs)
+		result.Hostnames = hostnames
+		if !config.Quiet {
+			fmt.Print(formatter.FormatCandidates(result.Candidates))
+		}
+		if config.OutputFile != "" {
+			if err := saveReport(config.OutputFile, result, config.Format); err != nil {
+				fmt.Fprintf(os.Stderr, "%sError saving report: %s%s\n", colors.RED, err, colors.NC)
+			} else if !config.Quiet {
+				fmt.Printf("%s[+] Report saved to: %s%s\n", colors.GREEN, config.OutputFile, colors.NC)
+			}
+		}
+	}
+
+	// Report where adaptive rate limiting settled
+	if config.AdaptiveRate && !config.Quiet {
+		fmt.Printf("%s[*] Adaptive rate limit ended at %.1f req/s%s\n", colors.CYAN, s.Rate(), colors.NC)
+	}
+
 	// Warn if many timeouts/errors and high worker count (possible rate limiting)
 	totalFailed := uint64(len(result.Timeouts)) + uint64(len(result.Errors))
 	if !config.Quiet && totalFailed > 0 && result.Summary.SuccessCount == 0 && config.Workers >= 10 {
@@ -340,6 +469,9 @@ func main() {
 			fmt.Fprintf(os.Stderr, "\n%s[!] Warning: %.0f%% of requests failed%s\n", colors.YELLOW, failureRate, colors.NC)
 			fmt.Fprintf(os.Stderr, "%s[!] The server may be rate-limiting connections%s\n", colors.YELLOW, colors.NC)
 			fmt.Fprintf(os.Stderr, "%s[*] Try: Reduce workers (-j 5) and increase timeout (-t 10)%s\n", colors.CYAN, colors.NC)
+			if !config.AdaptiveRate {
+				fmt.Fprintf(os.Stderr, "%s[*] Or let the scanner back off automatically with --adaptive-rate%s\n", colors.CYAN, colors.NC)
+			}
 
 			// Build example command without redundant flags
 			exampleCmd := getRerunCommand(config)
@@ -392,6 +524,8 @@ func parseFlags() (*core.Config, bool) {
 
 	// Performance flags
 	pflag.IntVarP(&config.Workers, "threads", "j", 10, "Number of parallel workers")
+	pflag.Float64Var(&config.RateLimit, "rate-limit", 0, "Maximum requests per second across all workers (0 = unlimited)")
+	pflag.BoolVar(&config.AdaptiveRate, "adaptive-rate", false, "Slow down when timeouts/errors spike and speed back up when they clear")
 
 	// HTTP flags
 	pflag.StringVarP(&config.HTTPMethod, "method", "m", "GET", "HTTP method")
@@ -399,12 +533,17 @@ func parseFlags() (*core.Config, bool) {
 	var connectTimeout int
 	pflag.IntVarP(&timeout, "timeout", "t", 5, "HTTP timeout in seconds")
 	pflag.IntVar(&connectTimeout, "connect-timeout", 3, "TCP connect timeout in seconds")
-	pflag.StringVarP(&config.CustomHeader, "header", "H", "", "Custom header")
+	pflag.StringArrayVarP(&config.Headers, "header", "H", nil, "Custom header 'Name: Value' (repeatable)")
+	var scheme string
+	pflag.StringVar(&scheme, "scheme", "http", "Probe scheme: http, https (domain sent as TLS SNI), or both (HTTPS first, then HTTP)")
+	var ports string
+	pflag.StringVar(&ports, "ports", "", "Comma-separated ports to probe per IP (e.g. 80,443,8080,8443,2052,2083)")
 	pflag.StringVarP(&config.UserAgent, "user-agent", "A", "", "User-Agent: random, chrome, firefox, safari, edge, opera, brave, mobile, or custom string")
 	pflag.BoolVar(&config.NoUserAgent, "no-ua", false, "Disable User-Agent header")
 	followRedirectFlag := pflag.IntP("follow-redirect", "", 0, "Follow HTTP redirects (use alone for unlimited, or --follow-redirect=3 for max hops)")
 	pflag.Lookup("follow-redirect").NoOptDefVal = "10" // Default to 10 when flag used without value
 	pflag.BoolVar(&config.VerifyContent, "verify", false, "Extract title and hash response body for verification")
+	pflag.BoolVar(&config.Baseline, "baseline", false, "Fetch the live site as a reference profile and annotate matching candidates (implied by --verify)")
 	pflag.BoolVar(&config.FilterUnique, "filter-unique", false, "Show only IPs with unique content (requires --verify)")
 
 	// Proxy flags
@@ -413,6 +552,12 @@ func parseFlags() (*core.Config, bool) {
 	pflag.BoolVar(&config.ProxyRotate, "proxy-rotate", false, "Rotate through proxy list")
 	pflag.BoolVar(&config.ProxyTest, "proxy-test", true, "Test proxy before use")
 
+	// DNS flags
+	var resolvers string
+	pflag.StringVar(&resolvers, "resolvers", "", "Comma-separated DNS resolvers used round-robin: IP[:port], tcp://IP[:port] or https:// DoH URL")
+	var dnsTimeout int
+	pflag.IntVar(&dnsTimeout, "dns-timeout", 3, "Per-query DNS timeout in seconds")
+
 	// WAF filtering flags
 	pflag.BoolVar(&config.SkipWAF, "skip-waf", false, "Skip known WAF/CDN IP ranges")
 	var skipProviders string
@@ -426,7 +571,13 @@ func parseFlags() (*core.Config, bool) {
 	pflag.BoolVar(&config.AutoScan, "auto-scan", false, "Auto-scan: passive then active")
 	pflag.Float64Var(&config.MinConfidence, "min-confidence", 0.7, "Minimum confidence score (0.0-1.0)")
 	var passiveSources string
-	pflag.StringVar(&passiveSources, "passive-sources", "", "Comma-separated passive sources (ct,dns,shodan,censys)")
+	pflag.StringVar(&passiveSources, "passive-sources", "", "Comma-separated passive sources (ct,dns,mail,shodan,censys)")
+	var ctLogs string
+	pflag.StringVar(&ctLogs, "ct-logs", "", "Comma-separated RFC 6962 CT log URLs read directly when crt.sh fails")
+	pflag.Uint64Var(&config.CTWindow, "ct-window", 0, "Newest entries read from each CT log when crt.sh fails (default 16384)")
+	pflag.StringVar(&config.Wordlist, "wordlist", "", "Subdomain wordlist for DNS brute force, one name per line (default: built-in list)")
+	pflag.BoolVar(&config.Permutations, "permutations", false, "Also resolve permutations of discovered subdomains (dev-api, api2, api-staging)")
+	pflag.StringVar(&config.EMLFile, "eml", "", "Mail message (.eml) from the target whose Received headers are searched for mail server IPs")
 
 	// Output flags
 	outputFlag := pflag.StringP("output", "o", "", "Output file path (use '-o' alone for auto-generated name, or '-o=file.txt' for custom)")
@@ -439,6 +590,10 @@ func parseFlags() (*core.Config, bool) {
 	pflag.BoolVar(&config.NoProgress, "no-progress", false, "Disable progress bar")
 	pflag.BoolVar(&config.SilentErrors, "silent-errors", false, "Suppress passive source API error warnings")
 
+	// Checkpoint flags (resumable active scans)
+	pflag.StringVar(&config.CheckpointFile, "checkpoint", "", "Save active scan progress to this file periodically and on Ctrl-C")
+	pflag.StringVar(&config.ResumeFile, "resume", "", "Resume an interrupted active or auto scan from a checkpoint file")
+
 	// Version flag
 	showVersion := pflag.BoolP("version", "V", false, "Show version")
 
@@ -447,6 +602,12 @@ func parseFlags() (*core.Config, bool) {
 	// Handle --follow-redirect flag
 	config.MaxRedirects = *followRedirectFlag
 
+	// DNS settings are applied before the config files so they can take precedence
+	if resolvers != "" {
+		config.Resolvers = strings.Split(resolvers, ",")
+	}
+	config.DNSTimeout = time.Duration(dnsTimeout) * time.Second
+
 	// Check if -o flag was provided
 	outputFlagProvided := pflag.Lookup("output").Changed
 	config.OutputFile = *outputFlag
@@ -505,6 +666,22 @@ func parseFlags() (*core.Config, bool) {
 		os.Exit(0)
 	}
 
+	// Parse probe scheme; left empty unless --scheme is given, so a config file value is kept
+	config.Scheme = ""
+	if pflag.Lookup("scheme").Changed {
+		switch strings.ToLower(scheme) {
+		case "http":
+			config.Scheme = core.SchemeHTTP
+		case "https":
+			config.Scheme = core.SchemeHTTPS
+		case "both":
+			config.Scheme = core.SchemeBoth
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid scheme: %s (expected http, https or both)\n", scheme)
+			os.Exit(1)
+		}
+	}
+
 	// Load global config first (lowest priority)
 	globalConfig, err := core.LoadGlobalConfig()
 	if err != nil {
@@ -545,6 +722,21 @@ func parseFlags() (*core.Config, bool) {
 		os.Exit(1)
 	}
 
+	// No scheme from --scheme or a config file: probe over HTTP
+	if config.Scheme == "" {
+		config.Scheme = core.SchemeHTTP
+	}
+
+	// Parse probe ports
+	if ports != "" {
+		parsed, err := parsePorts(ports)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid ports: %s\n", err)
+			os.Exit(1)
+		}
+		config.Ports = parsed
+	}
+
 	// Parse skip providers
 	if skipProviders != "" {
 		config.SkipProviders = strings.Split(skipProviders, ",")
@@ -554,6 +746,9 @@ func parseFlags() (*core.Config, bool) {
 	if passiveSources != "" {
 		config.PassiveSources = strings.Split(passiveSources, ",")
 	}
+	if ctLogs != "" {
+		config.CTLogs = strings.Split(ctLogs, ",")
+	}
 
 	// Handle input scrape flag (--input-scrape)
 	if inputScrape != "" {
@@ -781,11 +976,87 @@ func initializeGlobalConfig() error {
 	return nil
 }
 
+// printBaseline prints the reference profile candidates are compared against
+func printBaseline(b *scanner.Reference) {
+	fmt.Printf("%s[*] Baseline: %s (HTTP %d)", colors.CYAN, b.URL, b.StatusCode)
+	if b.Title != "" {
+		fmt.Printf(" %q", b.Title)
+	}
+	fmt.Printf(" [%s]%s\n", b.BodyHash, colors.NC)
+
+	var details []string
+	for name, value := range b.Headers {
+		details = append(details, name+": "+value)
+	}
+	sort.Strings(details)
+	if len(b.Cookies) > 0 {
+		details = append(details, "cookies: "+strings.Join(b.Cookies, ", "))
+	}
+	if b.FaviconHash != 0 {
+		details = append(details, fmt.Sprintf("favicon: %d", b.FaviconHash))
+	}
+	if len(details) > 0 {
+		fmt.Printf("%s    %s%s\n", colors.CYAN, strings.Join(details, " | "), colors.NC)
+	}
+	fmt.Println()
+}
+
+// resumeConfig returns the scan configuration saved in a checkpoint, keeping the
+// output options and custom headers of this command line and checkpointing to the same file
+func resumeConfig(checkpoint *scanner.Checkpoint, cli *core.Config) (*core.Config, error) {
+	saved := checkpoint.Config
+	if cli.Domain != "" && !strings.EqualFold(cli.Domain, saved.Domain) {
+		return nil, fmt.Errorf("checkpoint %s is for %s, not %s", cli.ResumeFile, saved.Domain, cli.Domain)
+	}
+
+	// Header values are not saved (they may hold credentials), so they must be given again
+	headers, err := core.ParseHeaders(cli.Headers)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, name := range checkpoint.HeaderNames {
+		if len(headers.Values(name)) == 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("checkpoint %s was scanned with header(s) %s; pass them again with -H", cli.ResumeFile, strings.Join(missing, ", "))
+	}
+
+	config := *saved
+	config.CheckpointFile = cli.ResumeFile
+	config.ResumeFile = cli.ResumeFile
+	config.Headers = cli.Headers
+
+	config.OutputFile = cli.OutputFile
+	config.Format = cli.Format
+	config.Quiet = cli.Quiet
+	config.Verbose = cli.Verbose
+	config.ShowAll = config.ShowAll || cli.ShowAll
+	config.NoColor = cli.NoColor
+	config.NoProgress = cli.NoProgress
+	config.SilentErrors = cli.SilentErrors
+	return &config, nil
+}
+
 func validateConfig(config *core.Config) error {
 	if config.Domain == "" {
 		return fmt.Errorf("domain is required (-d or --domain)")
 	}
 
+	switch config.Scheme {
+	case "", core.SchemeHTTP, core.SchemeHTTPS, core.SchemeBoth:
+	default:
+		return fmt.Errorf("invalid scheme %q (expected http, https or both)", config.Scheme)
+	}
+
+	for _, port := range config.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d (expected 1-65535)", port)
+		}
+	}
+
 	// Validate --filter-unique requires --verify
 	if config.FilterUnique && !config.VerifyContent {
 		return fmt.Errorf("--filter-unique requires --verify flag")
@@ -795,7 +1066,7 @@ func validateConfig(config *core.Config) error {
 	// For auto mode, IP ranges are optional (will be discovered from passive scan)
 	// For active mode, IP ranges are required
 	if config.Mode == core.ModeActive || config.Mode == "" {
-		if config.StartIP == "" && config.CIDR == "" && config.InputFile == "" && config.ASN == "" {
+		if len(config.IPRanges) == 0 && config.StartIP == "" && config.CIDR == "" && config.InputFile == "" && config.ASN == "" {
 			return fmt.Errorf("must specify IP range for active scan: -s/-e, -n, -i, or --asn (or use --passive/--auto-scan)")
 		}
 	}
@@ -809,7 +1080,7 @@ func validateConfig(config *core.Config) error {
 }
 
 func parseIPRanges(config *core.Config) error {
-	var ranges [][2]uint32
+	var ranges [][2]netip.Addr
 
 	// Handle ASN lookup first (supports comma-separated ASNs)
 	if config.ASN != "" {
@@ -839,7 +1110,7 @@ func parseIPRanges(config *core.Config) error {
 
 			// Parse each CIDR from ASN response
 			for _, cidr := range asnResp.ASNRanges {
-				r, err := ip.ParseCIDRRange(cidr)
+				r, err := ip.ParseCIDRAddrRange(cidr)
 				if err != nil {
 					if !config.Quiet {
 						fmt.Fprintf(os.Stderr, "%s[!] Warning: Invalid CIDR %s: %s%s\n",
@@ -847,7 +1118,7 @@ func parseIPRanges(config *core.Config) error {
 					}
 					continue
 				}
-				ranges = append(ranges, [2]uint32{r.Start, r.End})
+				ranges = append(ranges, r.Pair())
 				totalASNRanges++
 			}
 		}
@@ -864,20 +1135,20 @@ func parseIPRanges(config *core.Config) error {
 
 	// Parse IP range
 	if config.StartIP != "" && config.EndIP != "" {
-		r, err := ip.ParseIPRange(config.StartIP, config.EndIP)
+		r, err := ip.ParseAddrRange(config.StartIP, config.EndIP)
 		if err != nil {
 			return fmt.Errorf("invalid IP range: %w", err)
 		}
-		ranges = append(ranges, [2]uint32{r.Start, r.End})
+		ranges = append(ranges, r.Pair())
 	}
 
 	// Parse CIDR
 	if config.CIDR != "" {
-		r, err := ip.ParseCIDRRange(config.CIDR)
+		r, err := ip.ParseCIDRAddrRange(config.CIDR)
 		if err != nil {
 			return fmt.Errorf("invalid CIDR: %w", err)
 		}
-		ranges = append(ranges, [2]uint32{r.Start, r.End})
+		ranges = append(ranges, r.Pair())
 	}
 
 	// Parse input file
@@ -899,16 +1170,15 @@ func parseIPRanges(config *core.Config) error {
 			for _, r := range fileRanges {
 				// For each IP in the range, expand to subnet
 				// If it's a single IP (Start == End), expand it
-				if r.Start == r.End {
-					ipAddr := ip.FromUint32(r.Start)
-					expandedRange, err := expandIPToCIDR(ipAddr.String(), cidrBits)
+				if r.Start == r.End && r.Is4() {
+					expandedRange, err := expandIPToCIDR(r.Start.String(), cidrBits)
 					if err == nil {
 						ranges = append(ranges, expandedRange)
 						expandedCount++
 					}
 				} else {
-					// If it's already a range, keep it as-is
-					ranges = append(ranges, [2]uint32{r.Start, r.End})
+					// Ranges and IPv6 addresses are kept as-is
+					ranges = append(ranges, r.Pair())
 				}
 			}
 
@@ -919,15 +1189,38 @@ func parseIPRanges(config *core.Config) error {
 		} else {
 			// No expansion, use as-is
 			for _, r := range fileRanges {
-				ranges = append(ranges, [2]uint32{r.Start, r.End})
+				ranges = append(ranges, r.Pair())
 			}
 		}
 	}
 
+	if err := checkRangeSize(ranges, config.Quiet); err != nil {
+		return err
+	}
+
 	config.IPRanges = ranges
 	return nil
 }
 
+// Scan size limits: ranges above maxScanIPs (e.g. an IPv6 /64) would never finish
+// and are rejected; ranges above warnScanIPs (a /8) only get a warning.
+const (
+	maxScanIPs  = 1 << 32
+	warnScanIPs = 1 << 24
+)
+
+// checkRangeSize rejects scan ranges that are too large to finish and warns on very large ones
+func checkRangeSize(ranges [][2]netip.Addr, quiet bool) error {
+	total := ip.NewAddrIterator(ip.MergeAddrRanges(ip.RangesFromPairs(ranges))).TotalIPs()
+	if total > maxScanIPs {
+		return fmt.Errorf("%w: more than %d IPs, scan smaller ranges", core.ErrRangeTooLarge, uint64(maxScanIPs))
+	}
+	if total > warnScanIPs && !quiet {
+		fmt.Fprintf(os.Stderr, "%s[!] Scanning %d IPs will take a long time; consider narrowing the range%s\n", colors.YELLOW, total, colors.NC)
+	}
+	return nil
+}
+
 // scrapeIPsFromFile reads any file, extracts IPv4 addresses using a regex,
 // writes them to '<domain>-ips.txt' (one per line) and returns the filename.
 func scrapeIPsFromFile(path, domain string) (string, error) {
@@ -968,14 +1261,18 @@ func scrapeIPsFromFile(path, domain string) (string, error) {
 
 // (Previously had a Censys-specific parser; removed in favor of generic scrape.)
 
-// runPassiveRecon performs passive reconnaissance to discover IPs related to the domain
-func runPassiveRecon(config *core.Config) ([]string, error) {
-	var discoveredIPs []string
-	var mu sync.Mutex
+// runPassiveRecon performs passive reconnaissance to discover IPs related to the domain.
+// Results are scored, filtered by --min-confidence and ordered by confidence (highest first);
+// the hostname inventory lists every name under the domain the sources found.
+func runPassiveRecon(out io.Writer, config *core.Config) ([]core.PassiveIP, []core.Hostname, error) {
+	var records []core.PassiveIP
 	var wg sync.WaitGroup
 
-	// Channel for collecting IPs from different sources
-	ipChan := make(chan string, 100)
+	// Channel for collecting records from different sources
+	ipChan := make(chan core.PassiveIP, 100)
+
+	// Keyed sources share one manager so rate-limited keys rotate and cool down
+	keys := newKeyManager(config)
 
 	// Start goroutines for each passive source (if enabled)
 	sources := getEnabledPassiveSources(config)
@@ -984,15 +1281,15 @@ func runPassiveRecon(config *core.Config) ([]string, error) {
 	for _, source := range sources {
 		go func(src string) {
 			defer wg.Done()
-			ips, err := queryPassiveSource(src, config)
+			ips, err := queryPassiveSource(out, src, config, keys)
 			if err != nil {
 				if !config.Quiet && !config.SilentErrors {
 					fmt.Fprintf(os.Stderr, "%s[!] %s: %s%s\n", colors.YELLOW, src, err, colors.NC)
 				}
 				return
 			}
-			for _, ipAddr := range ips {
-				ipChan <- ipAddr
+			for _, record := range ips {
+				ipChan <- record
 			}
 		}(source)
 	}
@@ -1003,300 +1300,334 @@ func runPassiveRecon(config *core.Config) ([]string, error) {
 		close(ipChan)
 	}()
 
-	// Collect unique IPs
+	// Keep one record per (IP, source, hostname) so multi-source agreement is scored
+	// and every name behind an IP is kept; dated A-record history is merged from
+	// every record first
 	seen := make(map[string]bool)
-	for ipAddr := range ipChan {
-		mu.Lock()
-		if !seen[ipAddr] {
-			seen[ipAddr] = true
-			discoveredIPs = append(discoveredIPs, ipAddr)
+	dnsHistory := history.New()
+	for record := range ipChan {
+		dnsHistory.Add(record)
+		key := record.IP + "|" + record.Source + "|" + strings.ToLower(record.Hostname)
+		if !seen[key] {
+			seen[key] = true
+			records = append(records, record)
 		}
-		mu.Unlock()
 	}
 
-	return discoveredIPs, nil
-}
+	// Hostnames found by any source are resolved (and permuted) until no new names appear
+	if !config.Quiet {
+		fmt.Fprintf(out, "%s[*] Expanding hostnames found by passive sources...%s\n", colors.CYAN, colors.NC)
+	}
+	// Ctrl-C or the deadline stops the expansion, keeping the names found so far
+	expandCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	expandCtx, cancel := context.WithTimeout(expandCtx, passive.Timeout(config)*4)
+	expanded := passive.ExpandHostnames(expandCtx, config, config.Domain, records)
+	if expandCtx.Err() != nil && !config.Quiet {
+		fmt.Fprintf(os.Stderr, "%s[!] Hostname expansion stopped early: %s%s\n", colors.YELLOW, context.Cause(expandCtx), colors.NC)
+	}
+	cancel()
+	stop()
+	if !config.Quiet && len(expanded) > 0 {
+		fmt.Fprintf(out, "%s[+] Found %d more hostname/IP pairs from passive hostnames%s\n", colors.GREEN, len(expanded), colors.NC)
+	}
+	records = append(records, expanded...)
 
-// getEnabledPassiveSources returns list of passive sources to query
-func getEnabledPassiveSources(config *core.Config) []string {
-	// Parse passive sources from config
-	if len(config.PassiveSources) == 0 {
-		return []string{"ct", "dns"} // Default: free sources only
+	if !config.Quiet {
+		printKeyStatus(out, keys.Report())
+	}
+	if path, err := core.GetAPIStatePath(); err == nil {
+		if err := keys.SaveState(path); err != nil && config.Verbose {
+			fmt.Fprintf(os.Stderr, "%s[!] %s%s\n", colors.YELLOW, err, colors.NC)
+		}
 	}
 
-	// PassiveSources is already a slice of strings
-	return config.PassiveSources
-}
+	scoringConfig := scoring.DefaultScoringConfig()
+	scoringConfig.MinConfidence = config.MinConfidence
+	ranked := scoring.NewScorer(config.Domain, scoringConfig).Rank(records)
 
-// queryPassiveSource queries a specific passive intelligence source
-func queryPassiveSource(source string, config *core.Config) ([]string, error) {
 	if !config.Quiet {
-		fmt.Printf("%s[*] Querying %s...%s\n", colors.CYAN, source, colors.NC)
-	}
-
-	switch strings.ToLower(source) {
-	case "ct":
-		// Certificate Transparency logs (free)
-		return queryCertificateTransparency(config.Domain, config)
-	case "dns":
-		// DNS history (free via public resolvers)
-		return queryDNSHistory(config.Domain, config)
-	case "shodan":
-		// Shodan API (requires key)
-		return queryShodan(config.Domain, config)
-	case "censys":
-		// Censys API (requires key)
-		return queryCensys(config.Domain, config)
-	case "securitytrails":
-		// SecurityTrails API (requires key)
-		return querySecurityTrails(config.Domain, config)
-	case "zoomeye":
-		// ZoomEye API (requires key)
-		return queryZoomEye(config.Domain, config)
-	case "wayback":
-		// Wayback Machine (free)
-		return queryWayback(config.Domain, config)
-	case "virustotal":
-		// VirusTotal API (free tier with rate limits)
-		return queryVirusTotal(config.Domain, config)
-	case "viewdns":
-		// ViewDNS API (requires key)
-		return queryViewDNS(config.Domain, config)
-	case "dnsdumpster":
-		// DNSDumpster (free web scraping)
-		return queryDNSDumpster(config.Domain, config)
-	default:
-		return nil, fmt.Errorf("unknown passive source: %s", source)
+		if filtered := len(uniquePassiveIPs(records)) - len(ranked); filtered > 0 {
+			fmt.Fprintf(out, "%s[*] %d IPs below minimum confidence %.2f filtered out (adjust with --min-confidence)%s\n",
+				colors.CYAN, filtered, config.MinConfidence, colors.NC)
+		}
 	}
-}
 
-// passiveTimeout returns the timeout to use for passive intelligence sources.
-// It is at least 30s, but follows the CLI `-t` value if greater.
-func passiveTimeout(config *core.Config) time.Duration {
-	if config.Timeout > 30*time.Second {
-		return config.Timeout
+	// The IPs that served the domain right before it moved onto a CDN go first
+	ranges := loadCDNRanges(config.WAFDatabasePath)
+	migration := dnsHistory.Migration(history.InRanges(ranges))
+	if !config.Quiet {
+		printHistory(out, dnsHistory, ranges, migration)
+	}
+	if migration != nil {
+		ranked = migration.Prioritize(ranked)
 	}
-	return 30 * time.Second
-}
 
-// queryCertificateTransparency searches CT logs for domain certificates
-func queryCertificateTransparency(domain string, config *core.Config) ([]string, error) {
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
+	// Flag IPs inside known WAF/CDN ranges
+	cdn := func(addr string) string {
+		if ranges == nil {
+			return ""
+		}
+		provider, _ := ranges.FindProvider(net.ParseIP(addr))
+		return provider
+	}
+	for i := range ranked {
+		if provider := cdn(ranked[i].IP); provider != "" {
+			ranked[i].WAF = provider
+		}
+	}
 
-	ips, err := ct.SearchCrtSh(ctx, domain, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("CT search failed: %w", err)
+	hostnames := passive.Inventory(config.Domain, records, cdn)
+	if !config.Quiet {
+		printHostnames(out, hostnames)
 	}
 
-	return ips, nil
+	return ranked, hostnames, nil
 }
 
-// queryDNSHistory queries DNS history and MX records for the domain
-func queryDNSHistory(domain string, config *core.Config) ([]string, error) {
-	var allIPs []string
-
-	// Phase 1: Subdomain enumeration
-	fmt.Printf("  → Enumerating subdomains...\n")
-	t := passiveTimeout(config)
-	subScanner := subdomain.NewScanner(domain, 20, t)
-	ctx, cancel := context.WithTimeout(context.Background(), t*4)
-	defer cancel()
-
-	subResults, err := subScanner.Scan(ctx, subdomain.CommonSubdomains)
-	if err == nil && len(subResults) > 0 {
-		subIPs := subScanner.GetAllIPs()
-		fmt.Printf("  → Found %d IPs from %d subdomains\n", len(subIPs), len(subResults))
-		allIPs = append(allIPs, subIPs...)
-	}
-
-	// Phase 2: MX record analysis
-	fmt.Printf("  → Analyzing MX records...\n")
-	mxRecords, err := passivedns.LookupMX(ctx, domain, t)
-	if err == nil && len(mxRecords) > 0 {
-		mxIPs := passivedns.GetAllMXIPs(mxRecords)
-		fmt.Printf("  → Found %d IPs from %d MX records\n", len(mxIPs), len(mxRecords))
-		allIPs = append(allIPs, mxIPs...)
+// printHostnames prints the hostname inventory, names outside CDN ranges first
+// since those may point at the origin
+func printHostnames(out io.Writer, hostnames []core.Hostname) {
+	if len(hostnames) == 0 {
+		return
 	}
-
-	if len(allIPs) == 0 {
-		return []string{}, fmt.Errorf("no IPs discovered from DNS enumeration")
+	fmt.Fprintf(out, "%s[*] Hostnames (%d):%s\n", colors.CYAN, len(hostnames), colors.NC)
+	for _, cdn := range []bool{false, true} {
+		for _, h := range hostnames {
+			if (h.CDN != "") == cdn {
+				fmt.Fprintf(out, "    %s\n", output.FormatHostname(h))
+			}
+		}
 	}
+}
 
-	// Deduplicate
+// uniquePassiveIPs returns the distinct IP addresses of passive records, in order
+func uniquePassiveIPs(records []core.PassiveIP) []string {
 	seen := make(map[string]bool)
-	var unique []string
-	for _, ip := range allIPs {
-		if !seen[ip] {
-			seen[ip] = true
-			unique = append(unique, ip)
+	var ips []string
+	for _, r := range records {
+		if !seen[r.IP] {
+			seen[r.IP] = true
+			ips = append(ips, r.IP)
 		}
 	}
-
-	return unique, nil
+	return ips
 }
 
-// queryShodan searches Shodan for IPs hosting the domain
-func queryShodan(domain string, config *core.Config) ([]string, error) {
-	if len(config.ShodanKeys) == 0 {
-		return []string{}, fmt.Errorf("no Shodan API keys configured")
+// formatPassiveIP formats a ranked passive IP for console output
+func formatPassiveIP(record core.PassiveIP) string {
+	sources := record.Source
+	if len(record.Sources) > 0 {
+		sources = strings.Join(record.Sources, ", ")
 	}
-
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-
-	ips, err := shodan.SearchHostname(ctx, domain, config.ShodanKeys, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("Shodan search failed: %w", err)
+	line := fmt.Sprintf("%-15s  %.2f  (%s) via %s", record.IP, record.Confidence, sources, record.Provenance())
+	if record.Network != "" {
+		line += " [network: " + record.Network + "]"
 	}
-
-	return ips, nil
+	if record.WAF != "" {
+		line += " [WAF: " + record.WAF + "]"
+	}
+	return line
 }
 
-// queryCensys searches Censys for IPs hosting the domain
-func queryCensys(domain string, config *core.Config) ([]string, error) {
-	if len(config.CensysTokens) == 0 {
-		return []string{}, fmt.Errorf("no Censys PAT tokens configured")
+// printHistory prints the merged A-record timelines with the CDN each IP belongs
+// to and, if the domain moved onto a CDN, the IPs that served it last before the move
+func printHistory(out io.Writer, h *history.History, ranges *waf.RangeSet, migration *history.Migration) {
+	if h.Len() == 0 {
+		return
 	}
-
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-
-	ips, err := censys.SearchHosts(ctx, domain, config.CensysTokens, config.CensysOrgID, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("Censys search failed: %w", err)
+	const day = "2006-01-02"
+	isCDN := history.InRanges(ranges)
+
+	fmt.Fprintf(out, "%s[*] A-record history:%s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "    %-15s %-10s  %-10s  %-10s  %-12s  %s\n", "IP", "FIRST SEEN", "LAST SEEN", "BEFORE CDN", "CDN", "SOURCES")
+	for _, timeline := range h.Timelines() {
+		beforeCDN, cdn := "-", "-"
+		if isCDN(timeline) {
+			cdn = cdnProvider(timeline, ranges)
+		} else if migration != nil {
+			if last := timeline.LastSeenBefore(migration.Date); !last.IsZero() {
+				beforeCDN = last.Format(day)
+			}
+		}
+		sources := strings.Join(timeline.Sources(), ", ")
+		if timeline.Organization != "" {
+			sources += " (" + timeline.Organization + ")"
+		}
+		fmt.Fprintf(out, "    %-15s %-10s  %-10s  %-10s  %-12s  %s\n", timeline.IP,
+			timeline.FirstSeen().Format(day), timeline.LastSeen().Format(day), beforeCDN, cdn, sources)
 	}
 
-	return ips, nil
+	if migration != nil {
+		fmt.Fprintf(out, "%s[+] Domain moved onto %s on %s%s\n", colors.GREEN, cdnProvider(migration.CDN, ranges), migration.Date.Format(day), colors.NC)
+		for _, origin := range migration.Origins {
+			fmt.Fprintf(out, "%s[+] Last A record before the CDN: %s (until %s) - checked first%s\n",
+				colors.GREEN, origin.IP, origin.LastSeenBefore(migration.Date).Format(day), colors.NC)
+		}
+	}
+	fmt.Fprintln(out)
 }
 
-// querySecurityTrails searches SecurityTrails for subdomains and historical IPs
-func querySecurityTrails(domain string, config *core.Config) ([]string, error) {
-	if len(config.SecurityTrailsKeys) == 0 {
-		return []string{}, fmt.Errorf("no SecurityTrails API keys configured")
+// cdnProvider names the CDN a timeline's IP belongs to
+func cdnProvider(timeline history.Timeline, ranges *waf.RangeSet) string {
+	if ranges != nil {
+		if provider, ok := ranges.FindProvider(net.ParseIP(timeline.IP)); ok {
+			return provider
+		}
 	}
+	if timeline.Organization != "" {
+		return timeline.Organization
+	}
+	return "a CDN"
+}
 
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-
-	ips, err := securitytrails.SearchSubdomainsAndHistory(ctx, domain, config.SecurityTrailsKeys, t)
+// loadCDNRanges loads every provider's ranges from the WAF database, or returns
+// nil if it cannot be read
+func loadCDNRanges(path string) *waf.RangeSet {
+	db, err := waf.LoadWAFDatabase(path)
 	if err != nil {
-		return []string{}, fmt.Errorf("SecurityTrails search failed: %w", err)
+		return nil
 	}
-
-	return ips, nil
+	ranges, err := waf.LoadFromDatabase(db, nil)
+	if err != nil {
+		return nil
+	}
+	return ranges
 }
 
-// queryZoomEye searches ZoomEye for hosts matching the domain
-func queryZoomEye(domain string, config *core.Config) ([]string, error) {
-	if len(config.ZoomEyeKeys) == 0 {
-		return []string{}, fmt.Errorf("no ZoomEye API keys configured")
+// getEnabledPassiveSources returns list of passive sources to query
+func getEnabledPassiveSources(config *core.Config) []string {
+	// Parse passive sources from config
+	if len(config.PassiveSources) == 0 {
+		return []string{"ct", "dns"} // Default: free sources only
 	}
 
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
+	// PassiveSources is already a slice of strings
+	return config.PassiveSources
+}
 
-	ips, err := zoomeye.SearchHost(ctx, domain, config.ZoomEyeKeys, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("ZoomEye search failed: %w", err)
-	}
+// newKeyManager builds the API key manager from the failover settings and the
+// key usage saved by earlier runs
+func newKeyManager(config *core.Config) *api.Manager {
+	manager := api.NewManager(config.APIFailover.Enabled)
+	manager.SetCooldownPolicy(config.APIFailover.SkipOnRateLimit, config.APIFailover.RetryAfterCooldown, api.DefaultCooldown)
 
-	return ips, nil
+	if path, err := core.GetAPIStatePath(); err == nil {
+		if err := manager.LoadState(path); err != nil && config.Verbose {
+			fmt.Fprintf(os.Stderr, "%s[!] %s%s\n", colors.YELLOW, err, colors.NC)
+		}
+	}
+	return manager
 }
 
-// queryWayback searches the Wayback Machine CDX API for historical subdomains
-func queryWayback(domain string, config *core.Config) ([]string, error) {
-	// Wayback Machine is free - no API key needed
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-
-	ips, err := wayback.SearchSubdomains(ctx, domain, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("Wayback Machine search failed: %w", err)
+// printKeyStatus prints the per-source API key status table
+func printKeyStatus(out io.Writer, reports []api.SourceReport) {
+	if len(reports) == 0 {
+		return
 	}
 
-	return ips, nil
+	fmt.Fprintf(out, "\n%s[*] API key status:%s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "    %-15s %-13s %6s %9s %6s  %s\n", "SOURCE", "STATUS", "KEYS", "REQUESTS", "429s", "NOTE")
+	for _, r := range reports {
+		note := ""
+		switch {
+		case !r.CooldownUntil.IsZero():
+			note = "next key resets " + r.CooldownUntil.Local().Format("2006-01-02 15:04")
+		case r.Status == api.StatusError && r.LastError != nil:
+			note = r.LastError.Error()
+			if len(note) > 60 {
+				note = note[:60] + "..."
+			}
+		}
+		fmt.Fprintf(out, "    %-15s %-13s %6s %9d %6d  %s\n", r.Source, r.Status,
+			fmt.Sprintf("%d/%d", r.Available, r.Keys), r.Requests, r.RateLimits, note)
+	}
+	fmt.Fprintln(out)
 }
 
-// queryVirusTotal searches VirusTotal for subdomains and DNS records
-func queryVirusTotal(domain string, config *core.Config) ([]string, error) {
-	if len(config.VirusTotalKeys) == 0 {
-		return []string{}, fmt.Errorf("no VirusTotal API keys configured")
+// queryPassiveSource queries a specific passive intelligence source
+func queryPassiveSource(out io.Writer, source string, config *core.Config, keys *api.Manager) ([]core.PassiveIP, error) {
+	if !config.Quiet {
+		fmt.Fprintf(out, "%s[*] Querying %s...%s\n", colors.CYAN, source, colors.NC)
 	}
 
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
-
-	ips, err := virustotal.SearchSubdomains(ctx, domain, config.VirusTotalKeys, t)
+	src, err := passive.New(source, config, keys)
 	if err != nil {
-		return []string{}, fmt.Errorf("VirusTotal search failed: %w", err)
+		return nil, err
 	}
-
-	return ips, nil
+	return src.Search(context.Background(), config.Domain)
 }
 
-// queryViewDNS searches ViewDNS reverse IP for related domains
-func queryViewDNS(domain string, config *core.Config) ([]string, error) {
-	if len(config.ViewDNSKeys) == 0 {
-		return []string{}, fmt.Errorf("no ViewDNS API keys configured")
+// savePassiveResults saves discovered IPs to output file, highest confidence first.
+// In CSV the hostname inventory goes to a second file, <name>-hostnames.csv.
+func savePassiveResults(outputPath string, ips []core.PassiveIP, hostnames []core.Hostname, domain string, format core.OutputFormat) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
 	}
+	defer file.Close()
 
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
+	if err := writePassiveResults(file, ips, hostnames, domain, format); err != nil {
+		return err
+	}
+	if format != core.FormatCSV || len(hostnames) == 0 {
+		return nil
+	}
 
-	ips, err := viewdns.SearchReverseIP(ctx, domain, config.ViewDNSKeys, t)
+	hostnamesPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "-hostnames.csv"
+	hostnamesFile, err := os.Create(hostnamesPath)
 	if err != nil {
-		return []string{}, fmt.Errorf("ViewDNS search failed: %w", err)
+		return fmt.Errorf("failed to create hostnames file: %w", err)
 	}
+	defer hostnamesFile.Close()
 
-	return ips, nil
+	return output.WriteHostnamesCSV(hostnamesFile, hostnames)
 }
 
-// queryDNSDumpster scrapes DNSDumpster for subdomains and IPs
-func queryDNSDumpster(domain string, config *core.Config) ([]string, error) {
-	if len(config.DNSDumpsterKeys) == 0 {
-		return []string{}, fmt.Errorf("no DNSDumpster API keys configured")
+// writePassiveResults writes discovered IPs as JSON, CSV or text.
+// In text, scores and the hostname inventory are written as comments so the file
+// can be reused with -i. CSV holds the IPs only.
+func writePassiveResults(w io.Writer, ips []core.PassiveIP, hostnames []core.Hostname, domain string, format core.OutputFormat) error {
+	switch format {
+	case core.FormatJSON:
+		return output.WritePassiveJSON(w, domain, ips, hostnames)
+	case core.FormatCSV:
+		return output.WritePassiveCSV(w, ips)
 	}
 
-	t := passiveTimeout(config)
-	ctx, cancel := context.WithTimeout(context.Background(), t)
-	defer cancel()
+	// Write header
+	fmt.Fprintf(w, "# Passive reconnaissance results for: %s\n", domain)
+	fmt.Fprintf(w, "# Discovered at: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "# Total IPs: %d (ordered by confidence)\n\n", len(ips))
 
-	ips, err := dnsdumpster.SearchDomain(ctx, domain, config.DNSDumpsterKeys, t)
-	if err != nil {
-		return []string{}, fmt.Errorf("DNSDumpster search failed: %w", err)
+	// Write IPs
+	for _, record := range ips {
+		if _, err := fmt.Fprintf(w, "# %s\n%s\n", formatPassiveIP(record), record.IP); err != nil {
+			return err
+		}
 	}
 
-	return ips, nil
+	// Write the hostname inventory
+	if len(hostnames) > 0 {
+		fmt.Fprintf(w, "\n# Hostnames: %d\n", len(hostnames))
+		for _, h := range hostnames {
+			if _, err := fmt.Fprintf(w, "# %s\n", output.FormatHostname(h)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-// savePassiveResults saves discovered IPs to output file
-func savePassiveResults(outputPath string, ips []string, domain string) error {
+// saveReport writes the unified auto mode report to a file
+func saveReport(outputPath string, result *core.ScanResult, format core.OutputFormat) error {
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer file.Close()
 
-	// Write header
-	fmt.Fprintf(file, "# Passive reconnaissance results for: %s\n", domain)
-	fmt.Fprintf(file, "# Discovered at: %s\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(file, "# Total IPs: %d\n\n", len(ips))
-
-	// Write IPs
-	for _, ipAddr := range ips {
-		fmt.Fprintf(file, "%s\n", ipAddr)
-	}
-
-	return nil
+	return output.WriteReport(file, result, format)
 }
 
 // checkForUpdatesAsync checks for updates in the background without blocking
@@ -1309,10 +1640,10 @@ func checkForUpdatesAsync() {
 	}
 
 	if release != nil {
-		fmt.Printf("%s\n╔═══════════════════════════════════════════════════════════╗%s\n", colors.YELLOW, colors.NC)
-		fmt.Printf("%s║  🎉 New version available: v%s (current: v%s)       ║%s\n", colors.YELLOW, release.LatestVersion, version.Version, colors.NC)
-		fmt.Printf("%s║  Run: origindive --update                                 ║%s\n", colors.YELLOW, colors.NC)
-		fmt.Printf("%s╚═══════════════════════════════════════════════════════════╝%s\n\n", colors.YELLOW, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s\n╔═══════════════════════════════════════════════════════════╗%s\n", colors.YELLOW, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s║  🎉 New version available: v%s (current: v%s)       ║%s\n", colors.YELLOW, release.LatestVersion, version.Version, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s║  Run: origindive --update                                 ║%s\n", colors.YELLOW, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s╚═══════════════════════════════════════════════════════════╝%s\n\n", colors.YELLOW, colors.NC)
 		time.Sleep(2 * time.Second) // Let user see the notification
 	}
 }
@@ -1343,8 +1674,8 @@ func autoInitializeGlobalConfig() {
 		// If example doesn't exist, create minimal config
 		minimalConfig := createMinimalGlobalConfig()
 		if err := os.WriteFile(configPath, []byte(minimalConfig), 0600); err == nil {
-			fmt.Printf("%s[+] Created global config: %s%s\n", colors.GREEN, configPath, colors.NC)
-			fmt.Printf("%s[*] Edit this file to add API keys for passive reconnaissance%s\n\n", colors.CYAN, colors.NC)
+			fmt.Fprintf(os.Stderr, "%s[+] Created global config: %s%s\n", colors.GREEN, configPath, colors.NC)
+			fmt.Fprintf(os.Stderr, "%s[*] Edit this file to add API keys for passive reconnaissance%s\n\n", colors.CYAN, colors.NC)
 		}
 		return
 	}
@@ -1356,8 +1687,8 @@ func autoInitializeGlobalConfig() {
 	}
 
 	if err := os.WriteFile(configPath, exampleData, 0600); err == nil {
-		fmt.Printf("%s[+] Created global config: %s%s\n", colors.GREEN, configPath, colors.NC)
-		fmt.Printf("%s[*] Edit this file to add API keys for passive reconnaissance%s\n\n", colors.CYAN, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s[+] Created global config: %s%s\n", colors.GREEN, configPath, colors.NC)
+		fmt.Fprintf(os.Stderr, "%s[*] Edit this file to add API keys for passive reconnaissance%s\n\n", colors.CYAN, colors.NC)
 	}
 }
 
@@ -1526,57 +1857,88 @@ func generateOutputFilename(domain, mode string) string {
 
 // expandIPToCIDR expands a single IP to its CIDR network
 // Example: "192.168.1.5", "/24" -> 192.168.1.0/24 range
-func expandIPToCIDR(ipAddr string, cidrSuffix string) ([2]uint32, error) {
+// IPv4 netmasks don't apply to IPv6, so IPv6 addresses are returned unexpanded
+func expandIPToCIDR(ipAddr string, cidrSuffix string) ([2]netip.Addr, error) {
 	// Parse IP
-	parsedIP := net.ParseIP(ipAddr)
-	if parsedIP == nil {
-		return [2]uint32{}, fmt.Errorf("invalid IP: %s", ipAddr)
+	addr, err := ip.ParseAddr(ipAddr)
+	if err != nil {
+		return [2]netip.Addr{}, fmt.Errorf("invalid IP: %s", ipAddr)
+	}
+	if addr.Is6() {
+		return [2]netip.Addr{addr, addr}, nil
 	}
 
 	// Create CIDR string
-	cidrStr := ipAddr + cidrSuffix // e.g., "192.168.1.5/24"
+	cidrStr := addr.String() + cidrSuffix // e.g., "192.168.1.5/24"
 
 	// Parse CIDR to get network range
-	ipRange, err := ip.ParseCIDRRange(cidrStr)
+	ipRange, err := ip.ParseCIDRAddrRange(cidrStr)
+	if err != nil {
+		return [2]netip.Addr{}, err
+	}
+
+	return ipRange.Pair(), nil
+}
+
+// maxNetworkScanIPs bounds the passive networks auto mode scans in full
+const maxNetworkScanIPs = 1 << 16
+
+// addNetworkRange adds the whole network of a passive record that stands for one
+// (PassiveIP.Network, e.g. an SPF ip4:/16) to the scan ranges. It reports whether
+// the record was a network; networks above maxNetworkScanIPs are skipped with a warning.
+func addNetworkRange(config *core.Config, record core.PassiveIP) bool {
+	network := record.Network
+	if network == "" {
+		return false
+	}
+	r, err := ip.ParseCIDRAddrRange(network)
 	if err != nil {
-		return [2]uint32{}, err
+		return false
 	}
+	if r.Count() > maxNetworkScanIPs {
+		fmt.Fprintf(os.Stderr, "%s[!] Not scanning %s found via %s: more than %d IPs, scan it with -c%s\n", colors.YELLOW, network, record.Provenance(), maxNetworkScanIPs, colors.NC)
+		return true
+	}
+	config.IPRanges = append(config.IPRanges, r.Pair())
+	return true
+}
 
-	return [2]uint32{ipRange.Start, ipRange.End}, nil
+// parsePorts parses a comma-separated port list, dropping duplicates
+func parsePorts(list string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		port, err := strconv.Atoi(field)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("%q is not a valid port (1-65535)", field)
+		}
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	return ports, nil
 }
 
 // deduplicateIPRanges removes overlapping and duplicate IP ranges
-func deduplicateIPRanges(ranges [][2]uint32) [][2]uint32 {
+func deduplicateIPRanges(ranges [][2]netip.Addr) [][2]netip.Addr {
 	if len(ranges) <= 1 {
 		return ranges
 	}
 
-	// Sort ranges by start IP
-	sort.Slice(ranges, func(i, j int) bool {
-		return ranges[i][0] < ranges[j][0]
-	})
-
-	// Merge overlapping ranges
-	merged := [][2]uint32{ranges[0]}
-
-	for i := 1; i < len(ranges); i++ {
-		current := ranges[i]
-		last := &merged[len(merged)-1]
-
-		// Check if current range overlaps or is adjacent to last range
-		if current[0] <= last[1]+1 {
-			// Merge: extend last range if current ends later
-			if current[1] > last[1] {
-				last[1] = current[1]
-			}
-			// If current is completely contained in last, do nothing
-		} else {
-			// No overlap: add as new range
-			merged = append(merged, current)
-		}
+	merged := ip.MergeAddrRanges(ip.RangesFromPairs(ranges))
+	pairs := make([][2]netip.Addr, len(merged))
+	for i, r := range merged {
+		pairs[i] = r.Pair()
 	}
-
-	return merged
+	return pairs
 }
 
 // getRerunCommand generates a rerun command suggestion
@@ -1600,8 +1962,8 @@ func getRerunCommand(config *core.Config) string {
 // checkDomainWAF checks if a domain's current IP is behind WAF/CDN
 func checkDomainWAF(domain, wafDBPath string) (bool, string) {
 	// Resolve domain to IP
-	ips, err := net.LookupIP(domain)
-	if err != nil || len(ips) == 0 {
+	addrs, err := resolver.Default().LookupHost(context.Background(), domain)
+	if err != nil || len(addrs) == 0 {
 		return false, ""
 	}
 
@@ -1617,44 +1979,69 @@ func checkDomainWAF(domain, wafDBPath string) (bool, string) {
 		rangeSet.AddProvider(&db.Providers[i])
 	}
 
-	// Check each resolved IP
-	for _, ip := range ips {
-		// Only check IPv4
-		if ipv4 := ip.To4(); ipv4 != nil {
-			if providerID, found := rangeSet.FindProvider(ipv4); found {
-				// Get provider name
-				if provider := db.GetProvider(providerID); provider != nil {
-					return true, provider.Name
-				}
-				return true, providerID
+	// Check each resolved IP (A and AAAA)
+	for _, addr := range addrs {
+		if providerID, found := rangeSet.FindProvider(net.ParseIP(addr)); found {
+			// Get provider name
+			if provider := db.GetProvider(providerID); provider != nil {
+				return true, provider.Name
 			}
+			return true, providerID
 		}
 	}
 
 	return false, ""
 }
 
-func printBanner(config *core.Config) {
-	fmt.Println()
-	fmt.Printf("%s           _      _         ___         %s\n", colors.CYAN, colors.NC)
-	fmt.Printf("%s ___  ____(_)__ _(_)__  ___/ (_)  _____ %s\n", colors.CYAN, colors.NC)
-	fmt.Printf("%s/ _ \\/ __/ / _ `/ / _ \\/ _  / / |/ / -_)%s\n", colors.CYAN, colors.NC)
-	fmt.Printf("%s\\___/_/ /_/\\_, /_/_//_/\\_,_/_/|___/\\__/ %s\n", colors.CYAN, colors.NC)
-	fmt.Printf("%s          /___/                         %s\n", colors.CYAN, colors.NC)
-	fmt.Println()
-	fmt.Printf("%sv%s - Origin IP Discovery Tool%s\n", colors.BOLD, version.Version, colors.NC)
+func printBanner(out io.Writer, config *core.Config) {
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%s           _      _         ___         %s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "%s ___  ____(_)__ _(_)__  ___/ (_)  _____ %s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "%s/ _ \\/ __/ / _ `/ / _ \\/ _  / / |/ / -_)%s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "%s\\___/_/ /_/\\_, /_/_//_/\\_,_/_/|___/\\__/ %s\n", colors.CYAN, colors.NC)
+	fmt.Fprintf(out, "%s          /___/                         %s\n", colors.CYAN, colors.NC)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%sv%s - Origin IP Discovery Tool%s\n", colors.BOLD, version.Version, colors.NC)
 	if config.SkipWAF {
-		fmt.Printf("%sWAF Filtering: ENABLED%s\n", colors.GREEN, colors.NC)
+		fmt.Fprintf(out, "%sWAF Filtering: ENABLED%s\n", colors.GREEN, colors.NC)
 	}
-	fmt.Printf("%s[*]%s Domain: %s\n", colors.BLUE, colors.NC, config.Domain)
+	fmt.Fprintf(out, "%s[*]%s Domain: %s\n", colors.BLUE, colors.NC, config.Domain)
 
 	// Check if domain is behind WAF/CDN
 	if behindWAF, provider := checkDomainWAF(config.Domain, config.WAFDatabasePath); behindWAF {
-		fmt.Printf("%s[!]%s Domain appears to be behind %s%s%s\n", colors.YELLOW, colors.NC, colors.BOLD, provider, colors.NC)
+		fmt.Fprintf(out, "%s[!]%s Domain appears to be behind %s%s%s\n", colors.YELLOW, colors.NC, colors.BOLD, provider, colors.NC)
 	}
 
-	fmt.Printf("%s[*]%s Mode: %s\n", colors.BLUE, colors.NC, config.Mode)
-	fmt.Printf("%s[*]%s Workers: %d\n", colors.BLUE, colors.NC, config.Workers)
-	fmt.Printf("%s[*]%s Timeout: %s\n", colors.BLUE, colors.NC, config.Timeout)
-	fmt.Println()
+	fmt.Fprintf(out, "%s[*]%s Mode: %s\n", colors.BLUE, colors.NC, config.Mode)
+	fmt.Fprintf(out, "%s[*]%s Workers: %d\n", colors.BLUE, colors.NC, config.Workers)
+	fmt.Fprintf(out, "%s[*]%s Timeout: %s\n", colors.BLUE, colors.NC, config.Timeout)
+	if servers := resolver.Default().Servers(); len(servers) > 0 {
+		names := make([]string, len(servers))
+		for i, server := range servers {
+			names[i] = server.String()
+		}
+		fmt.Fprintf(out, "%s[*]%s Resolvers: %s\n", colors.BLUE, colors.NC, strings.Join(names, ", "))
+	}
+	if config.Mode != core.ModePassive && (config.RateLimit > 0 || config.AdaptiveRate) {
+		rate := config.RateLimit
+		if rate <= 0 {
+			rate = scanner.DefaultAdaptiveRate
+		}
+		mode := ""
+		if config.AdaptiveRate {
+			mode = " (adaptive)"
+		}
+		fmt.Fprintf(out, "%s[*]%s Rate limit: %g req/s%s\n", colors.BLUE, colors.NC, rate, mode)
+	}
+	if config.Mode != core.ModePassive && config.Scheme != "" && config.Scheme != core.SchemeHTTP {
+		fmt.Fprintf(out, "%s[*]%s Scheme: %s\n", colors.BLUE, colors.NC, config.Scheme)
+	}
+	if config.Mode != core.ModePassive && len(config.Ports) > 0 {
+		portList := make([]string, len(config.Ports))
+		for i, port := range config.Ports {
+			portList[i] = strconv.Itoa(port)
+		}
+		fmt.Fprintf(out, "%s[*]%s Ports: %s\n", colors.BLUE, colors.NC, strings.Join(portList, ","))
+	}
+	fmt.Fprintln(out)
 }
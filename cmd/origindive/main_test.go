@@ -1,7 +1,14 @@
 package main
 
 import (
+	"errors"
+	"net/netip"
+	"reflect"
 	"testing"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/ip"
+	"github.com/jhaxce/origindive/v3/pkg/scanner"
 )
 
 // TestMain_BasicUsage tests basic command invocation
@@ -24,6 +31,132 @@ func TestHelp_Flag(t *testing.T) {
 	t.Log("Help flag test placeholder")
 }
 
+// TestParsePorts tests port list parsing
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{"80", []int{80}, false},
+		{"80,443,8080", []int{80, 443, 8080}, false},
+		{" 443 , 8443 ,443", []int{443, 8443}, false},
+		{"", nil, true},
+		{"0", nil, true},
+		{"65536", nil, true},
+		{"80,http", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePorts(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePorts(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePorts(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestResumeConfig tests that a resumed scan keeps its saved settings
+func TestResumeConfig(t *testing.T) {
+	addr := netip.MustParseAddr("192.0.2.1")
+	checkpoint := &scanner.Checkpoint{Config: &core.Config{
+		Domain:   "example.com",
+		Mode:     core.ModeAuto,
+		Ports:    []int{443},
+		Workers:  50,
+		IPRanges: [][2]netip.Addr{{addr, addr}},
+	}}
+
+	cli := &core.Config{ResumeFile: "scan.json", Quiet: true, Format: core.FormatJSON, Workers: 10}
+	config, err := resumeConfig(checkpoint, cli)
+	if err != nil {
+		t.Fatalf("resumeConfig() error = %v", err)
+	}
+	if config.Mode != core.ModeAuto || config.Workers != 50 || !reflect.DeepEqual(config.Ports, []int{443}) || len(config.IPRanges) != 1 {
+		t.Errorf("resumeConfig() = %+v, want the saved scan settings and mode", config)
+	}
+	if config.CheckpointFile != "scan.json" || !config.Quiet || config.Format != core.FormatJSON {
+		t.Errorf("resumeConfig() = %+v, want checkpointing to scan.json with the CLI output options", config)
+	}
+
+	cli.Domain = "other.com"
+	if _, err := resumeConfig(checkpoint, cli); err == nil {
+		t.Error("resumeConfig() should reject a different domain")
+	}
+}
+
+// TestResumeConfig_Headers tests that a resumed scan needs its custom headers again
+func TestResumeConfig_Headers(t *testing.T) {
+	checkpoint := &scanner.Checkpoint{
+		Config:      &core.Config{Domain: "example.com"},
+		HeaderNames: []string{"Authorization"},
+	}
+
+	cli := &core.Config{ResumeFile: "scan.json"}
+	if _, err := resumeConfig(checkpoint, cli); err == nil {
+		t.Error("resumeConfig() should require the saved headers")
+	}
+
+	cli.Headers = []string{"authorization: Bearer token"}
+	config, err := resumeConfig(checkpoint, cli)
+	if err != nil {
+		t.Fatalf("resumeConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(config.Headers, cli.Headers) {
+		t.Errorf("Headers = %v, want %v", config.Headers, cli.Headers)
+	}
+}
+
 // Note: Testing main() directly is challenging because it calls os.Exit()
 // Best practice is to extract logic into testable functions and test those
 // For now, these placeholder tests ensure the package compiles
+
+// TestAddNetworkRange tests that auto mode scans passive records standing for a network
+func TestAddNetworkRange(t *testing.T) {
+	config := &core.Config{}
+	single := core.PassiveIP{IP: "192.0.2.1", Source: "dns"}
+	network := core.PassiveIP{IP: "203.0.112.0", Source: "mail", Network: "203.0.112.0/23"}
+	huge := core.PassiveIP{IP: "10.0.0.0", Source: "mail", Network: "10.0.0.0/8"}
+
+	if addNetworkRange(config, single) {
+		t.Error("addNetworkRange() should not handle a single IP record")
+	}
+	if !addNetworkRange(config, network) || !addNetworkRange(config, huge) {
+		t.Error("addNetworkRange() should handle network records")
+	}
+	want := [][2]netip.Addr{{netip.MustParseAddr("203.0.112.0"), netip.MustParseAddr("203.0.113.255")}}
+	if !reflect.DeepEqual(config.IPRanges, want) {
+		t.Errorf("IPRanges = %v, want only the /23", config.IPRanges)
+	}
+}
+
+// TestCheckRangeSize tests that ranges too large to finish are rejected
+func TestCheckRangeSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		wantErr bool
+	}{
+		{"single /24", []string{"192.0.2.0/24"}, false},
+		{"whole IPv4 space", []string{"0.0.0.0/0"}, false},
+		{"IPv6 /64", []string{"2001:db8::/64"}, true},
+		{"IPv6 /96 plus more", []string{"2001:db8::/96", "192.0.2.0/24"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges [][2]netip.Addr
+			for _, cidr := range tt.cidrs {
+				p := netip.MustParsePrefix(cidr)
+				ranges = append(ranges, ip.PrefixRange(p).Pair())
+			}
+			err := checkRangeSize(ranges, true)
+			if tt.wantErr != errors.Is(err, core.ErrRangeTooLarge) {
+				t.Errorf("checkRangeSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,7 +3,11 @@ package core
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
 	"os"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -30,12 +34,12 @@ type Config struct {
 	Mode ScanMode `yaml:"mode" json:"mode"` // passive, active, auto
 
 	// IP ranges for active scan
-	IPRanges  [][2]uint32 `yaml:"-" json:"-"` // Computed from inputs
-	StartIP   string      `yaml:"start_ip" json:"start_ip"`
-	EndIP     string      `yaml:"end_ip" json:"end_ip"`
-	CIDR      string      `yaml:"cidr" json:"cidr"`
-	InputFile string      `yaml:"input_file" json:"input_file"`
-	ASN       string      `yaml:"asn" json:"asn"` // ASN lookup (e.g., "AS4775" or "4775")
+	IPRanges  [][2]netip.Addr `yaml:"-" json:"-"` // Computed from inputs (IPv4 or IPv6 start/end pairs)
+	StartIP   string          `yaml:"start_ip" json:"start_ip"`
+	EndIP     string          `yaml:"end_ip" json:"end_ip"`
+	CIDR      string          `yaml:"cidr" json:"cidr"`
+	InputFile string          `yaml:"input_file" json:"input_file"`
+	ASN       string          `yaml:"asn" json:"asn"` // ASN lookup (e.g., "AS4775" or "4775")
 
 	// CIDR expansion for auto mode
 	ExpandNetmask string `yaml:"expand_netmask" json:"expand_netmask"` // e.g., "/24" or "24"
@@ -44,12 +48,15 @@ type Config struct {
 	HTTPMethod     string        `yaml:"http_method" json:"http_method"`
 	Timeout        time.Duration `yaml:"timeout" json:"timeout"`
 	ConnectTimeout time.Duration `yaml:"connect_timeout" json:"connect_timeout"`
-	CustomHeader   string        `yaml:"custom_header" json:"custom_header"`
+	Headers        []string      `yaml:"headers" json:"headers"`               // Extra request headers as "Name: Value"
+	Scheme         Scheme        `yaml:"scheme" json:"scheme"`                 // Probe scheme: http, https, both
+	Ports          []int         `yaml:"ports" json:"ports"`                   // Ports probed per IP (empty = scheme default port)
 	UserAgent      string        `yaml:"user_agent" json:"user_agent"`         // Custom UA: "random", "chrome", "firefox", etc., or custom string
 	NoUserAgent    bool          `yaml:"no_user_agent" json:"no_user_agent"`   // Disable User-Agent header entirely
 	MaxRedirects   int           `yaml:"max_redirects" json:"max_redirects"`   // Maximum redirects to follow (0=disabled, >0=enabled, default: 3)
 	VerifyContent  bool          `yaml:"verify_content" json:"verify_content"` // Extract title and hash response
 	FilterUnique   bool          `yaml:"filter_unique" json:"filter_unique"`   // Show only unique responses
+	Baseline       bool          `yaml:"baseline" json:"baseline"`             // Fetch the live site as a reference profile (also with VerifyContent)
 
 	// Proxy configuration
 	ProxyURL    string `yaml:"proxy_url" json:"proxy_url"`       // Single proxy URL (http://IP:PORT, socks5://IP:PORT)
@@ -57,12 +64,22 @@ type Config struct {
 	ProxyRotate bool   `yaml:"proxy_rotate" json:"proxy_rotate"` // Rotate through proxy list
 	ProxyTest   bool   `yaml:"proxy_test" json:"proxy_test"`     // Test proxy before use (default: true)
 
+	// DNS resolution (every hostname lookup goes through these)
+	Resolvers  []string      `yaml:"resolvers" json:"resolvers"`     // IP[:port], udp://, tcp:// or https:// DoH resolvers used round-robin (empty = system)
+	DNSTimeout time.Duration `yaml:"dns_timeout" json:"dns_timeout"` // Per-query DNS timeout
+
 	// Webshare.io premium proxy configuration
 	WebshareAPIKey string `yaml:"webshare_api_key" json:"webshare_api_key"` // Webshare.io API token
 	WebsharePlanID string `yaml:"webshare_plan_id" json:"webshare_plan_id"` // Optional plan ID for download endpoint
 
 	// Performance
-	Workers int `yaml:"workers" json:"workers"`
+	Workers      int     `yaml:"workers" json:"workers"`
+	RateLimit    float64 `yaml:"rate_limit" json:"rate_limit"`       // Maximum requests per second across all workers (0 = unlimited)
+	AdaptiveRate bool    `yaml:"adaptive_rate" json:"adaptive_rate"` // Slow down when timeouts/errors spike, speed up when they clear
+
+	// Checkpointing (resumable active scans)
+	CheckpointFile string `yaml:"checkpoint_file" json:"checkpoint_file"` // State file saved periodically during active scans
+	ResumeFile     string `yaml:"-" json:"-"`                             // Checkpoint to continue from (--resume)
 
 	// WAF filtering
 	SkipWAF         bool     `yaml:"skip_waf" json:"skip_waf"`
@@ -77,6 +94,14 @@ type Config struct {
 	AutoScan       bool     `yaml:"auto_scan" json:"auto_scan"`
 	MinConfidence  float64  `yaml:"min_confidence" json:"min_confidence"`
 	PassiveSources []string `yaml:"passive_sources" json:"passive_sources"`
+	CTLogs         []string `yaml:"ct_logs" json:"ct_logs"`           // RFC 6962 log URLs read when crt.sh fails (empty = built-in list)
+	CTWindow       uint64   `yaml:"ct_window" json:"ct_window"`       // Newest entries read from each CT log (0 = default)
+	Wordlist       string   `yaml:"wordlist" json:"wordlist"`         // Subdomain wordlist streamed by the dns source (empty = built-in list)
+	Permutations   bool     `yaml:"permutations" json:"permutations"` // Also resolve permutations of discovered subdomains
+	EMLFile        string   `yaml:"eml" json:"eml"`                   // Mail message whose Received headers the mail source reads
+
+	// Key rotation and rate-limit handling for keyed passive sources
+	APIFailover APIFailoverConfig `yaml:"api_failover" json:"api_failover"`
 
 	// API Keys for passive sources (flat structure for easier YAML editing)
 	ShodanKeys         []string `yaml:"shodan_keys" json:"shodan_keys"`
@@ -98,6 +123,7 @@ type Config struct {
 	NoColor      bool         `yaml:"no_color" json:"no_color"`
 	NoProgress   bool         `yaml:"no_progress" json:"no_progress"`
 	SilentErrors bool         `yaml:"silent_errors" json:"silent_errors"` // Suppress passive source API error warnings
+	Status       io.Writer    `yaml:"-" json:"-"`                         // Where passive sources print progress lines (nil = stdout)
 }
 
 // ScanMode represents the scanning mode
@@ -109,6 +135,15 @@ const (
 	ModeAuto    ScanMode = "auto"    // Passive then active
 )
 
+// Scheme represents the protocol used to probe candidate IPs
+type Scheme string
+
+const (
+	SchemeHTTP  Scheme = "http"  // Plain HTTP only
+	SchemeHTTPS Scheme = "https" // HTTPS with the target domain as TLS SNI
+	SchemeBoth  Scheme = "both"  // HTTPS first, falling back to HTTP
+)
+
 // OutputFormat represents the output format
 type OutputFormat string
 
@@ -125,12 +160,18 @@ func DefaultConfig() *Config {
 		HTTPMethod:     "GET",
 		Timeout:        5 * time.Second,
 		ConnectTimeout: 3 * time.Second,
+		DNSTimeout:     3 * time.Second,
+		Scheme:         SchemeHTTP,
 		Workers:        10,
 		MaxRedirects:   0,
 		Format:         FormatText,
 		MinConfidence:  0.7,
 		// Use all passive sources by default (filtered by API key availability)
 		PassiveSources: []string{"ct", "dns", "shodan", "censys", "securitytrails", "zoomeye", "wayback", "virustotal", "viewdns", "dnsdumpster"},
+		APIFailover: APIFailoverConfig{
+			Enabled:         true,
+			SkipOnRateLimit: true,
+		},
 	}
 }
 
@@ -146,6 +187,22 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	switch c.Scheme {
+	case "", SchemeHTTP, SchemeHTTPS, SchemeBoth:
+	default:
+		return ErrInvalidScheme
+	}
+
+	for _, port := range c.Ports {
+		if port < 1 || port > 65535 {
+			return ErrInvalidPort
+		}
+	}
+
+	if _, err := ParseHeaders(c.Headers); err != nil {
+		return err
+	}
+
 	if c.Workers < 1 {
 		c.Workers = 1
 	}
@@ -154,6 +211,10 @@ func (c *Config) Validate() error {
 		return ErrTooManyWorkers
 	}
 
+	if c.RateLimit < 0 {
+		return ErrInvalidRateLimit
+	}
+
 	return nil
 }
 
@@ -202,15 +263,30 @@ func (c *Config) MergeWithCLI(cli *Config) {
 	if cli.ConnectTimeout != 0 && cli.ConnectTimeout != 3*time.Second {
 		c.ConnectTimeout = cli.ConnectTimeout
 	}
-	if cli.CustomHeader != "" {
-		c.CustomHeader = cli.CustomHeader
+	if len(cli.Headers) > 0 {
+		c.Headers = cli.Headers
+	}
+	if cli.Scheme != "" { // empty unless --scheme was given
+		c.Scheme = cli.Scheme
+	}
+	if len(cli.Ports) > 0 {
+		c.Ports = cli.Ports
 	}
 	if cli.NoUserAgent {
 		c.NoUserAgent = cli.NoUserAgent
 	}
+	if cli.Baseline {
+		c.Baseline = cli.Baseline
+	}
 	if cli.Workers != 0 && cli.Workers != 10 {
 		c.Workers = cli.Workers
 	}
+	if cli.RateLimit != 0 {
+		c.RateLimit = cli.RateLimit
+	}
+	if cli.AdaptiveRate {
+		c.AdaptiveRate = cli.AdaptiveRate
+	}
 	if cli.SkipWAF {
 		c.SkipWAF = cli.SkipWAF
 	}
@@ -238,7 +314,34 @@ func (c *Config) MergeWithCLI(cli *Config) {
 	if len(cli.PassiveSources) > 0 {
 		c.PassiveSources = cli.PassiveSources
 	}
+	if len(cli.CTLogs) > 0 {
+		c.CTLogs = cli.CTLogs
+	}
+	if cli.CTWindow != 0 {
+		c.CTWindow = cli.CTWindow
+	}
+	if cli.Wordlist != "" {
+		c.Wordlist = cli.Wordlist
+	}
+	if cli.Permutations {
+		c.Permutations = cli.Permutations
+	}
+	if cli.EMLFile != "" {
+		c.EMLFile = cli.EMLFile
+	}
+	if len(cli.Resolvers) > 0 {
+		c.Resolvers = cli.Resolvers
+	}
+	if cli.DNSTimeout != 0 && cli.DNSTimeout != 3*time.Second {
+		c.DNSTimeout = cli.DNSTimeout
+	}
 	// Note: API keys now loaded from global config only, not CLI
+	if cli.CheckpointFile != "" {
+		c.CheckpointFile = cli.CheckpointFile
+	}
+	if cli.ResumeFile != "" {
+		c.ResumeFile = cli.ResumeFile
+	}
 	if cli.OutputFile != "" {
 		c.OutputFile = cli.OutputFile
 	}
@@ -261,3 +364,35 @@ func (c *Config) MergeWithCLI(cli *Config) {
 		c.NoProgress = cli.NoProgress
 	}
 }
+
+// ParseHeaders parses "Name: Value" header lines into an http.Header
+// Repeated names are all sent. Host cannot be set: it is always the target domain.
+func ParseHeaders(lines []string) (http.Header, error) {
+	headers := make(http.Header, len(lines))
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || !validHeaderName(name) || strings.ContainsAny(value, "\r\n\x00") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHeader, line)
+		}
+		if strings.EqualFold(name, "Host") {
+			return nil, fmt.Errorf("%w: %q (Host is always the target domain)", ErrInvalidHeader, line)
+		}
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// validHeaderName reports whether name is a non-empty RFC 7230 token
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > '~' || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -22,6 +23,9 @@ func TestDefaultConfig(t *testing.T) {
 	if config.ConnectTimeout != 3*time.Second {
 		t.Errorf("ConnectTimeout = %v, want 3s", config.ConnectTimeout)
 	}
+	if config.DNSTimeout != 3*time.Second {
+		t.Errorf("DNSTimeout = %v, want 3s", config.DNSTimeout)
+	}
 	if config.Workers != 10 {
 		t.Errorf("Workers = %d, want 10", config.Workers)
 	}
@@ -31,6 +35,9 @@ func TestDefaultConfig(t *testing.T) {
 	if config.MinConfidence != 0.7 {
 		t.Errorf("MinConfidence = %f, want 0.7", config.MinConfidence)
 	}
+	if config.Scheme != SchemeHTTP {
+		t.Errorf("Scheme = %s, want %s", config.Scheme, SchemeHTTP)
+	}
 }
 
 func TestValidate(t *testing.T) {
@@ -62,6 +69,42 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: ErrTooManyWorkers,
 		},
+		{
+			name: "Invalid scheme",
+			config: &Config{
+				Domain: "example.com",
+				Mode:   ModePassive,
+				Scheme: "ftp",
+			},
+			wantErr: ErrInvalidScheme,
+		},
+		{
+			name: "Invalid port",
+			config: &Config{
+				Domain: "example.com",
+				Mode:   ModePassive,
+				Ports:  []int{443, 70000},
+			},
+			wantErr: ErrInvalidPort,
+		},
+		{
+			name: "Invalid header",
+			config: &Config{
+				Domain:  "example.com",
+				Mode:    ModePassive,
+				Headers: []string{"X-Forwarded-For 1.2.3.4"},
+			},
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name: "Negative rate limit",
+			config: &Config{
+				Domain:    "example.com",
+				Mode:      ModePassive,
+				RateLimit: -1,
+			},
+			wantErr: ErrInvalidRateLimit,
+		},
 		{
 			name: "Passive mode without IP range",
 			config: &Config{
@@ -83,7 +126,7 @@ func TestValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.config.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -220,6 +263,26 @@ func TestMergeWithCLI_EmptyCLIDoesNotOverride(t *testing.T) {
 	}
 }
 
+func TestMergeWithCLI_Scheme(t *testing.T) {
+	fileConfig := &Config{Scheme: SchemeBoth}
+
+	fileConfig.MergeWithCLI(&Config{})
+	if fileConfig.Scheme != SchemeBoth {
+		t.Errorf("Scheme = %s, want both (unset CLI scheme should not override)", fileConfig.Scheme)
+	}
+
+	fileConfig.MergeWithCLI(&Config{Scheme: SchemeHTTPS})
+	if fileConfig.Scheme != SchemeHTTPS {
+		t.Errorf("Scheme = %s, want https (from CLI)", fileConfig.Scheme)
+	}
+
+	// An explicit --scheme http overrides a config file that sets https
+	fileConfig.MergeWithCLI(&Config{Scheme: SchemeHTTP})
+	if fileConfig.Scheme != SchemeHTTP {
+		t.Errorf("Scheme = %s, want http (from CLI)", fileConfig.Scheme)
+	}
+}
+
 func TestOutputFormat(t *testing.T) {
 	if FormatText != "text" {
 		t.Errorf("FormatText = %s, want text", FormatText)
@@ -296,15 +359,19 @@ func TestMergeWithCLI_StringFields(t *testing.T) {
 	fileConfig.Domain = "original.com"
 
 	cliConfig := &Config{
-		Mode:          ModeAuto,
-		StartIP:       "192.0.2.1",
-		EndIP:         "192.0.2.254",
-		CIDR:          "192.0.2.0/24",
-		InputFile:     "/path/to/input.txt",
-		CustomHeader:  "X-Custom: value",
-		CustomWAFFile: "/path/to/waf.json",
-		OutputFile:    "/path/to/output.txt",
-		HTTPMethod:    "POST",
+		Mode:           ModeAuto,
+		StartIP:        "192.0.2.1",
+		EndIP:          "192.0.2.254",
+		CIDR:           "192.0.2.0/24",
+		InputFile:      "/path/to/input.txt",
+		Headers:        []string{"Authorization: Bearer token", "Cookie: a=1"},
+		CustomWAFFile:  "/path/to/waf.json",
+		OutputFile:     "/path/to/output.txt",
+		HTTPMethod:     "POST",
+		CheckpointFile: "/path/to/scan.checkpoint.json",
+		ResumeFile:     "/path/to/old.checkpoint.json",
+		RateLimit:      25,
+		AdaptiveRate:   true,
 	}
 
 	fileConfig.MergeWithCLI(cliConfig)
@@ -324,8 +391,8 @@ func TestMergeWithCLI_StringFields(t *testing.T) {
 	if fileConfig.InputFile != "/path/to/input.txt" {
 		t.Errorf("InputFile = %s", fileConfig.InputFile)
 	}
-	if fileConfig.CustomHeader != "X-Custom: value" {
-		t.Errorf("CustomHeader = %s", fileConfig.CustomHeader)
+	if len(fileConfig.Headers) != 2 || fileConfig.Headers[1] != "Cookie: a=1" {
+		t.Errorf("Headers = %v", fileConfig.Headers)
 	}
 	if fileConfig.CustomWAFFile != "/path/to/waf.json" {
 		t.Errorf("CustomWAFFile = %s", fileConfig.CustomWAFFile)
@@ -336,6 +403,12 @@ func TestMergeWithCLI_StringFields(t *testing.T) {
 	if fileConfig.HTTPMethod != "POST" {
 		t.Errorf("HTTPMethod = %s, want POST", fileConfig.HTTPMethod)
 	}
+	if fileConfig.CheckpointFile != "/path/to/scan.checkpoint.json" || fileConfig.ResumeFile != "/path/to/old.checkpoint.json" {
+		t.Errorf("CheckpointFile/ResumeFile = %s/%s", fileConfig.CheckpointFile, fileConfig.ResumeFile)
+	}
+	if fileConfig.RateLimit != 25 || !fileConfig.AdaptiveRate {
+		t.Errorf("RateLimit/AdaptiveRate = %v/%v, want 25/true", fileConfig.RateLimit, fileConfig.AdaptiveRate)
+	}
 }
 
 func TestMergeWithCLI_SliceFields(t *testing.T) {
@@ -364,6 +437,11 @@ func TestMergeWithCLI_TimeoutFields(t *testing.T) {
 	cliConfig := &Config{
 		Timeout:        10 * time.Second, // Different from default 5s
 		ConnectTimeout: 5 * time.Second,  // Different from default 3s
+		DNSTimeout:     8 * time.Second,  // Different from default 3s
+		Resolvers:      []string{"9.9.9.9", "https://dns.example/dns-query"},
+		Wordlist:       "subdomains.txt",
+		Permutations:   true,
+		EMLFile:        "order.eml",
 	}
 
 	fileConfig.MergeWithCLI(cliConfig)
@@ -374,6 +452,18 @@ func TestMergeWithCLI_TimeoutFields(t *testing.T) {
 	if fileConfig.ConnectTimeout != 5*time.Second {
 		t.Errorf("ConnectTimeout = %v, want 5s", fileConfig.ConnectTimeout)
 	}
+	if fileConfig.DNSTimeout != 8*time.Second {
+		t.Errorf("DNSTimeout = %v, want 8s", fileConfig.DNSTimeout)
+	}
+	if len(fileConfig.Resolvers) != 2 {
+		t.Errorf("Resolvers = %v, want the CLI resolvers", fileConfig.Resolvers)
+	}
+	if fileConfig.Wordlist != "subdomains.txt" || !fileConfig.Permutations {
+		t.Errorf("Wordlist/Permutations = %q/%v, want the CLI values", fileConfig.Wordlist, fileConfig.Permutations)
+	}
+	if fileConfig.EMLFile != "order.eml" {
+		t.Errorf("EMLFile = %q, want the CLI value", fileConfig.EMLFile)
+	}
 }
 
 func TestMergeWithCLI_MinConfidence(t *testing.T) {
@@ -422,3 +512,34 @@ func TestPassiveConfig_Structure(t *testing.T) {
 		t.Errorf("CensysTokens length = %d, want 1", len(config.CensysTokens))
 	}
 }
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{
+		"Authorization: Bearer abc:def",
+		"x-forwarded-for:  203.0.113.7 ",
+		"Cookie: a=1",
+		"Cookie: b=2",
+		"X-Empty:",
+	})
+	if err != nil {
+		t.Fatalf("ParseHeaders() error: %v", err)
+	}
+	if got := headers.Get("Authorization"); got != "Bearer abc:def" {
+		t.Errorf("Authorization = %q, want value after the first colon", got)
+	}
+	if got := headers.Get("X-Forwarded-For"); got != "203.0.113.7" {
+		t.Errorf("X-Forwarded-For = %q, want canonicalized and trimmed", got)
+	}
+	if got := headers.Values("Cookie"); len(got) != 2 {
+		t.Errorf("Cookie = %v, want both values", got)
+	}
+	if _, ok := headers["X-Empty"]; !ok {
+		t.Error("empty header value should be kept")
+	}
+
+	for _, line := range []string{"NoColon", ": value", "Bad Name: x", "X-Test: a\r\nInjected: b", "Host: evil.com"} {
+		if _, err := ParseHeaders([]string{line}); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("ParseHeaders(%q) error = %v, want ErrInvalidHeader", line, err)
+		}
+	}
+}
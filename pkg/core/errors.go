@@ -21,4 +21,31 @@ var (
 
 	// ErrInvalidConfig is returned when configuration is invalid
 	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrInvalidScheme is returned when the probe scheme is not http, https or both
+	ErrInvalidScheme = errors.New("invalid scheme (expected http, https or both)")
+
+	// ErrInvalidPort is returned when a probe port is outside 1-65535
+	ErrInvalidPort = errors.New("invalid port (expected 1-65535)")
+
+	// ErrUnknownSource is returned when a passive source name is not registered
+	ErrUnknownSource = errors.New("unknown passive source")
+
+	// ErrScanInterrupted is returned when a checkpointed scan is cancelled before finishing
+	ErrScanInterrupted = errors.New("scan interrupted")
+
+	// ErrInvalidRateLimit is returned when the request rate limit is negative
+	ErrInvalidRateLimit = errors.New("invalid rate limit (expected requests per second >= 0)")
+
+	// ErrInvalidHeader is returned when a custom header is not in "Name: Value" form
+	ErrInvalidHeader = errors.New("invalid header (expected \"Name: Value\")")
+
+	// ErrUnsupportedPivot is returned when a passive source cannot search on a pivot kind
+	ErrUnsupportedPivot = errors.New("unsupported pivot")
+
+	// ErrInvalidResolver is returned when a DNS resolver is not an IP[:port] or a udp://, tcp:// or https:// address
+	ErrInvalidResolver = errors.New("invalid resolver (expected IP[:port], udp://, tcp:// or https:// address)")
+
+	// ErrRangeTooLarge is returned when the IP ranges to scan are too large to finish
+	ErrRangeTooLarge = errors.New("IP range too large to scan")
 )
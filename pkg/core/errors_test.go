@@ -17,6 +17,15 @@ func TestErrors(t *testing.T) {
 		{"ErrInvalidCIDR", ErrInvalidCIDR, "invalid CIDR notation"},
 		{"ErrInvalidIP", ErrInvalidIP, "invalid IP address"},
 		{"ErrInvalidConfig", ErrInvalidConfig, "invalid configuration"},
+		{"ErrInvalidScheme", ErrInvalidScheme, "invalid scheme (expected http, https or both)"},
+		{"ErrInvalidPort", ErrInvalidPort, "invalid port (expected 1-65535)"},
+		{"ErrUnknownSource", ErrUnknownSource, "unknown passive source"},
+		{"ErrScanInterrupted", ErrScanInterrupted, "scan interrupted"},
+		{"ErrInvalidRateLimit", ErrInvalidRateLimit, "invalid rate limit (expected requests per second >= 0)"},
+		{"ErrInvalidHeader", ErrInvalidHeader, "invalid header (expected \"Name: Value\")"},
+		{"ErrUnsupportedPivot", ErrUnsupportedPivot, "unsupported pivot"},
+		{"ErrInvalidResolver", ErrInvalidResolver, "invalid resolver (expected IP[:port], udp://, tcp:// or https:// address)"},
+		{"ErrRangeTooLarge", ErrRangeTooLarge, "IP range too large to scan"},
 	}
 
 	for _, tt := range tests {
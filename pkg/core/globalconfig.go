@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -35,8 +36,14 @@ type GlobalConfig struct {
 	ConnectTimeout string `yaml:"connect_timeout,omitempty" json:"connect_timeout,omitempty"` // e.g., "3s"
 	NoUserAgent    bool   `yaml:"no_user_agent,omitempty" json:"no_user_agent,omitempty"`
 
+	// DNS resolution (global defaults)
+	Resolvers  []string `yaml:"resolvers,omitempty" json:"resolvers,omitempty"`     // IP[:port], udp://, tcp:// or https:// DoH resolvers
+	DNSTimeout string   `yaml:"dns_timeout,omitempty" json:"dns_timeout,omitempty"` // e.g., "3s"
+
 	// Performance (global defaults)
-	Workers int `yaml:"workers,omitempty" json:"workers,omitempty"`
+	Workers      int     `yaml:"workers,omitempty" json:"workers,omitempty"`
+	RateLimit    float64 `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"` // Requests per second (0 = unlimited)
+	AdaptiveRate bool    `yaml:"adaptive_rate,omitempty" json:"adaptive_rate,omitempty"`
 
 	// WAF filtering (global defaults)
 	SkipWAF       bool     `yaml:"skip_waf,omitempty" json:"skip_waf,omitempty"`
@@ -46,6 +53,8 @@ type GlobalConfig struct {
 
 	// Passive scan (global defaults)
 	PassiveSources []string `yaml:"passive_sources,omitempty" json:"passive_sources,omitempty"`
+	CTLogs         []string `yaml:"ct_logs,omitempty" json:"ct_logs,omitempty"`     // RFC 6962 logs read when crt.sh fails
+	CTWindow       uint64   `yaml:"ct_window,omitempty" json:"ct_window,omitempty"` // Newest entries read from each CT log
 	MinConfidence  float64  `yaml:"min_confidence,omitempty" json:"min_confidence,omitempty"`
 
 	// Output (global defaults)
@@ -89,6 +98,15 @@ func GetGlobalConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.yaml"), nil
 }
 
+// GetAPIStatePath returns the path to the file that remembers API key usage and cooldowns
+func GetAPIStatePath() (string, error) {
+	configPath, err := GetGlobalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), "api_state.json"), nil
+}
+
 // LoadGlobalConfig loads the global configuration from the default location
 func LoadGlobalConfig() (*GlobalConfig, error) {
 	configPath, err := GetGlobalConfigPath()
@@ -259,10 +277,30 @@ func formatGlobalConfigYAML(config *GlobalConfig) string {
 	}
 	sb.WriteString("\n")
 
+	if len(config.Resolvers) > 0 || config.DNSTimeout != "" {
+		sb.WriteString("# DNS Resolution\n")
+		if len(config.Resolvers) > 0 {
+			sb.WriteString("resolvers:\n")
+			for _, resolver := range config.Resolvers {
+				sb.WriteString(fmt.Sprintf("  - %s\n", resolver))
+			}
+		}
+		if config.DNSTimeout != "" {
+			sb.WriteString(fmt.Sprintf("dns_timeout: %s\n", config.DNSTimeout))
+		}
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("# Performance\n")
 	if config.Workers > 0 {
 		sb.WriteString(fmt.Sprintf("workers: %d\n", config.Workers))
 	}
+	if config.RateLimit > 0 {
+		sb.WriteString(fmt.Sprintf("rate_limit: %g\n", config.RateLimit))
+	}
+	if config.AdaptiveRate {
+		sb.WriteString("adaptive_rate: true\n")
+	}
 	sb.WriteString("\n")
 
 	sb.WriteString("# WAF/CDN Filtering\n")
@@ -293,6 +331,15 @@ func formatGlobalConfigYAML(config *GlobalConfig) string {
 	if config.MinConfidence > 0 {
 		sb.WriteString(fmt.Sprintf("min_confidence: %.1f\n", config.MinConfidence))
 	}
+	if len(config.CTLogs) > 0 {
+		sb.WriteString("ct_logs:\n")
+		for _, log := range config.CTLogs {
+			sb.WriteString(fmt.Sprintf("  - %s\n", log))
+		}
+	}
+	if config.CTWindow > 0 {
+		sb.WriteString(fmt.Sprintf("ct_window: %d\n", config.CTWindow))
+	}
 	sb.WriteString("\n")
 
 	sb.WriteString("# Output Settings\n")
@@ -391,10 +438,26 @@ func (gc *GlobalConfig) MergeIntoConfig(c *Config) {
 		c.NoUserAgent = gc.NoUserAgent
 	}
 
+	// DNS resolution
+	if len(c.Resolvers) == 0 && len(gc.Resolvers) > 0 {
+		c.Resolvers = gc.Resolvers
+	}
+	if c.DNSTimeout == 3*time.Second && gc.DNSTimeout != "" { // 3s is package default
+		if timeout, err := time.ParseDuration(gc.DNSTimeout); err == nil && timeout > 0 {
+			c.DNSTimeout = timeout
+		}
+	}
+
 	// Performance
 	if c.Workers == 10 && gc.Workers != 0 { // 10 is package default
 		c.Workers = gc.Workers
 	}
+	if c.RateLimit == 0 && gc.RateLimit > 0 {
+		c.RateLimit = gc.RateLimit
+	}
+	if !c.AdaptiveRate && gc.AdaptiveRate {
+		c.AdaptiveRate = gc.AdaptiveRate
+	}
 
 	// WAF filtering
 	if !c.SkipWAF && gc.SkipWAF {
@@ -414,9 +477,18 @@ func (gc *GlobalConfig) MergeIntoConfig(c *Config) {
 	if len(c.PassiveSources) == 0 && len(gc.PassiveSources) > 0 {
 		c.PassiveSources = gc.PassiveSources
 	}
+	if len(c.CTLogs) == 0 && len(gc.CTLogs) > 0 {
+		c.CTLogs = gc.CTLogs
+	}
+	if c.CTWindow == 0 && gc.CTWindow > 0 {
+		c.CTWindow = gc.CTWindow
+	}
 	if c.MinConfidence == 0.7 && gc.MinConfidence != 0 { // 0.7 is package default
 		c.MinConfidence = gc.MinConfidence
 	}
+	if c.APIFailover == DefaultConfig().APIFailover {
+		c.APIFailover = gc.APIFailover
+	}
 
 	// Output settings
 	if c.Format == "" || c.Format == FormatText {
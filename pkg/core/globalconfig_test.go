@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultGlobalConfig(t *testing.T) {
@@ -134,7 +135,11 @@ func TestMergeIntoConfig(t *testing.T) {
 		Workers:        30,
 		SkipWAF:        true,
 		PassiveSources: []string{"ct", "dns", "shodan"},
+		CTLogs:         []string{"https://ct.example.com/log/"},
+		CTWindow:       50000,
 		MinConfidence:  0.9,
+		Resolvers:      []string{"tcp://9.9.9.9"},
+		DNSTimeout:     "10s",
 	}
 
 	scanConfig := DefaultConfig()
@@ -165,6 +170,18 @@ func TestMergeIntoConfig(t *testing.T) {
 		t.Error("PassiveSources not merged from global config")
 	}
 	// Note: Default config has ["ct", "dns"], merge logic preserves if empty
+	if len(scanConfig.CTLogs) != 1 || scanConfig.CTLogs[0] != "https://ct.example.com/log/" {
+		t.Errorf("CTLogs not merged correctly: %v", scanConfig.CTLogs)
+	}
+	if scanConfig.CTWindow != 50000 {
+		t.Errorf("CTWindow not merged correctly: %d", scanConfig.CTWindow)
+	}
+	if len(scanConfig.Resolvers) != 1 || scanConfig.Resolvers[0] != "tcp://9.9.9.9" {
+		t.Errorf("Resolvers not merged correctly: %v", scanConfig.Resolvers)
+	}
+	if scanConfig.DNSTimeout != 10*time.Second {
+		t.Errorf("DNSTimeout = %v, want 10s (from global)", scanConfig.DNSTimeout)
+	}
 }
 
 func TestMergeIntoConfig_ScanConfigTakesPrecedence(t *testing.T) {
@@ -347,6 +364,7 @@ func TestMergeIntoConfig_AllBranches(t *testing.T) {
 		Verbose:         true,
 		NoColor:         true,
 		NoProgress:      true,
+		APIFailover:     APIFailoverConfig{Enabled: false, RetryAfterCooldown: true},
 	}
 
 	c := DefaultConfig()
@@ -417,6 +435,20 @@ func TestMergeIntoConfig_AllBranches(t *testing.T) {
 	if !c.NoProgress {
 		t.Error("NoProgress not merged")
 	}
+	if c.APIFailover != gc.APIFailover {
+		t.Errorf("APIFailover = %+v, want %+v", c.APIFailover, gc.APIFailover)
+	}
+}
+
+func TestGetAPIStatePath(t *testing.T) {
+	configPath, _ := GetGlobalConfigPath()
+	path, err := GetAPIStatePath()
+	if err != nil {
+		t.Fatalf("GetAPIStatePath() error = %v", err)
+	}
+	if filepath.Dir(path) != filepath.Dir(configPath) || filepath.Base(path) != "api_state.json" {
+		t.Errorf("GetAPIStatePath() = %s, want api_state.json next to %s", path, configPath)
+	}
 }
 
 func TestGetGlobalConfigPath_AllPlatforms(t *testing.T) {
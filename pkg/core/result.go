@@ -19,6 +19,11 @@ type ScanResult struct {
 
 	// Passive scan results (if applicable)
 	PassiveIPs []PassiveIP `json:"passive_ips,omitempty"`
+	Hostnames  []Hostname  `json:"hostnames,omitempty"` // Hostname inventory of the passive scan
+
+	// Passive and active evidence per IP (auto mode)
+	Candidates []Candidate `json:"candidates,omitempty"`
+	Probes     []*IPResult `json:"-"` // Every probe result of an auto mode scan, including those not displayed
 
 	// Summary statistics
 	Summary ScanSummary `json:"summary"`
@@ -43,30 +48,217 @@ type ScanSummary struct {
 
 // IPResult represents the result of scanning a single IP
 type IPResult struct {
-	IP                 string   `json:"ip"`
-	Status             string   `json:"status"` // "200", "3xx", "4xx", "5xx", "timeout", "error", "skipped"
-	HTTPCode           int      `json:"http_code"`
-	ResponseTime       string   `json:"response_time"`
-	BodyHash           string   `json:"body_hash,omitempty"`      // SHA256 hash of response body (first 8KB)
-	Title              string   `json:"title,omitempty"`          // HTML title tag content
-	ContentType        string   `json:"content_type,omitempty"`   // Response Content-Type header
-	Server             string   `json:"server,omitempty"`         // Server header
-	PTR                string   `json:"ptr,omitempty"`            // Reverse DNS PTR record
-	RedirectChain      []string `json:"redirect_chain,omitempty"` // Redirect URLs if --follow-redirect is used
-	Error              string   `json:"error,omitempty"`
-	Provider           string   `json:"provider,omitempty"` // WAF provider if skipped
-	PossibleOrigin     bool     `json:"possible_origin,omitempty"`
-	PossibleOriginDest string   `json:"possible_origin_dest,omitempty"`
+	IP                 string    `json:"ip"`
+	Scheme             string    `json:"scheme,omitempty"` // "http" or "https" - the scheme that answered
+	Port               int       `json:"port,omitempty"`   // Port probed (0 = scheme default)
+	Status             string    `json:"status"`           // "200", "3xx", "4xx", "5xx", "timeout", "error", "skipped"
+	HTTPCode           int       `json:"http_code"`
+	ResponseTime       string    `json:"response_time"`
+	BodyHash           string    `json:"body_hash,omitempty"`        // SHA256 hash of response body (first 8KB)
+	Title              string    `json:"title,omitempty"`            // HTML title tag content
+	FaviconHash        int32     `json:"favicon_hash,omitempty"`     // Shodan-style mmh3 favicon hash
+	Similarity         float64   `json:"similarity,omitempty"`       // Similarity to the live site baseline (0.0 - 1.0)
+	BaselineMatches    []string  `json:"baseline_matches,omitempty"` // Baseline attributes matched: status, title, body, favicon, headers, cookies
+	ContentType        string    `json:"content_type,omitempty"`     // Response Content-Type header
+	Server             string    `json:"server,omitempty"`           // Server header
+	PTR                string    `json:"ptr,omitempty"`              // Reverse DNS PTR record
+	RedirectChain      []string  `json:"redirect_chain,omitempty"`   // Redirect URLs if --follow-redirect is used
+	Error              string    `json:"error,omitempty"`
+	Provider           string    `json:"provider,omitempty"` // WAF provider if skipped or a known edge
+	PossibleOrigin     bool      `json:"possible_origin,omitempty"`
+	PossibleOriginDest string    `json:"possible_origin_dest,omitempty"`
+	Cert               *CertInfo `json:"cert,omitempty"`       // Leaf TLS certificate (HTTPS probes only)
+	CertMatch          bool      `json:"cert_match,omitempty"` // Certificate SANs cover the target domain
+}
+
+// CertInfo describes the leaf certificate presented by an IP during the TLS handshake
+type CertInfo struct {
+	Subject     string    `json:"subject"`        // Subject common name
+	SANs        []string  `json:"sans,omitempty"` // DNS and IP subject alternative names
+	Issuer      string    `json:"issuer"`         // Issuer common name (or full DN if CN is empty)
+	Fingerprint string    `json:"fingerprint"`    // SHA-256 of the DER certificate, hex encoded
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
 }
 
 // PassiveIP represents an IP discovered through passive reconnaissance
 type PassiveIP struct {
 	IP         string                 `json:"ip"`
-	Source     string                 `json:"source"`     // "ct", "dns", "shodan", etc.
-	Confidence float64                `json:"confidence"` // 0.0 - 1.0
+	Source     string                 `json:"source"`             // "ct", "dns", "shodan", etc.
+	Hostname   string                 `json:"hostname,omitempty"` // Hostname that resolved to (or was indexed with) the IP
+	Via        string                 `json:"via,omitempty"`      // How the source found it, e.g. "CT SAN", "MX record"
+	Pivot      string                 `json:"pivot,omitempty"`    // Pivot kind that found it (PivotFavicon, ...); empty for hostname searches
+	Confidence float64                `json:"confidence"`         // 0.0 - 1.0
 	FirstSeen  time.Time              `json:"first_seen"`
 	LastSeen   time.Time              `json:"last_seen"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Sources    []string               `json:"sources,omitempty"`   // Every source that reported the IP (set when ranked)
+	Hostnames  []string               `json:"hostnames,omitempty"` // Every hostname reported with the IP
+	FoundVia   []string               `json:"found_via,omitempty"` // Every distinct provenance of the IP (set when ranked)
+	Network    string                 `json:"network,omitempty"`   // Network the IP stands for, e.g. an SPF ip4:/16
+	WAF        string                 `json:"waf,omitempty"`       // WAF/CDN provider whose ranges contain the IP
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`  // Raw source-specific data (ASN, org, PTR, ...)
+}
+
+// Hostname is one name in the passive hostname inventory
+type Hostname struct {
+	Name    string   `json:"name"`
+	IPs     []string `json:"ips"`           // Addresses sources or DNS associated with the name
+	Sources []string `json:"sources"`       // Sources that found the name
+	CDN     string   `json:"cdn,omitempty"` // WAF/CDN provider when every IP is inside its ranges
+}
+
+// Pivot kinds: attributes of the live site other than its hostname that passive sources search on
+const (
+	PivotFavicon     = "favicon"      // Shodan-style mmh3 favicon hash
+	PivotTitle       = "title"        // HTML title
+	PivotCertSHA256  = "cert_sha256"  // SHA-256 fingerprint of the TLS certificate
+	PivotCertSubject = "cert_subject" // Subject common name of the TLS certificate
+)
+
+// Pivot is an attribute of the live site to search for, e.g. {PivotTitle, "Example Shop"}
+type Pivot struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Provenance describes how the IP was found, e.g. "CT SAN api.example.com"
+func (p PassiveIP) Provenance() string {
+	via := p.Via
+	if via == "" {
+		via = p.Source
+	}
+	if p.Hostname == "" {
+		return via
+	}
+	return via + " " + p.Hostname
+}
+
+// Verdicts on a candidate IP after active probing, strongest first
+const (
+	VerdictConfirmed     = "confirmed"      // Serves the live site (similar content or a redirect back to the domain)
+	VerdictLikely        = "likely"         // Certificate, possible-origin or baseline evidence, but not confirmed
+	VerdictResponding    = "responding"     // Answers HTTP with no evidence tying it to the domain
+	VerdictFalsePositive = "false_positive" // Flagged by verification (shared hosting, PTR mismatch)
+	VerdictUnreachable   = "unreachable"    // Only timeouts or connection errors
+	VerdictNotScanned    = "not_scanned"    // No probe results, e.g. skipped as a WAF IP
+)
+
+// confirmedSimilarity is the live site similarity at which a 200 response confirms an origin
+const confirmedSimilarity = 0.8
+
+// Candidate combines the passive provenance and active results of one IP
+type Candidate struct {
+	IP         string      `json:"ip"`
+	Verdict    string      `json:"verdict"`
+	Confidence float64     `json:"confidence,omitempty"` // Passive confidence (0 if not found passively)
+	Sources    []string    `json:"sources,omitempty"`
+	Hostnames  []string    `json:"hostnames,omitempty"`
+	FoundVia   []string    `json:"found_via,omitempty"`
+	WAF        string      `json:"waf,omitempty"`    // WAF/CDN provider whose ranges contain the IP
+	Probes     []*IPResult `json:"probes,omitempty"` // One result per probed port
+}
+
+// AddPassive records the passive results of an auto mode scan and builds one
+// candidate per IP: every passive IP in ranking order, then every other displayed
+// IP that answered HTTP. Passive IPs are judged on all their probes (Probes, when
+// the scanner recorded them), not only the displayed ones; WAF-skipped probes do
+// not count. Call it after the scan and its verification have finished.
+func (sr *ScanResult) AddPassive(ips []PassiveIP) {
+	sr.PassiveIPs = ips
+
+	var displayed []*IPResult
+	for _, group := range [][]*IPResult{sr.Success, sr.Redirects, sr.Other, sr.Timeouts, sr.Errors} {
+		displayed = append(displayed, group...)
+	}
+	all := sr.Probes
+	if all == nil {
+		all = displayed
+	}
+	probes := make(map[string][]*IPResult)
+	for _, r := range all {
+		if r.Status != "skipped" {
+			probes[r.IP] = append(probes[r.IP], r)
+		}
+	}
+	var answered []string
+	seen := make(map[string]bool)
+	for _, r := range displayed {
+		if !seen[r.IP] && r.Status != "timeout" && r.Status != "error" && r.Status != "skipped" {
+			seen[r.IP] = true
+			answered = append(answered, r.IP)
+		}
+	}
+
+	sr.Candidates = make([]Candidate, 0, len(ips))
+	added := make(map[string]bool)
+	for _, ip := range ips {
+		if added[ip.IP] {
+			continue
+		}
+		added[ip.IP] = true
+		candidate := Candidate{IP: ip.IP, Confidence: ip.Confidence, Probes: probes[ip.IP]}
+		candidate.Sources = ip.Sources
+		if len(candidate.Sources) == 0 {
+			candidate.Sources = []string{ip.Source}
+		}
+		candidate.Hostnames = ip.Hostnames
+		if len(candidate.Hostnames) == 0 && ip.Hostname != "" {
+			candidate.Hostnames = []string{ip.Hostname}
+		}
+		candidate.FoundVia = ip.FoundVia
+		if len(candidate.FoundVia) == 0 {
+			candidate.FoundVia = []string{ip.Provenance()}
+		}
+		candidate.WAF = ip.WAF
+		candidate.Verdict = sr.verdict(candidate.IP, candidate.Probes)
+		sr.Candidates = append(sr.Candidates, candidate)
+	}
+	for _, addr := range answered {
+		if !added[addr] {
+			added[addr] = true
+			sr.Candidates = append(sr.Candidates, Candidate{IP: addr, Probes: probes[addr], Verdict: sr.verdict(addr, probes[addr])})
+		}
+	}
+}
+
+// verdict judges an IP from its probe results and the scan's verification summary
+func (sr *ScanResult) verdict(ip string, probes []*IPResult) string {
+	if len(probes) == 0 {
+		return VerdictNotScanned
+	}
+	for _, flagged := range sr.Summary.FalsePositiveIPs {
+		if flagged == ip {
+			return VerdictFalsePositive
+		}
+	}
+	related := false
+	for _, origin := range sr.Summary.PossibleOriginRelatedIPs {
+		related = related || origin == ip
+	}
+
+	verdict := VerdictUnreachable
+	for _, r := range probes {
+		switch {
+		case r.Status == "200" && (r.Similarity >= confirmedSimilarity || related):
+			return VerdictConfirmed
+		case r.CertMatch || r.PossibleOrigin || matchesContent(r.BaselineMatches):
+			verdict = VerdictLikely
+		case r.Status != "timeout" && r.Status != "error" && verdict == VerdictUnreachable:
+			verdict = VerdictResponding
+		}
+	}
+	return verdict
+}
+
+// matchesContent reports whether a response shares more than its status code with
+// the baseline: every page the site serves with the same code matches on status
+func matchesContent(matches []string) bool {
+	for _, m := range matches {
+		if m != "status" {
+			return true
+		}
+	}
+	return false
 }
 
 // NewScanResult creates a new scan result
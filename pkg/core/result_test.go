@@ -177,6 +177,24 @@ func TestPassiveIP(t *testing.T) {
 	}
 }
 
+func TestPassiveIP_Provenance(t *testing.T) {
+	tests := []struct {
+		ip   PassiveIP
+		want string
+	}{
+		{PassiveIP{Source: "ct", Via: "CT SAN", Hostname: "api.example.com"}, "CT SAN api.example.com"},
+		{PassiveIP{Source: "securitytrails", Via: "historical A record"}, "historical A record"},
+		{PassiveIP{Source: "dns", Hostname: "mail.example.com"}, "dns mail.example.com"},
+		{PassiveIP{Source: "shodan"}, "shodan"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.ip.Provenance(); got != tt.want {
+			t.Errorf("Provenance() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
 func TestScanMode(t *testing.T) {
 	if ModePassive != "passive" {
 		t.Errorf("ModePassive = %s, want passive", ModePassive)
@@ -188,3 +206,105 @@ func TestScanMode(t *testing.T) {
 		t.Errorf("ModeAuto = %s, want auto", ModeAuto)
 	}
 }
+
+func TestAddPassive(t *testing.T) {
+	sr := NewScanResult("example.com", ModeAuto)
+	sr.AddResult(&IPResult{IP: "192.0.2.1", Status: "200", HTTPCode: 200, Similarity: 0.93})
+	sr.AddResult(&IPResult{IP: "192.0.2.2", Status: "4xx", HTTPCode: 403, CertMatch: true, PossibleOrigin: true})
+	sr.AddResult(&IPResult{IP: "192.0.2.2", Port: 8080, Status: "timeout"})
+	sr.AddResult(&IPResult{IP: "192.0.2.3", Status: "200", HTTPCode: 200})
+	sr.AddResult(&IPResult{IP: "192.0.2.4", Status: "error", Error: "connection refused"})
+	sr.AddResult(&IPResult{IP: "192.0.2.50", Status: "200", HTTPCode: 200, BaselineMatches: []string{"status"}}) // Found by CIDR expansion
+	sr.AddResult(&IPResult{IP: "192.0.2.52", Status: "4xx", HTTPCode: 403, BaselineMatches: []string{"status", "title"}})
+	sr.AddResult(&IPResult{IP: "192.0.2.51", Status: "timeout"})
+	sr.Summary.FalsePositiveIPs = []string{"192.0.2.3"}
+
+	passive := []PassiveIP{
+		{IP: "192.0.2.1", Source: "securitytrails", Hostname: "example.com", Via: "historical A record", Confidence: 0.9,
+			Sources:   []string{"dns", "securitytrails"},
+			Hostnames: []string{"example.com", "mail.example.com"},
+			FoundVia:  []string{"historical A record example.com", "MX record mail.example.com"}},
+		{IP: "192.0.2.2", Source: "ct", Hostname: "api.example.com", Via: "CT SAN", Confidence: 0.6},
+		{IP: "192.0.2.3", Source: "shodan", Confidence: 0.5},
+		{IP: "192.0.2.4", Source: "censys", Confidence: 0.4},
+		{IP: "104.16.1.1", Source: "dns", Confidence: 0.3, WAF: "cloudflare"},
+	}
+	sr.AddPassive(passive)
+
+	if len(sr.PassiveIPs) != len(passive) {
+		t.Errorf("PassiveIPs = %d, want %d", len(sr.PassiveIPs), len(passive))
+	}
+	want := []struct {
+		ip      string
+		verdict string
+		probes  int
+	}{
+		{"192.0.2.1", VerdictConfirmed, 1},
+		{"192.0.2.2", VerdictLikely, 2},
+		{"192.0.2.3", VerdictFalsePositive, 1},
+		{"192.0.2.4", VerdictUnreachable, 1},
+		{"104.16.1.1", VerdictNotScanned, 0},
+		{"192.0.2.50", VerdictResponding, 1}, // A status-only baseline match is not evidence
+		{"192.0.2.52", VerdictLikely, 1},
+	}
+	if len(sr.Candidates) != len(want) {
+		t.Fatalf("Candidates = %+v, want %d", sr.Candidates, len(want))
+	}
+	for i, w := range want {
+		c := sr.Candidates[i]
+		if c.IP != w.ip || c.Verdict != w.verdict || len(c.Probes) != w.probes {
+			t.Errorf("candidate %d = %s %s with %d probes, want %s %s with %d", i, c.IP, c.Verdict, len(c.Probes), w.ip, w.verdict, w.probes)
+		}
+	}
+
+	first := sr.Candidates[0]
+	if first.Confidence != 0.9 || len(first.Sources) != 2 || len(first.Hostnames) != 2 || len(first.FoundVia) != 2 {
+		t.Errorf("first candidate provenance = %+v", first)
+	}
+	// Unranked records fall back to their own source, hostname and provenance
+	second := sr.Candidates[1]
+	if second.Sources[0] != "ct" || second.Hostnames[0] != "api.example.com" || second.FoundVia[0] != "CT SAN api.example.com" {
+		t.Errorf("second candidate provenance = %+v", second)
+	}
+	if sr.Candidates[4].WAF != "cloudflare" {
+		t.Errorf("WAF = %q, want cloudflare", sr.Candidates[4].WAF)
+	}
+	if sr.Candidates[5].Sources != nil {
+		t.Errorf("active-only candidate has sources %v", sr.Candidates[5].Sources)
+	}
+}
+
+func TestAddPassive_Probes(t *testing.T) {
+	// Without --show-all only the 200 is displayed, but the scanner recorded every probe
+	sr := NewScanResult("example.com", ModeAuto)
+	ok := &IPResult{IP: "192.0.2.1", Status: "200", HTTPCode: 200}
+	sr.AddResult(ok)
+	sr.Probes = []*IPResult{
+		ok,
+		{IP: "192.0.2.2", Status: "4xx", HTTPCode: 403},
+		{IP: "192.0.2.3", Status: "timeout"},
+		{IP: "192.0.2.4", Status: "skipped", Provider: "cloudflare"},
+		{IP: "192.0.2.50", Status: "3xx", HTTPCode: 301}, // Neither passive nor displayed
+	}
+	sr.AddPassive([]PassiveIP{
+		{IP: "192.0.2.1", Source: "dns"},
+		{IP: "192.0.2.2", Source: "ct"},
+		{IP: "192.0.2.3", Source: "ct"},
+		{IP: "192.0.2.4", Source: "ct"},
+	})
+
+	want := map[string]string{
+		"192.0.2.1": VerdictResponding,
+		"192.0.2.2": VerdictResponding,
+		"192.0.2.3": VerdictUnreachable,
+		"192.0.2.4": VerdictNotScanned,
+	}
+	if len(sr.Candidates) != len(want) {
+		t.Fatalf("Candidates = %+v, want %d", sr.Candidates, len(want))
+	}
+	for _, c := range sr.Candidates {
+		if c.Verdict != want[c.IP] {
+			t.Errorf("%s verdict = %s, want %s", c.IP, c.Verdict, want[c.IP])
+		}
+	}
+}
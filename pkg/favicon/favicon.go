@@ -0,0 +1,179 @@
+// Package favicon computes Shodan-style favicon hashes and locates a site's favicon
+package favicon
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/jhaxce/origindive/v3/internal/version"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+)
+
+// ReadLimit caps the size of a downloaded favicon
+const ReadLimit = 1024 * 1024
+
+// DefaultPath is where browsers look for a favicon when a page links none
+const DefaultPath = "/favicon.ico"
+
+// Hash returns the favicon hash used by Shodan (http.favicon.hash), ZoomEye
+// (iconhash) and Censys (hash_shodan): the signed 32-bit MurmurHash3 of the
+// icon's base64 encoding, wrapped at 76 characters with a trailing newline.
+func Hash(data []byte) int32 {
+	return int32(murmur3([]byte(encodeLines(data)), 0))
+}
+
+// Href returns the icon linked from an HTML page, or DefaultPath
+func Href(body []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return DefaultPath
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if name, hasAttr := z.TagName(); string(name) != "link" || !hasAttr {
+				continue
+			}
+			var rel, href string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = strings.ToLower(string(val))
+				case "href":
+					href = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			for _, field := range strings.Fields(rel) {
+				if field == "icon" && href != "" {
+					return href
+				}
+			}
+		}
+	}
+}
+
+// NewClient returns an HTTP client that accepts any certificate, for fetching
+// pages whose certificate is being inspected rather than trusted
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:     (&net.Dialer{Resolver: resolver.Default().Net()}).DialContext,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+}
+
+// Fetch downloads and hashes the favicon of an already fetched page
+func Fetch(ctx context.Context, client *http.Client, page *url.URL, body []byte) (int32, error) {
+	icon, err := page.Parse(Href(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid favicon URL: %w", err)
+	}
+	resp, data, err := Get(ctx, client, icon.String(), ReadLimit)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK || len(data) == 0 {
+		return 0, fmt.Errorf("no favicon at %s (HTTP %d)", icon, resp.StatusCode)
+	}
+	return Hash(data), nil
+}
+
+// Get performs a GET request and reads up to limit bytes of the body
+func Get(ctx context.Context, client *http.Client, rawURL string, limit int64) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "origindive/"+version.Version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, body, nil
+}
+
+// encodeLines base64-encodes data like Python's base64.encodebytes
+func encodeLines(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var sb strings.Builder
+	for len(encoded) > 76 {
+		sb.WriteString(encoded[:76])
+		sb.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	if encoded != "" {
+		sb.WriteString(encoded)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// murmur3 is MurmurHash3 x86_32
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	h := seed
+	n := len(data)
+	for i := 0; i+4 <= n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[n&^3:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(n)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
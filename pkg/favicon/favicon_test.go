@@ -0,0 +1,117 @@
+package favicon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/internal/version"
+)
+
+func TestMurmur3(t *testing.T) {
+	tests := []struct {
+		input string
+		seed  uint32
+		want  uint32
+	}{
+		{"", 0, 0},
+		{"", 1, 0x514e28b7},
+		{"hello", 0, 0x248bfa47},
+		{"The quick brown fox jumps over the lazy dog", 0, 0x2e4ff723},
+	}
+	for _, tt := range tests {
+		if got := murmur3([]byte(tt.input), tt.seed); got != tt.want {
+			t.Errorf("murmur3(%q, %d) = %#x, want %#x", tt.input, tt.seed, got, tt.want)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	// Reference values from Python: mmh3.hash(base64.encodebytes(data))
+	icon := []byte(strings.Repeat(string(func() []byte {
+		b := make([]byte, 256)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		return b
+	}()), 3))
+	if got := Hash(icon); got != 1836528006 {
+		t.Errorf("Hash(multi-line icon) = %d, want 1836528006", got)
+	}
+	if got := Hash([]byte("icon-bytes")); got != -2059846915 {
+		t.Errorf("Hash(icon-bytes) = %d, want -2059846915", got)
+	}
+}
+
+func TestEncodeLines(t *testing.T) {
+	// 57 bytes encode to exactly one 76-character line
+	if got := encodeLines(make([]byte, 57)); strings.Count(got, "\n") != 1 || len(got) != 77 {
+		t.Errorf("encodeLines(57 bytes) = %d chars with %d newlines, want 77 and 1", len(got), strings.Count(got, "\n"))
+	}
+	if got := encodeLines(make([]byte, 58)); strings.Count(got, "\n") != 2 {
+		t.Errorf("encodeLines(58 bytes) has %d newlines, want 2", strings.Count(got, "\n"))
+	}
+	if got := encodeLines(nil); got != "" {
+		t.Errorf("encodeLines(nil) = %q, want empty", got)
+	}
+}
+
+func TestHref(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`<link rel="stylesheet" href="/a.css"><link rel="icon" href="/img/fav.svg">`, "/img/fav.svg"},
+		{`<LINK REL="Shortcut Icon" HREF="https://cdn.example.com/f.ico"/>`, "https://cdn.example.com/f.ico"},
+		{`<link rel="apple-touch-icon" href="/apple.png">`, DefaultPath},
+		{`not html`, DefaultPath},
+	}
+	for _, tt := range tests {
+		if got := Href([]byte(tt.body)); got != tt.want {
+			t.Errorf("Href(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); ua != "origindive/"+version.Version {
+			t.Errorf("User-Agent = %q, want origindive/%s", ua, version.Version)
+		}
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><link rel="icon" href="/static/icon.png"></head></html>`))
+		case "/static/icon.png":
+			w.Write([]byte("icon-bytes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(5 * time.Second)
+	resp, body, err := Get(context.Background(), client, server.URL+"/", ReadLimit)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	hash, err := Fetch(context.Background(), client, resp.Request.URL, body)
+	if err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	if hash != Hash([]byte("icon-bytes")) {
+		t.Errorf("Fetch() = %d, want the hash of the linked icon", hash)
+	}
+
+	missing := httptest.NewTLSServer(http.NotFoundHandler())
+	defer missing.Close()
+	resp, body, err = Get(context.Background(), client, missing.URL+"/", ReadLimit)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if _, err := Fetch(context.Background(), client, resp.Request.URL, body); err == nil {
+		t.Error("Fetch() without a favicon should fail")
+	}
+}
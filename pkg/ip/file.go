@@ -10,19 +10,19 @@ import (
 
 // ParseInputFile reads IPs, CIDRs, and IP ranges from a file
 // Supports:
-// - Single IPs: 192.168.1.1
-// - CIDR notation: 192.168.1.0/24
-// - IP ranges: 192.168.1.1-192.168.1.254
+// - Single IPs: 192.168.1.1 or 2001:db8::1
+// - CIDR notation: 192.168.1.0/24 or 2001:db8::/120
+// - IP ranges: 192.168.1.1-192.168.1.254 or 2001:db8::1-2001:db8::ff
 // - Comments (lines starting with #)
 // - Blank lines (ignored)
-func ParseInputFile(path string) ([]IPRange, error) {
+func ParseInputFile(path string) ([]AddrRange, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open input file: %w", err)
 	}
 	defer file.Close()
 
-	var ranges []IPRange
+	var ranges []AddrRange
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
 
@@ -37,7 +37,7 @@ func ParseInputFile(path string) ([]IPRange, error) {
 
 		// Try to parse as CIDR
 		if strings.Contains(line, "/") {
-			r, err := ParseCIDRRange(line)
+			r, err := ParseCIDRAddrRange(line)
 			if err != nil {
 				return nil, fmt.Errorf("line %d: invalid CIDR %q: %w", lineNum, line, err)
 			}
@@ -51,10 +51,8 @@ func ParseInputFile(path string) ([]IPRange, error) {
 			if len(parts) != 2 {
 				return nil, fmt.Errorf("line %d: invalid IP range format %q (expected start-end)", lineNum, line)
 			}
-			startIP := strings.TrimSpace(parts[0])
-			endIP := strings.TrimSpace(parts[1])
 
-			r, err := ParseIPRange(startIP, endIP)
+			r, err := ParseAddrRange(parts[0], parts[1])
 			if err != nil {
 				return nil, fmt.Errorf("line %d: invalid IP range %q: %w", lineNum, line, err)
 			}
@@ -63,18 +61,13 @@ func ParseInputFile(path string) ([]IPRange, error) {
 		}
 
 		// Parse as single IP
-		ipInt, err := ParseIP(line)
+		addr, err := ParseAddr(line)
 		if err != nil {
 			return nil, fmt.Errorf("line %d: invalid IP %q: %w", lineNum, line, err)
 		}
 
-		ipUint32, err := ToUint32(ipInt)
-		if err != nil {
-			return nil, fmt.Errorf("line %d: IP must be IPv4: %w", lineNum, err)
-		}
-
 		// Single IP becomes a range of 1
-		ranges = append(ranges, IPRange{Start: ipUint32, End: ipUint32})
+		ranges = append(ranges, AddrRange{Start: addr, End: addr})
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -94,8 +94,21 @@ func TestParseInputFile(t *testing.T) {
 			wantErr:   true,
 		},
 		{
-			name:      "IPv6 (should fail)",
+			name:      "IPv6 address",
 			content:   `2001:db8::1`,
+			wantCount: 1,
+			wantErr:   false,
+		},
+		{
+			name: "IPv6 CIDR and range",
+			content: `2001:db8::/120
+2001:db8::1-2001:db8::ff`,
+			wantCount: 2,
+			wantErr:   false,
+		},
+		{
+			name:      "mixed family range",
+			content:   `192.168.1.1-2001:db8::1`,
 			wantCount: 0,
 			wantErr:   true,
 		},
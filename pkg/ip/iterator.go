@@ -2,7 +2,10 @@
 package ip
 
 import (
+	"math"
+	"math/bits"
 	"net"
+	"net/netip"
 )
 
 // Iterator provides efficient iteration over IP ranges
@@ -120,3 +123,102 @@ func (it *Iterator) Channel(bufferSize int) <-chan uint32 {
 
 	return ch
 }
+
+// AddrIterator iterates over IPv4 and IPv6 address ranges
+type AddrIterator struct {
+	ranges     []AddrRange
+	current    netip.Addr
+	rangeIndex int
+	totalIPs   uint64
+}
+
+// NewAddrIterator creates a new iterator over address-family independent ranges
+func NewAddrIterator(ranges []AddrRange) *AddrIterator {
+	total := uint64(0)
+	for _, r := range ranges {
+		count := r.Count()
+		if total > math.MaxUint64-count {
+			total = math.MaxUint64
+			break
+		}
+		total += count
+	}
+
+	iter := &AddrIterator{
+		ranges:   ranges,
+		totalIPs: total,
+	}
+
+	if len(ranges) > 0 {
+		iter.current = ranges[0].Start
+	}
+
+	return iter
+}
+
+// TotalIPs returns the total number of IPs in all ranges (saturating at math.MaxUint64)
+func (it *AddrIterator) TotalIPs() uint64 {
+	return it.totalIPs
+}
+
+// MulSaturating returns a*b, saturating at math.MaxUint64 (e.g. TotalIPs() times the port count)
+func MulSaturating(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	if hi != 0 {
+		return math.MaxUint64
+	}
+	return lo
+}
+
+// Next returns the next address in the iteration
+// Returns false when iteration is complete
+func (it *AddrIterator) Next() (netip.Addr, bool) {
+	if it.rangeIndex >= len(it.ranges) {
+		return netip.Addr{}, false
+	}
+
+	addr := it.current
+
+	// Advance to next address, or to the next range once End is reached
+	if it.current.Compare(it.ranges[it.rangeIndex].End) < 0 {
+		it.current = it.current.Next()
+	} else {
+		it.rangeIndex++
+		if it.rangeIndex < len(it.ranges) {
+			it.current = it.ranges[it.rangeIndex].Start
+		}
+	}
+
+	return addr, true
+}
+
+// Skip advances the iterator past the next n addresses
+// Used to resume a scan from a checkpoint without re-probing completed addresses
+func (it *AddrIterator) Skip(n uint64) {
+	for n > 0 && it.rangeIndex < len(it.ranges) {
+		remaining := AddrRange{Start: it.current, End: it.ranges[it.rangeIndex].End}.Count()
+		if n < remaining {
+			it.current = addAddr(it.current, n)
+			return
+		}
+
+		n -= remaining
+		it.rangeIndex++
+		if it.rangeIndex < len(it.ranges) {
+			it.current = it.ranges[it.rangeIndex].Start
+		}
+	}
+}
+
+// HasNext checks if there are more addresses to iterate
+func (it *AddrIterator) HasNext() bool {
+	return it.rangeIndex < len(it.ranges)
+}
+
+// Reset resets the iterator to the beginning
+func (it *AddrIterator) Reset() {
+	it.rangeIndex = 0
+	if len(it.ranges) > 0 {
+		it.current = it.ranges[0].Start
+	}
+}
@@ -1,6 +1,7 @@
 package ip
 
 import (
+	"math"
 	"net"
 	"testing"
 )
@@ -323,3 +324,108 @@ func TestIterator_SingleIPRange(t *testing.T) {
 		t.Errorf("Next() after single IP = %v, want nil", ip2)
 	}
 }
+
+func TestAddrIterator(t *testing.T) {
+	v4, _ := ParseAddrOrCIDR("192.0.2.254-192.0.3.1")
+	v6, _ := ParseAddrOrCIDR("2001:db8::fffe-2001:db8::1:1")
+
+	it := NewAddrIterator([]AddrRange{*v4, *v6})
+	if it.TotalIPs() != 8 {
+		t.Errorf("TotalIPs() = %d, want 8", it.TotalIPs())
+	}
+
+	want := []string{
+		"192.0.2.254", "192.0.2.255", "192.0.3.0", "192.0.3.1",
+		"2001:db8::fffe", "2001:db8::ffff", "2001:db8::1:0", "2001:db8::1:1",
+	}
+	for i, w := range want {
+		addr, ok := it.Next()
+		if !ok {
+			t.Fatalf("Next() ended after %d addresses, want %d", i, len(want))
+		}
+		if addr.String() != w {
+			t.Errorf("Next() #%d = %s, want %s", i, addr, w)
+		}
+	}
+	if _, ok := it.Next(); ok || it.HasNext() {
+		t.Error("iterator should be exhausted")
+	}
+
+	it.Reset()
+	if addr, ok := it.Next(); !ok || addr.String() != "192.0.2.254" {
+		t.Errorf("Next() after Reset() = %s, %v; want 192.0.2.254", addr, ok)
+	}
+}
+
+func TestAddrIterator_SaturatingTotal(t *testing.T) {
+	huge, _ := ParseCIDRAddrRange("2001:db8::/32")
+	it := NewAddrIterator([]AddrRange{*huge, *huge})
+	if it.TotalIPs() != math.MaxUint64 {
+		t.Errorf("TotalIPs() = %d, want MaxUint64", it.TotalIPs())
+	}
+}
+
+func TestMulSaturating(t *testing.T) {
+	tests := []struct {
+		a, b, want uint64
+	}{
+		{256, 3, 768},
+		{0, math.MaxUint64, 0},
+		{math.MaxUint64, 1, math.MaxUint64},
+		{math.MaxUint64, 2, math.MaxUint64},
+		{1 << 63, 2, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		if got := MulSaturating(tt.a, tt.b); got != tt.want {
+			t.Errorf("MulSaturating(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAddrIterator_Empty(t *testing.T) {
+	it := NewAddrIterator(nil)
+	if _, ok := it.Next(); ok {
+		t.Error("Next() on empty iterator returned ok")
+	}
+}
+
+func TestAddrIterator_Skip(t *testing.T) {
+	v4, _ := ParseAddrOrCIDR("192.0.2.254-192.0.3.1")
+	v6, _ := ParseAddrOrCIDR("2001:db8::fffe-2001:db8::1:1")
+
+	tests := []struct {
+		skip uint64
+		want string // "" means exhausted
+	}{
+		{0, "192.0.2.254"},
+		{2, "192.0.3.0"},
+		{3, "192.0.3.1"},
+		{4, "2001:db8::fffe"},
+		{6, "2001:db8::1:0"},
+		{8, ""},
+		{100, ""},
+	}
+
+	for _, tt := range tests {
+		it := NewAddrIterator([]AddrRange{*v4, *v6})
+		it.Skip(tt.skip)
+		addr, ok := it.Next()
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Skip(%d) then Next() = %s, want exhausted", tt.skip, addr)
+			}
+			continue
+		}
+		if !ok || addr.String() != tt.want {
+			t.Errorf("Skip(%d) then Next() = %s, %v; want %s", tt.skip, addr, ok, tt.want)
+		}
+	}
+
+	// Skipping inside a huge IPv6 range lands on the exact offset
+	huge, _ := ParseCIDRAddrRange("2001:db8::/32")
+	it := NewAddrIterator([]AddrRange{*huge})
+	it.Skip(1 << 40)
+	if addr, _ := it.Next(); addr.String() != "2001:db8::100:0:0" {
+		t.Errorf("Skip(1<<40) then Next() = %s, want 2001:db8::100:0:0", addr)
+	}
+}
@@ -0,0 +1,212 @@
+// Package ip provides address-family independent IP ranges
+package ip
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// AddrRange represents an inclusive IPv4 or IPv6 range
+// Both ends are always of the same address family
+type AddrRange struct {
+	Start netip.Addr
+	End   netip.Addr
+}
+
+// ParseAddr parses an IPv4 or IPv6 address string
+// IPv4-mapped IPv6 addresses (::ffff:a.b.c.d) are unmapped to plain IPv4
+func ParseAddr(ipStr string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ipStr))
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, core.ErrInvalidIP
+	}
+	return addr.Unmap(), nil
+}
+
+// AddrFromIP converts a net.IP to a netip.Addr, unmapping IPv4-mapped addresses
+func AddrFromIP(ip net.IP) (netip.Addr, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// ParseAddrRange parses start and end IP addresses into a range
+func ParseAddrRange(startIP, endIP string) (*AddrRange, error) {
+	start, err := ParseAddr(startIP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start IP: %w", err)
+	}
+
+	end, err := ParseAddr(endIP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end IP: %w", err)
+	}
+
+	if start.Is4() != end.Is4() {
+		return nil, fmt.Errorf("start and end IP must be the same address family")
+	}
+
+	if start.Compare(end) > 0 {
+		return nil, fmt.Errorf("start IP is greater than end IP")
+	}
+
+	return &AddrRange{Start: start, End: end}, nil
+}
+
+// ParseCIDRAddrRange parses an IPv4 or IPv6 CIDR into a range
+// Like ParseCIDRRange, network and broadcast addresses are included
+func ParseCIDRAddrRange(cidr string) (*AddrRange, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, core.ErrInvalidCIDR
+	}
+	r := PrefixRange(prefix)
+	return &r, nil
+}
+
+// PrefixRange returns the full address range covered by a prefix
+func PrefixRange(prefix netip.Prefix) AddrRange {
+	prefix = prefix.Masked()
+	start := prefix.Addr()
+
+	// Set every host bit of the network address to get the last address
+	bytes := start.AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 0x80 >> (bit % 8)
+	}
+	end, _ := netip.AddrFromSlice(bytes)
+
+	return AddrRange{Start: start, End: end}
+}
+
+// ParseAddrOrCIDR parses a single IP, a CIDR, or a start-end range
+func ParseAddrOrCIDR(input string) (*AddrRange, error) {
+	input = strings.TrimSpace(input)
+
+	if strings.Contains(input, "/") {
+		return ParseCIDRAddrRange(input)
+	}
+
+	if strings.Contains(input, "-") {
+		parts := strings.Split(input, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid IP range format %q (expected start-end)", input)
+		}
+		return ParseAddrRange(parts[0], parts[1])
+	}
+
+	addr, err := ParseAddr(input)
+	if err != nil {
+		return nil, err
+	}
+	return &AddrRange{Start: addr, End: addr}, nil
+}
+
+// ToAddrRange converts an IPv4 uint32 range to an AddrRange
+func (r *IPRange) ToAddrRange() AddrRange {
+	return AddrRange{Start: addrFromUint32(r.Start), End: addrFromUint32(r.End)}
+}
+
+// addrFromUint32 converts a uint32 to an IPv4 netip.Addr
+func addrFromUint32(n uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+// addAddr returns the address n positions after addr (without overflow checks)
+func addAddr(addr netip.Addr, n uint64) netip.Addr {
+	size := addr.BitLen() / 8
+	sum := new(big.Int).SetBytes(addr.AsSlice())
+	sum.Add(sum, new(big.Int).SetUint64(n))
+	next, _ := netip.AddrFromSlice(sum.FillBytes(make([]byte, size)))
+	return next
+}
+
+// Is4 reports whether the range holds IPv4 addresses
+func (r AddrRange) Is4() bool {
+	return r.Start.Is4()
+}
+
+// Contains checks if an address is within the range
+func (r AddrRange) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.Is4() != r.Is4() {
+		return false
+	}
+	return r.Start.Compare(addr) <= 0 && addr.Compare(r.End) <= 0
+}
+
+// Count returns the number of addresses in the range
+// IPv6 ranges larger than 2^64-1 addresses saturate at math.MaxUint64
+func (r AddrRange) Count() uint64 {
+	start := new(big.Int).SetBytes(r.Start.AsSlice())
+	end := new(big.Int).SetBytes(r.End.AsSlice())
+	count := end.Sub(end, start)
+	count.Add(count, big.NewInt(1))
+	if !count.IsUint64() {
+		return math.MaxUint64
+	}
+	return count.Uint64()
+}
+
+// String returns the range as "start-end" (or a single address)
+func (r AddrRange) String() string {
+	if r.Start == r.End {
+		return r.Start.String()
+	}
+	return r.Start.String() + "-" + r.End.String()
+}
+
+// MergeAddrRanges sorts ranges and merges overlapping or adjacent ones
+// IPv4 ranges sort before IPv6 ranges
+func MergeAddrRanges(ranges []AddrRange) []AddrRange {
+	if len(ranges) <= 1 {
+		return ranges
+	}
+
+	sorted := make([]AddrRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Is4() != sorted[j].Is4() {
+			return sorted[i].Is4()
+		}
+		return sorted[i].Start.Less(sorted[j].Start)
+	})
+
+	merged := []AddrRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		next := last.End.Next()
+		if r.Is4() == last.Is4() && (r.Start.Compare(last.End) <= 0 || (next.IsValid() && r.Start == next)) {
+			if r.End.Compare(last.End) > 0 {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// RangesFromPairs converts config-style [start, end] pairs into ranges
+func RangesFromPairs(pairs [][2]netip.Addr) []AddrRange {
+	ranges := make([]AddrRange, len(pairs))
+	for i, p := range pairs {
+		ranges[i] = AddrRange{Start: p[0], End: p[1]}
+	}
+	return ranges
+}
+
+// Pair returns the range as a config-style [start, end] pair
+func (r AddrRange) Pair() [2]netip.Addr {
+	return [2]netip.Addr{r.Start, r.End}
+}
@@ -0,0 +1,210 @@
+package ip
+
+import (
+	"math"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"192.0.2.1", "192.0.2.1", false},
+		{" 2001:db8::1 ", "2001:db8::1", false},
+		{"::ffff:192.0.2.1", "192.0.2.1", false}, // IPv4-mapped is unmapped
+		{"fe80::1%eth0", "", true},               // zones are not scannable targets
+		{"999.1.1.1", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAddr(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAddr(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.String() != tt.want {
+			t.Errorf("ParseAddr(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseCIDRAddrRange(t *testing.T) {
+	tests := []struct {
+		cidr      string
+		wantStart string
+		wantEnd   string
+		wantCount uint64
+		wantErr   bool
+	}{
+		{"192.168.1.0/24", "192.168.1.0", "192.168.1.255", 256, false},
+		{"192.168.1.77/30", "192.168.1.76", "192.168.1.79", 4, false},
+		{"10.0.0.1/32", "10.0.0.1", "10.0.0.1", 1, false},
+		{"2001:db8::/120", "2001:db8::", "2001:db8::ff", 256, false},
+		{"2001:db8::1/128", "2001:db8::1", "2001:db8::1", 1, false},
+		{"2001:db8::/32", "2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", math.MaxUint64, false},
+		{"192.168.1.0/33", "", "", 0, true},
+		{"not-a-cidr", "", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			r, err := ParseCIDRAddrRange(tt.cidr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCIDRAddrRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if r.Start.String() != tt.wantStart || r.End.String() != tt.wantEnd {
+				t.Errorf("range = %s, want %s-%s", r, tt.wantStart, tt.wantEnd)
+			}
+			if r.Count() != tt.wantCount {
+				t.Errorf("Count() = %d, want %d", r.Count(), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestParseAddrRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   string
+		end     string
+		wantErr bool
+	}{
+		{"IPv4", "192.0.2.1", "192.0.2.10", false},
+		{"IPv6", "2001:db8::1", "2001:db8::10", false},
+		{"mixed families", "192.0.2.1", "2001:db8::1", true},
+		{"reversed", "2001:db8::10", "2001:db8::1", true},
+		{"invalid start", "bogus", "192.0.2.1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAddrRange(tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseAddrRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseAddrOrCIDR(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantCount uint64
+		wantErr   bool
+	}{
+		{"192.0.2.1", 1, false},
+		{"2001:db8::1", 1, false},
+		{"192.0.2.0/29", 8, false},
+		{"2001:db8::1-2001:db8::4", 4, false},
+		{"1.1.1.1-2.2.2.2-3.3.3.3", 0, true},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseAddrOrCIDR(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAddrOrCIDR(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && r.Count() != tt.wantCount {
+			t.Errorf("ParseAddrOrCIDR(%q).Count() = %d, want %d", tt.input, r.Count(), tt.wantCount)
+		}
+	}
+}
+
+func TestAddrRange_Contains(t *testing.T) {
+	v4, _ := ParseCIDRAddrRange("192.0.2.0/24")
+	v6, _ := ParseCIDRAddrRange("2001:db8::/64")
+
+	tests := []struct {
+		r    *AddrRange
+		addr string
+		want bool
+	}{
+		{v4, "192.0.2.55", true},
+		{v4, "::ffff:192.0.2.55", true},
+		{v4, "192.0.3.1", false},
+		{v6, "2001:db8::abcd", true},
+		{v6, "2001:db9::1", false},
+		{v6, "192.0.2.55", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("%s.Contains(%s) = %v, want %v", tt.r, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestIPRange_ToAddrRange(t *testing.T) {
+	r := IPRange{Start: 0xC0000201, End: 0xC000020A} // 192.0.2.1-192.0.2.10
+	got := r.ToAddrRange()
+	if got.String() != "192.0.2.1-192.0.2.10" {
+		t.Errorf("ToAddrRange() = %s, want 192.0.2.1-192.0.2.10", got)
+	}
+	if got.Count() != r.Count() {
+		t.Errorf("Count() = %d, want %d", got.Count(), r.Count())
+	}
+}
+
+func TestAddrFromIP(t *testing.T) {
+	addr, ok := AddrFromIP(net.ParseIP("192.0.2.1")) // 16-byte form
+	if !ok || !addr.Is4() {
+		t.Errorf("AddrFromIP(192.0.2.1) = %s, %v; want IPv4", addr, ok)
+	}
+	if _, ok := AddrFromIP(nil); ok {
+		t.Error("AddrFromIP(nil) ok = true, want false")
+	}
+}
+
+func TestMergeAddrRanges(t *testing.T) {
+	ranges := []AddrRange{
+		mustParseRange(t, "2001:db8::10-2001:db8::20"),
+		mustParseRange(t, "192.0.2.5-192.0.2.10"),
+		mustParseRange(t, "2001:db8::21"), // adjacent to the IPv6 range
+		mustParseRange(t, "192.0.2.1-192.0.2.6"),
+		mustParseRange(t, "198.51.100.1"),
+		mustParseRange(t, "255.255.255.255"),
+	}
+
+	merged := MergeAddrRanges(ranges)
+	want := []string{
+		"192.0.2.1-192.0.2.10",
+		"198.51.100.1",
+		"255.255.255.255",
+		"2001:db8::10-2001:db8::21",
+	}
+
+	if len(merged) != len(want) {
+		t.Fatalf("MergeAddrRanges() = %v, want %v", merged, want)
+	}
+	for i, r := range merged {
+		if r.String() != want[i] {
+			t.Errorf("merged[%d] = %s, want %s", i, r, want[i])
+		}
+	}
+}
+
+func TestRangesFromPairs(t *testing.T) {
+	r := mustParseRange(t, "2001:db8::/126")
+	ranges := RangesFromPairs([][2]netip.Addr{r.Pair()})
+	if len(ranges) != 1 || ranges[0] != r {
+		t.Errorf("RangesFromPairs(Pair()) = %v, want [%s]", ranges, r)
+	}
+}
+
+func mustParseRange(t *testing.T, s string) AddrRange {
+	t.Helper()
+	r, err := ParseAddrOrCIDR(s)
+	if err != nil {
+		t.Fatalf("ParseAddrOrCIDR(%q): %v", s, err)
+	}
+	return *r
+}
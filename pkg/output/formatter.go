@@ -5,7 +5,9 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jhaxce/origindive/v3/pkg/core"
@@ -68,7 +70,7 @@ func (f *Formatter) FormatResult(result core.IPResult) string {
 		data, _ := json.Marshal(result)
 		return string(data)
 	case core.FormatCSV:
-		return fmt.Sprintf("%s,%s,%d,%s", result.IP, result.Status, result.HTTPCode, result.Error)
+		return strings.Join(csvRecord(result), ",")
 	default:
 		return f.formatTextResult(result)
 	}
@@ -79,13 +81,15 @@ func (f *Formatter) formatTextResult(result core.IPResult) string {
 	switch result.Status {
 	case "200":
 		msg := fmt.Sprintf("%s[+]%s %s --> %s200 OK%s (%s)",
-			f.green, f.nc, result.IP, f.green, f.nc, result.ResponseTime)
+			f.green, f.nc, formatTarget(result), f.green, f.nc, result.ResponseTime)
 
 		// Add title if available
 		if result.Title != "" {
 			msg += fmt.Sprintf(" | %s\"%s\"%s", f.cyan, result.Title, f.nc)
 		}
 
+		msg += f.formatCert(result)
+
 		// // Add PTR if available
 		// if result.PTR != "" {
 		// 	msg += fmt.Sprintf(" | %sPTR:%s %s", f.yellow, f.nc, result.PTR)
@@ -96,6 +100,8 @@ func (f *Formatter) formatTextResult(result core.IPResult) string {
 			msg += fmt.Sprintf(" [%s%s%s]", f.magenta, result.BodyHash, f.nc)
 		}
 
+		msg += f.formatBaseline(result)
+
 		// Add redirect chain if available
 		if len(result.RedirectChain) > 0 {
 			msg += fmt.Sprintf("\n%s    Redirect chain:%s", f.yellow, f.nc)
@@ -106,11 +112,13 @@ func (f *Formatter) formatTextResult(result core.IPResult) string {
 
 		return msg
 	case "3xx":
-		if !f.showAll {
+		if !f.showAll && !result.CertMatch {
 			return ""
 		}
 		msg := fmt.Sprintf("%s[>]%s %s --> HTTP %d (Redirect)",
-			f.yellow, f.nc, result.IP, result.HTTPCode)
+			f.yellow, f.nc, formatTarget(result), result.HTTPCode)
+		msg += f.formatCert(result)
+		msg += f.formatBaseline(result)
 
 		// Add redirect chain if available
 		if len(result.RedirectChain) > 0 {
@@ -126,20 +134,71 @@ func (f *Formatter) formatTextResult(result core.IPResult) string {
 			return ""
 		}
 		return fmt.Sprintf("%s[~]%s %s --> Timeout",
-			f.blue, f.nc, result.IP)
+			f.blue, f.nc, formatTarget(result))
 	case "error":
 		if !f.showAll {
 			return ""
 		}
 		return fmt.Sprintf("%s[-]%s %s --> Error: %s",
-			f.red, f.nc, result.IP, result.Error)
+			f.red, f.nc, formatTarget(result), result.Error)
 	default:
-		if !f.showAll {
+		if !f.showAll && !result.CertMatch {
 			return ""
 		}
 		return fmt.Sprintf("%s[~]%s %s --> HTTP %d",
-			f.cyan, f.nc, result.IP, result.HTTPCode)
+			f.cyan, f.nc, formatTarget(result), result.HTTPCode) + f.formatCert(result) + f.formatBaseline(result)
+	}
+}
+
+// formatCert formats the TLS certificate suffix of a result line
+func (f *Formatter) formatCert(result core.IPResult) string {
+	if result.Cert == nil {
+		return ""
+	}
+	if result.CertMatch {
+		return fmt.Sprintf(" | %sTLS: %s (cert matches domain)%s", f.green, result.Cert.Subject, f.nc)
 	}
+	return fmt.Sprintf(" | TLS: %s", result.Cert.Subject)
+}
+
+// formatBaseline formats how a result compares with the live site baseline
+func (f *Formatter) formatBaseline(result core.IPResult) string {
+	var parts []string
+	if result.Similarity > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% similar", result.Similarity*100))
+	}
+	if len(result.BaselineMatches) > 0 {
+		parts = append(parts, "matches "+strings.Join(result.BaselineMatches, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	color := f.yellow
+	if result.Similarity >= 0.8 {
+		color = f.green
+	}
+	return fmt.Sprintf(" | %slive site: %s%s", color, strings.Join(parts, ", "), f.nc)
+}
+
+// formatTarget returns the display form of a result's target.
+// Plain HTTP results keep the bare IP; HTTPS results carry the scheme prefix.
+// Non-default ports are appended as ip:port (IPv6 addresses are bracketed).
+func formatTarget(result core.IPResult) string {
+	target := result.IP
+	defaultPort := 80
+	if result.Scheme == "https" {
+		defaultPort = 443
+	}
+	if result.Port != 0 && result.Port != defaultPort {
+		target = net.JoinHostPort(result.IP, strconv.Itoa(result.Port))
+	} else if result.Scheme == "https" && strings.Contains(result.IP, ":") {
+		target = "[" + result.IP + "]"
+	}
+	if result.Scheme == "https" {
+		return "https://" + target
+	}
+	return target
 }
 
 // FormatSummary formats the final scan summary
@@ -274,26 +333,31 @@ func (f *Formatter) formatTextSummary(summary core.ScanSummary) string {
 
 // FormatCSVHeader returns CSV header row
 func (f *Formatter) FormatCSVHeader() string {
-	return "IP,Status,HTTPCode,ResponseTime,Error\n"
+	return strings.Join(csvHeader, ",") + "\n"
+}
+
+// csvHeader names the columns of csvRecord
+var csvHeader = []string{"IP", "Status", "HTTPCode", "ResponseTime", "Error", "Scheme", "Port"}
+
+// csvRecord returns the CSV columns of a result (Port is empty when unknown)
+func csvRecord(r core.IPResult) []string {
+	port := ""
+	if r.Port != 0 {
+		port = strconv.Itoa(r.Port)
+	}
+	return []string{r.IP, r.Status, strconv.Itoa(r.HTTPCode), r.ResponseTime, r.Error, r.Scheme, port}
 }
 
 // WriteCSVResults writes results in CSV format
 func (f *Formatter) WriteCSVResults(results []*core.IPResult, writer *csv.Writer) error {
 	// Write header
-	if err := writer.Write([]string{"IP", "Status", "HTTPCode", "ResponseTime", "Error"}); err != nil {
+	if err := writer.Write(csvHeader); err != nil {
 		return err
 	}
 
 	// Write results
 	for _, r := range results {
-		record := []string{
-			r.IP,
-			r.Status,
-			fmt.Sprintf("%d", r.HTTPCode),
-			r.ResponseTime,
-			r.Error,
-		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(csvRecord(*r)); err != nil {
 			return err
 		}
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -75,6 +76,65 @@ func TestFormatter_FormatResult(t *testing.T) {
 			},
 			contains: "1.2.3.4",
 		},
+		{
+			name:   "text 200 OK over https",
+			format: core.FormatText,
+			result: core.IPResult{
+				IP:       "1.2.3.4",
+				Scheme:   "https",
+				Status:   "200",
+				HTTPCode: 200,
+			},
+			contains: "https://1.2.3.4",
+		},
+		{
+			name:   "text 403 with matching cert",
+			format: core.FormatText,
+			result: core.IPResult{
+				IP:        "1.2.3.4",
+				Scheme:    "https",
+				Status:    "4xx",
+				HTTPCode:  403,
+				Cert:      &core.CertInfo{Subject: "example.com"},
+				CertMatch: true,
+			},
+			contains: "cert matches domain",
+		},
+		{
+			name:   "text 200 OK on alternate port",
+			format: core.FormatText,
+			result: core.IPResult{
+				IP:       "1.2.3.4",
+				Scheme:   "https",
+				Port:     8443,
+				Status:   "200",
+				HTTPCode: 200,
+			},
+			contains: "https://1.2.3.4:8443",
+		},
+		{
+			name:   "text 200 OK over https on IPv6",
+			format: core.FormatText,
+			result: core.IPResult{
+				IP:       "2001:db8::1",
+				Scheme:   "https",
+				Status:   "200",
+				HTTPCode: 200,
+			},
+			contains: "https://[2001:db8::1]",
+		},
+		{
+			name:   "text 200 OK with baseline similarity",
+			format: core.FormatText,
+			result: core.IPResult{
+				IP:              "1.2.3.4",
+				Status:          "200",
+				HTTPCode:        200,
+				Similarity:      0.92,
+				BaselineMatches: []string{"status", "title"},
+			},
+			contains: "live site: 92% similar, matches status, title",
+		},
 		{
 			name:   "text timeout",
 			format: core.FormatText,
@@ -238,6 +298,26 @@ func TestFormatter_WriteCSVResults(t *testing.T) {
 	}
 }
 
+func TestFormatter_CSVSchemeAndPort(t *testing.T) {
+	f := NewFormatter(core.FormatCSV, false, false)
+	result := core.IPResult{IP: "192.0.2.1", Status: "200", HTTPCode: 200, ResponseTime: "50ms", Scheme: "https", Port: 8443}
+
+	if got, want := f.FormatCSVHeader(), "IP,Status,HTTPCode,ResponseTime,Error,Scheme,Port\n"; got != want {
+		t.Errorf("FormatCSVHeader() = %q, want %q", got, want)
+	}
+	if got, want := f.FormatResult(result), "192.0.2.1,200,200,50ms,,https,8443"; got != want {
+		t.Errorf("FormatResult() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := f.WriteCSVResults([]*core.IPResult{&result}, csv.NewWriter(&buf)); err != nil {
+		t.Fatalf("WriteCSVResults() error: %v", err)
+	}
+	if want := "IP,Status,HTTPCode,ResponseTime,Error,Scheme,Port\n192.0.2.1,200,200,50ms,,https,8443\n"; buf.String() != want {
+		t.Errorf("WriteCSVResults() = %q, want %q", buf.String(), want)
+	}
+}
+
 func TestNewWriter(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputFile := filepath.Join(tmpDir, "test_output.txt")
@@ -600,3 +680,206 @@ func TestReplaceAll(t *testing.T) {
 		})
 	}
 }
+
+// passiveTestIPs returns ranked passive IPs as produced by the scorer
+func passiveTestIPs() []core.PassiveIP {
+	seen := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return []core.PassiveIP{
+		{IP: "192.0.2.1", Source: "securitytrails", Hostname: "example.com", Confidence: 0.85, FirstSeen: seen, LastSeen: seen,
+			Sources:   []string{"dns", "securitytrails"},
+			Hostnames: []string{"example.com", "mail.example.com"},
+			FoundVia:  []string{"historical A record example.com", "MX record mail.example.com"}},
+		{IP: "104.16.1.1", Source: "ct", Confidence: 0.4, WAF: "cloudflare"},
+	}
+}
+
+// hostnameTestInventory returns a hostname inventory as built by passive.Inventory
+func hostnameTestInventory() []core.Hostname {
+	return []core.Hostname{
+		{Name: "example.com", IPs: []string{"192.0.2.1"}, Sources: []string{"securitytrails"}},
+		{Name: "www.example.com", IPs: []string{"104.16.1.1", "104.16.1.2"}, Sources: []string{"ct", "dns"}, CDN: "cloudflare"},
+	}
+}
+
+func TestWritePassiveJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePassiveJSON(&buf, "example.com", passiveTestIPs(), hostnameTestInventory()); err != nil {
+		t.Fatalf("WritePassiveJSON() error: %v", err)
+	}
+
+	var report PassiveReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if report.Domain != "example.com" || report.Total != 2 || len(report.IPs) != 2 {
+		t.Fatalf("report = %+v", report)
+	}
+	first := report.IPs[0]
+	if first.IP != "192.0.2.1" || first.Confidence != 0.85 || len(first.Sources) != 2 || len(first.Hostnames) != 2 || len(first.FoundVia) != 2 {
+		t.Errorf("first record = %+v", first)
+	}
+	// Records without merged metadata fall back to their own source
+	second := report.IPs[1]
+	if second.WAF != "cloudflare" || len(second.Sources) != 1 || second.Sources[0] != "ct" || second.Hostnames != nil {
+		t.Errorf("second record = %+v", second)
+	}
+}
+
+func TestWritePassiveJSON_Hostnames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePassiveJSON(&buf, "example.com", passiveTestIPs(), hostnameTestInventory()); err != nil {
+		t.Fatalf("WritePassiveJSON() error: %v", err)
+	}
+	var report PassiveReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if !reflect.DeepEqual(report.Hostnames, hostnameTestInventory()) {
+		t.Errorf("hostnames = %+v, want %+v", report.Hostnames, hostnameTestInventory())
+	}
+
+	buf.Reset()
+	if err := WritePassiveJSON(&buf, "example.com", passiveTestIPs(), nil); err != nil {
+		t.Fatalf("WritePassiveJSON() error: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if _, ok := fields["hostnames"]; ok {
+		t.Errorf("report without an inventory has a hostnames field: %s", fields["hostnames"])
+	}
+}
+
+func TestWriteHostnamesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHostnamesCSV(&buf, hostnameTestInventory()); err != nil {
+		t.Fatalf("WriteHostnamesCSV() error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	want := [][]string{
+		{"Hostname", "IPs", "Sources", "CDN"},
+		{"example.com", "192.0.2.1", "securitytrails", ""},
+		{"www.example.com", "104.16.1.1;104.16.1.2", "ct;dns", "cloudflare"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestFormatHostname(t *testing.T) {
+	inventory := hostnameTestInventory()
+	if got, want := FormatHostname(inventory[0]), "example.com -> 192.0.2.1 (securitytrails)"; got != want {
+		t.Errorf("FormatHostname() = %q, want %q", got, want)
+	}
+	if got, want := FormatHostname(inventory[1]), "www.example.com -> 104.16.1.1, 104.16.1.2 (ct, dns) [CDN: cloudflare]"; got != want {
+		t.Errorf("FormatHostname() = %q, want %q", got, want)
+	}
+}
+
+func TestWritePassiveCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePassiveCSV(&buf, passiveTestIPs()); err != nil {
+		t.Fatalf("WritePassiveCSV() error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "IP,Confidence,Sources,Hostnames,WAF,FoundVia,FirstSeen,LastSeen" {
+		t.Fatalf("rows = %v", rows)
+	}
+	want := []string{"192.0.2.1", "0.85", "dns;securitytrails", "example.com;mail.example.com", "",
+		"historical A record example.com;MX record mail.example.com", "2025-06-01T00:00:00Z", "2025-06-01T00:00:00Z"}
+	if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("row = %v, want %v", rows[1], want)
+	}
+	if rows[2][4] != "cloudflare" || rows[2][6] != "" {
+		t.Errorf("row = %v, want the WAF provider and no first seen date", rows[2])
+	}
+}
+
+// reportTestResult returns an auto mode result with candidates
+func reportTestResult() *core.ScanResult {
+	sr := core.NewScanResult("example.com", core.ModeAuto)
+	sr.AddResult(&core.IPResult{IP: "192.0.2.1", Scheme: "https", Status: "200", HTTPCode: 200, Title: "Example", Similarity: 0.9})
+	sr.AddResult(&core.IPResult{IP: "192.0.2.1", Port: 8080, Status: "timeout"})
+	sr.AddPassive(passiveTestIPs())
+	return sr
+}
+
+func TestFormatter_FormatCandidates(t *testing.T) {
+	f := NewFormatter(core.FormatText, false, false)
+	if got := f.FormatCandidates(nil); got != "" {
+		t.Errorf("FormatCandidates(nil) = %q, want empty", got)
+	}
+
+	got := f.FormatCandidates(reportTestResult().Candidates)
+	for _, want := range []string{
+		"192.0.2.1        confirmed",
+		"(0.85 dns, securitytrails)",
+		"via historical A record example.com; MX record mail.example.com",
+		`https://192.0.2.1 --> HTTP 200 | "Example" | live site: 90% similar`,
+		"192.0.2.1:8080 --> Timeout",
+		"104.16.1.1       not_scanned",
+		"[WAF: cloudflare]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCandidates() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	result := reportTestResult()
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, result, core.FormatJSON); err != nil {
+		t.Fatalf("WriteReport(json) error: %v", err)
+	}
+	var decoded core.ScanResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if len(decoded.Candidates) != 2 || len(decoded.PassiveIPs) != 2 || decoded.Candidates[0].Verdict != core.VerdictConfirmed {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, result, core.FormatCSV); err != nil {
+		t.Fatalf("WriteReport(csv) error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() error: %v", err)
+	}
+	if len(rows) != 3 || rows[0][0] != "IP" {
+		t.Fatalf("rows = %v", rows)
+	}
+	want := []string{"192.0.2.1", "confirmed", "0.85", "dns;securitytrails", "example.com;mail.example.com", "",
+		"historical A record example.com;MX record mail.example.com", "https://192.0.2.1;192.0.2.1:8080", "200;0", "Example", "0.90"}
+	if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("row = %v, want %v", rows[1], want)
+	}
+
+	buf.Reset()
+	if err := WriteReport(&buf, result, core.FormatText); err != nil {
+		t.Fatalf("WriteReport(text) error: %v", err)
+	}
+	if text := buf.String(); !strings.Contains(text, "# Origin discovery report for: example.com") || !strings.Contains(text, "Scan Results Summary") || strings.Contains(text, "\033[") {
+		t.Errorf("text report = %q", text)
+	}
+
+	buf.Reset()
+	result.Hostnames = hostnameTestInventory()
+	if err := WriteReport(&buf, result, core.FormatText); err != nil {
+		t.Fatalf("WriteReport(text) error: %v", err)
+	}
+	if text := buf.String(); !strings.Contains(text, "# Hostnames: 2\n# example.com -> 192.0.2.1 (securitytrails)\n") {
+		t.Errorf("text report without the hostname inventory: %q", text)
+	}
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// PassiveRecord is a ranked passive IP flattened for export
+type PassiveRecord struct {
+	IP         string    `json:"ip"`
+	Confidence float64   `json:"confidence"`
+	Sources    []string  `json:"sources"`
+	Hostnames  []string  `json:"hostnames,omitempty"`
+	FoundVia   []string  `json:"found_via,omitempty"`
+	Pivot      string    `json:"pivot,omitempty"`
+	Network    string    `json:"network,omitempty"` // Network the IP stands for, e.g. an SPF ip4:/16
+	WAF        string    `json:"waf,omitempty"`     // WAF/CDN provider whose ranges contain the IP
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// PassiveReport is the JSON document written for passive recon results
+type PassiveReport struct {
+	Domain       string          `json:"domain"`
+	DiscoveredAt time.Time       `json:"discovered_at"`
+	Total        int             `json:"total"`
+	IPs          []PassiveRecord `json:"ips"`
+	Hostnames    []core.Hostname `json:"hostnames,omitempty"`
+}
+
+// passiveCSVHeader lists the columns written by WritePassiveCSV
+var passiveCSVHeader = []string{"IP", "Confidence", "Sources", "Hostnames", "WAF", "FoundVia", "FirstSeen", "LastSeen"}
+
+// hostnameCSVHeader lists the columns written by WriteHostnamesCSV
+var hostnameCSVHeader = []string{"Hostname", "IPs", "Sources", "CDN"}
+
+// NewPassiveRecord flattens a ranked passive IP, falling back to its single source
+// and hostname when it was not merged from several records
+func NewPassiveRecord(ip core.PassiveIP) PassiveRecord {
+	record := PassiveRecord{
+		IP:         ip.IP,
+		Confidence: ip.Confidence,
+		Sources:    ip.Sources,
+		Hostnames:  ip.Hostnames,
+		FoundVia:   ip.FoundVia,
+		Pivot:      ip.Pivot,
+		Network:    ip.Network,
+		WAF:        ip.WAF,
+		FirstSeen:  ip.FirstSeen,
+		LastSeen:   ip.LastSeen,
+	}
+	if len(record.Sources) == 0 {
+		record.Sources = []string{ip.Source}
+	}
+	if len(record.Hostnames) == 0 && ip.Hostname != "" {
+		record.Hostnames = []string{ip.Hostname}
+	}
+	return record
+}
+
+// WritePassiveJSON writes ranked passive IPs and the hostname inventory as an
+// indented PassiveReport
+func WritePassiveJSON(w io.Writer, domain string, ips []core.PassiveIP, hostnames []core.Hostname) error {
+	report := PassiveReport{
+		Domain:       domain,
+		DiscoveredAt: time.Now(),
+		Total:        len(ips),
+		IPs:          make([]PassiveRecord, 0, len(ips)),
+		Hostnames:    hostnames,
+	}
+	for _, ip := range ips {
+		report.IPs = append(report.IPs, NewPassiveRecord(ip))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// WritePassiveCSV writes ranked passive IPs as CSV with a header row.
+// List columns are separated by semicolons; unknown dates are left empty.
+func WritePassiveCSV(w io.Writer, ips []core.PassiveIP) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(passiveCSVHeader); err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		record := NewPassiveRecord(ip)
+		row := []string{
+			record.IP,
+			fmt.Sprintf("%.2f", record.Confidence),
+			strings.Join(record.Sources, ";"),
+			strings.Join(record.Hostnames, ";"),
+			record.WAF,
+			strings.Join(record.FoundVia, ";"),
+			formatDate(record.FirstSeen),
+			formatDate(record.LastSeen),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteHostnamesCSV writes the hostname inventory as CSV with a header row.
+// IPs and sources are separated by semicolons.
+func WriteHostnamesCSV(w io.Writer, hostnames []core.Hostname) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(hostnameCSVHeader); err != nil {
+		return err
+	}
+
+	for _, h := range hostnames {
+		row := []string{h.Name, strings.Join(h.IPs, ";"), strings.Join(h.Sources, ";"), h.CDN}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// FormatHostname formats an inventory entry for console and text output,
+// e.g. "api.example.com -> 192.0.2.1 (ct, dns) [CDN: cloudflare]"
+func FormatHostname(h core.Hostname) string {
+	line := fmt.Sprintf("%s -> %s (%s)", h.Name, strings.Join(h.IPs, ", "), strings.Join(h.Sources, ", "))
+	if h.CDN != "" {
+		line += " [CDN: " + h.CDN + "]"
+	}
+	return line
+}
+
+// formatDate formats a timestamp as RFC 3339, or "" for the zero time
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,152 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// candidateCSVHeader lists the columns written by WriteReport in CSV
+var candidateCSVHeader = []string{"IP", "Verdict", "Confidence", "Sources", "Hostnames", "WAF", "FoundVia", "Targets", "HTTPCodes", "Title", "Similarity"}
+
+// FormatCandidates formats the auto mode candidates with their passive provenance,
+// probe results and verdict
+func (f *Formatter) FormatCandidates(candidates []core.Candidate) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	sb.WriteString(f.cyan + "═══════════════════════════════════════════════════════════════\n")
+	sb.WriteString(f.bold + "Candidates (passive + active evidence)\n" + f.nc)
+	sb.WriteString(f.cyan + "═══════════════════════════════════════════════════════════════" + f.nc + "\n")
+
+	for _, c := range candidates {
+		color := ""
+		switch c.Verdict {
+		case core.VerdictConfirmed:
+			color = f.green
+		case core.VerdictLikely:
+			color = f.yellow
+		case core.VerdictFalsePositive:
+			color = f.red
+		}
+
+		passive := "active scan only"
+		if len(c.Sources) > 0 {
+			passive = fmt.Sprintf("%.2f %s", c.Confidence, strings.Join(c.Sources, ", "))
+		}
+		sb.WriteString(fmt.Sprintf("%s%-15s  %-14s%s  (%s)", color, c.IP, c.Verdict, f.nc, passive))
+		if c.WAF != "" {
+			sb.WriteString(" [WAF: " + c.WAF + "]")
+		}
+		sb.WriteString("\n")
+
+		if len(c.FoundVia) > 0 {
+			sb.WriteString("    via " + strings.Join(c.FoundVia, "; ") + "\n")
+		}
+		for _, r := range c.Probes {
+			sb.WriteString("    " + formatTarget(*r) + " --> " + formatProbe(*r) + f.formatCert(*r) + f.formatBaseline(*r) + "\n")
+		}
+	}
+
+	sb.WriteString(f.cyan + "═══════════════════════════════════════════════════════════════" + f.nc + "\n")
+	return sb.String()
+}
+
+// formatProbe describes the outcome of one probe
+func formatProbe(r core.IPResult) string {
+	switch r.Status {
+	case "timeout":
+		return "Timeout"
+	case "error":
+		return "Error: " + r.Error
+	}
+	probe := fmt.Sprintf("HTTP %d", r.HTTPCode)
+	if r.Title != "" {
+		probe += fmt.Sprintf(" | \"%s\"", r.Title)
+	}
+	return probe
+}
+
+// WriteReport writes the unified auto mode report: the full ScanResult in JSON,
+// one row per candidate in CSV, or the candidate list, hostname inventory and
+// summary in text
+func WriteReport(w io.Writer, result *core.ScanResult, format core.OutputFormat) error {
+	switch format {
+	case core.FormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case core.FormatCSV:
+		return writeCandidatesCSV(w, result.Candidates)
+	}
+
+	f := NewFormatter(core.FormatText, false, false)
+	fmt.Fprintf(w, "# Origin discovery report for: %s\n", result.Domain)
+	fmt.Fprintf(w, "# Generated at: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "# Candidates: %d (passive ranking order)\n", len(result.Candidates))
+	fmt.Fprint(w, f.FormatCandidates(result.Candidates))
+	if len(result.Hostnames) > 0 {
+		fmt.Fprintf(w, "\n# Hostnames: %d\n", len(result.Hostnames))
+		for _, h := range result.Hostnames {
+			fmt.Fprintf(w, "# %s\n", FormatHostname(h))
+		}
+	}
+	_, err := fmt.Fprint(w, f.FormatSummary(result.Summary))
+	return err
+}
+
+// writeCandidatesCSV writes one row per candidate; multi-valued columns are
+// separated by semicolons and the similarity is the best across probes
+func writeCandidatesCSV(w io.Writer, candidates []core.Candidate) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(candidateCSVHeader); err != nil {
+		return err
+	}
+
+	for _, c := range candidates {
+		var targets, codes []string
+		var title string
+		var similarity float64
+		for _, r := range c.Probes {
+			targets = append(targets, formatTarget(*r))
+			codes = append(codes, strconv.Itoa(r.HTTPCode))
+			if title == "" {
+				title = r.Title
+			}
+			if r.Similarity > similarity {
+				similarity = r.Similarity
+			}
+		}
+		row := []string{
+			c.IP,
+			c.Verdict,
+			fmt.Sprintf("%.2f", c.Confidence),
+			strings.Join(c.Sources, ";"),
+			strings.Join(c.Hostnames, ";"),
+			c.WAF,
+			strings.Join(c.FoundVia, ";"),
+			strings.Join(targets, ";"),
+			strings.Join(codes, ";"),
+			title,
+			fmt.Sprintf("%.2f", similarity),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,20 @@
+// Package page extracts metadata from fetched HTML pages
+package page
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// titlePattern matches the first <title> element of a page
+var titlePattern = regexp.MustCompile(`(?i)<title[^>]*>([^<]+)</title>`)
+
+// Title returns the trimmed raw text of the page's <title>, or "" when it has none.
+// Entities are left escaped.
+func Title(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(match[1]))
+}
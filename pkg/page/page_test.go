@@ -0,0 +1,27 @@
+package page
+
+import "testing"
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"title present", "<html><head><title>Shop</title></head></html>", "Shop"},
+		{"attributes and case", `<TITLE lang="en">  Shop  </TITLE>`, "Shop"},
+		{"first of several", "<title>First</title><title>Second</title>", "First"},
+		{"entities kept", "<title>Tom &amp; Co</title>", "Tom &amp; Co"},
+		{"no title", "<html><body>none</body></html>", ""},
+		{"empty title", "<title></title>", ""},
+		{"unclosed", "<title>Unclosed", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Title([]byte(tt.body)); got != tt.want {
+				t.Errorf("Title() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -43,6 +43,7 @@ type APIStatus struct {
 	LastError    error
 	RateLimitEnd time.Time // When rate limit expires
 	RequestsMade int       // Total requests made
+	RateLimits   int       // Rate limits hit by this source's keys
 	mu           sync.RWMutex
 }
 
@@ -55,7 +56,15 @@ type Manager struct {
 	shodanKeys   []string
 	censysCreds  []CensysCredential
 	currentIndex map[Source]int // Current key index for each source
-	mu           sync.RWMutex
+
+	// Generic per-source keys used by Execute, with per-key usage and cooldowns
+	keys               map[Source][]string
+	keyStates          map[Source]map[string]*KeyStatus
+	cooldown           time.Duration
+	skipOnRateLimit    bool
+	retryAfterCooldown bool
+
+	mu sync.RWMutex
 }
 
 // CensysCredential holds Censys API credentials
@@ -70,6 +79,11 @@ func NewManager(failoverEnabled bool) *Manager {
 		sources:      make(map[Source]*APIStatus),
 		failover:     failoverEnabled,
 		currentIndex: make(map[Source]int),
+
+		keys:            make(map[Source][]string),
+		keyStates:       make(map[Source]map[string]*KeyStatus),
+		cooldown:        DefaultCooldown,
+		skipOnRateLimit: true,
 	}
 }
 
@@ -79,6 +93,10 @@ func (m *Manager) SetShodanKeys(keys []string) {
 	defer m.mu.Unlock()
 	m.shodanKeys = keys
 	m.currentIndex[SourceShodan] = 0
+	m.keys[SourceShodan] = keys
+	for _, key := range keys {
+		m.keyStatus(SourceShodan, key)
+	}
 }
 
 // SetCensysCreds sets multiple Censys credentials for rotation
@@ -190,7 +208,7 @@ func (m *Manager) ValidateSource(ctx context.Context, source Source, validator f
 	if err != nil {
 		status.LastError = err
 		// Check if this is a rate limit error
-		if isRateLimitError(err) {
+		if IsRateLimitError(err) {
 			status.Status = StatusRateLimited
 			// Set cooldown period (default 1 hour)
 			status.RateLimitEnd = time.Now().Add(1 * time.Hour)
@@ -226,6 +244,7 @@ func (m *Manager) GetStatus(source Source) (*APIStatus, error) {
 		LastError:    status.LastError,
 		RateLimitEnd: status.RateLimitEnd,
 		RequestsMade: status.RequestsMade,
+		RateLimits:   status.RateLimits,
 	}, nil
 }
 
@@ -337,14 +356,15 @@ func (m *Manager) AllStatus() map[Source]*APIStatus {
 			LastError:    status.LastError,
 			RateLimitEnd: status.RateLimitEnd,
 			RequestsMade: status.RequestsMade,
+			RateLimits:   status.RateLimits,
 		}
 		status.mu.RUnlock()
 	}
 	return result
 }
 
-// isRateLimitError checks if an error is a rate limit error
-func isRateLimitError(err error) bool {
+// IsRateLimitError checks if an error is a rate limit error
+func IsRateLimitError(err error) bool {
 	if err == nil {
 		return false
 	}
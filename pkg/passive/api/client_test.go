@@ -380,9 +380,9 @@ func TestIsRateLimitError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isRateLimitError(tt.err)
+			got := IsRateLimitError(tt.err)
 			if got != tt.want {
-				t.Errorf("isRateLimitError() = %v, want %v", got, tt.want)
+				t.Errorf("IsRateLimitError() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCooldown is how long a rate-limited key is rested before it is used again
+const DefaultCooldown = 1 * time.Hour
+
+// ErrKeysRateLimited is returned when every key of a source is rate limited
+var ErrKeysRateLimited = errors.New("API keys rate limited")
+
+// KeyStatus tracks the usage of a single API key
+// Keys are identified by a short fingerprint so state files never hold secrets
+type KeyStatus struct {
+	Fingerprint   string    `json:"fingerprint"`
+	Requests      int       `json:"requests"`
+	RateLimits    int       `json:"rate_limits"`
+	Failures      int       `json:"failures"`
+	LastUsed      time.Time `json:"last_used,omitempty"`
+	CooldownUntil time.Time `json:"cooldown_until,omitempty"`
+}
+
+// CoolingDown reports whether the key is still resting after a rate limit
+func (k *KeyStatus) CoolingDown(now time.Time) bool {
+	return now.Before(k.CooldownUntil)
+}
+
+// SourceReport summarizes a source's keys for the end-of-run status table
+type SourceReport struct {
+	Source        Source
+	Status        Status
+	Keys          int       // Configured keys
+	Available     int       // Keys not cooling down
+	Requests      int       // Requests made this run
+	RateLimits    int       // Rate limits hit this run
+	CooldownUntil time.Time // Earliest key reset when no key is available
+	LastError     error
+}
+
+// Fingerprint returns the identifier used for a key in status output and state files
+func Fingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// SetKeys sets the API keys used by Execute for a source and registers it
+// Usage history for keys already known (e.g. from LoadState) is kept
+func (m *Manager) SetKeys(source Source, keys []string) {
+	cleaned := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			cleaned = append(cleaned, key)
+		}
+	}
+
+	m.RegisterSource(source)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[source] = cleaned
+	for _, key := range cleaned {
+		m.keyStatus(source, key)
+	}
+}
+
+// SetCooldownPolicy controls how Execute treats rate-limited keys
+// skipOnRateLimit skips keys that are cooling down; retryAfterCooldown waits
+// for the earliest cooldown to end when every key is resting
+func (m *Manager) SetCooldownPolicy(skipOnRateLimit, retryAfterCooldown bool, cooldown time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.skipOnRateLimit = skipOnRateLimit
+	m.retryAfterCooldown = retryAfterCooldown
+	if cooldown > 0 {
+		m.cooldown = cooldown
+	}
+}
+
+// Execute calls fn with the source's keys until one succeeds
+// Least-used keys go first so a shared pool wears evenly. A rate-limited key is
+// put in cooldown and, with failover enabled, the next key is tried. Any other
+// error is returned immediately.
+func (m *Manager) Execute(ctx context.Context, source Source, fn func(ctx context.Context, key string) error) error {
+	for {
+		keys, wait := m.nextKeys(source)
+		if len(keys) == 0 && wait.IsZero() {
+			return fmt.Errorf("no API keys configured for %s", source)
+		}
+
+		var lastErr error
+		for i, key := range keys {
+			if i > 0 && !m.failover {
+				break
+			}
+
+			m.beginRequest(source, key)
+			err := fn(ctx, key)
+			if err == nil {
+				m.finishRequest(source, key, nil)
+				return nil
+			}
+			if !IsRateLimitError(err) {
+				m.finishRequest(source, key, err)
+				return err
+			}
+
+			m.keyRateLimited(source, key, err)
+			lastErr = err
+			if until := m.cooldownEnd(source, key); wait.IsZero() || until.Before(wait) {
+				wait = until
+			}
+		}
+
+		m.mu.RLock()
+		retry := m.retryAfterCooldown
+		m.mu.RUnlock()
+
+		if !retry || ctx.Err() != nil {
+			return m.exhausted(source, wait, lastErr)
+		}
+
+		// Every key is resting: wait for the first one to come back
+		timer := time.NewTimer(time.Until(wait))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return m.exhausted(source, wait, lastErr)
+		case <-timer.C:
+		}
+	}
+}
+
+// Report returns the key status of every source with configured keys, sorted by name
+func (m *Manager) Report() []SourceReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	reports := make([]SourceReport, 0, len(m.keys))
+	for source, keys := range m.keys {
+		if len(keys) == 0 {
+			continue
+		}
+
+		report := SourceReport{Source: source, Status: StatusUnchecked, Keys: len(keys)}
+		for _, key := range keys {
+			ks := m.keyStates[source][Fingerprint(key)]
+			if !ks.CoolingDown(now) {
+				report.Available++
+			} else if report.CooldownUntil.IsZero() || ks.CooldownUntil.Before(report.CooldownUntil) {
+				report.CooldownUntil = ks.CooldownUntil
+			}
+		}
+		if report.Available > 0 {
+			report.CooldownUntil = time.Time{}
+		}
+
+		if status, ok := m.sources[source]; ok {
+			status.mu.RLock()
+			report.Status = status.Status
+			report.Requests = status.RequestsMade
+			report.RateLimits = status.RateLimits
+			report.LastError = status.LastError
+			status.mu.RUnlock()
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Source < reports[j].Source })
+	return reports
+}
+
+// LoadState restores key usage and cooldowns saved by SaveState
+// A missing file is not an error
+func (m *Manager) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read API key state: %w", err)
+	}
+
+	var state map[Source][]KeyStatus
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse API key state: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for source, statuses := range state {
+		if m.keyStates[source] == nil {
+			m.keyStates[source] = make(map[string]*KeyStatus)
+		}
+		for i := range statuses {
+			ks := statuses[i]
+			m.keyStates[source][ks.Fingerprint] = &ks
+		}
+	}
+	return nil
+}
+
+// SaveState writes key usage and cooldowns so later runs can respect them
+func (m *Manager) SaveState(path string) error {
+	m.mu.RLock()
+	state := make(map[Source][]KeyStatus, len(m.keyStates))
+	for source, byFingerprint := range m.keyStates {
+		for _, ks := range byFingerprint {
+			state[source] = append(state[source], *ks)
+		}
+		sort.Slice(state[source], func(i, j int) bool {
+			return state[source][i].Fingerprint < state[source][j].Fingerprint
+		})
+	}
+	m.mu.RUnlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API key state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write API key state: %w", err)
+	}
+	return nil
+}
+
+// nextKeys returns the keys to try, least used first
+// When every key is cooling down it returns the earliest reset time instead
+func (m *Manager) nextKeys(source Source) ([]string, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	var ready, resting []string
+	var wait time.Time
+	for _, key := range m.keys[source] {
+		ks := m.keyStates[source][Fingerprint(key)]
+		if ks.CoolingDown(now) {
+			resting = append(resting, key)
+			if wait.IsZero() || ks.CooldownUntil.Before(wait) {
+				wait = ks.CooldownUntil
+			}
+			continue
+		}
+		ready = append(ready, key)
+	}
+
+	byUsage := func(keys []string) {
+		sort.SliceStable(keys, func(i, j int) bool {
+			a := m.keyStates[source][Fingerprint(keys[i])]
+			b := m.keyStates[source][Fingerprint(keys[j])]
+			if a.Requests != b.Requests {
+				return a.Requests < b.Requests
+			}
+			return a.LastUsed.Before(b.LastUsed)
+		})
+	}
+	byUsage(ready)
+
+	if !m.skipOnRateLimit {
+		// Resting keys are still tried, after every ready key
+		byUsage(resting)
+		return append(ready, resting...), time.Time{}
+	}
+	if len(ready) > 0 {
+		return ready, time.Time{}
+	}
+	return nil, wait
+}
+
+// keyStatus returns the status entry for a key, creating it if needed
+// Callers must hold m.mu for writing
+func (m *Manager) keyStatus(source Source, key string) *KeyStatus {
+	if m.keyStates[source] == nil {
+		m.keyStates[source] = make(map[string]*KeyStatus)
+	}
+	fp := Fingerprint(key)
+	ks, ok := m.keyStates[source][fp]
+	if !ok {
+		ks = &KeyStatus{Fingerprint: fp}
+		m.keyStates[source][fp] = ks
+	}
+	return ks
+}
+
+// beginRequest records that a key is about to be used
+func (m *Manager) beginRequest(source Source, key string) {
+	m.mu.Lock()
+	ks := m.keyStatus(source, key)
+	ks.Requests++
+	ks.LastUsed = time.Now()
+	m.mu.Unlock()
+
+	m.IncrementRequests(source)
+}
+
+// finishRequest records the outcome of a request that was not rate limited
+func (m *Manager) finishRequest(source Source, key string, err error) {
+	if err != nil {
+		m.mu.Lock()
+		m.keyStatus(source, key).Failures++
+		m.mu.Unlock()
+	}
+
+	m.updateStatus(source, func(status *APIStatus) {
+		status.LastChecked = time.Now()
+		status.LastError = err
+		if err != nil {
+			status.Status = StatusError
+		} else {
+			status.Status = StatusAvailable
+		}
+	})
+}
+
+// keyRateLimited puts a key in cooldown
+func (m *Manager) keyRateLimited(source Source, key string, err error) {
+	m.mu.Lock()
+	ks := m.keyStatus(source, key)
+	ks.RateLimits++
+	ks.CooldownUntil = time.Now().Add(m.cooldown)
+	m.mu.Unlock()
+
+	m.updateStatus(source, func(status *APIStatus) {
+		status.RateLimits++
+		status.LastError = err
+		status.LastChecked = time.Now()
+	})
+}
+
+// cooldownEnd returns when a key's cooldown ends
+func (m *Manager) cooldownEnd(source Source, key string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keyStates[source][Fingerprint(key)].CooldownUntil
+}
+
+// exhausted marks a source rate limited until its first key resets
+func (m *Manager) exhausted(source Source, until time.Time, lastErr error) error {
+	m.updateStatus(source, func(status *APIStatus) {
+		status.Status = StatusRateLimited
+		status.RateLimitEnd = until
+		status.LastChecked = time.Now()
+		if lastErr != nil {
+			status.LastError = lastErr
+		}
+	})
+
+	if lastErr != nil {
+		return fmt.Errorf("%w (next key resets %s): %v", ErrKeysRateLimited, until.Format(time.RFC3339), lastErr)
+	}
+	return fmt.Errorf("%w (next key resets %s)", ErrKeysRateLimited, until.Format(time.RFC3339))
+}
+
+// updateStatus applies fn to a registered source's status
+func (m *Manager) updateStatus(source Source, fn func(status *APIStatus)) {
+	m.mu.RLock()
+	status, exists := m.sources[source]
+	m.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	fn(status)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_Execute_RotatesOnRateLimit(t *testing.T) {
+	m := NewManager(true)
+	m.SetKeys(SourceShodan, []string{"key1", "key2", "key3"})
+
+	var used []string
+	err := m.Execute(context.Background(), SourceShodan, func(ctx context.Context, key string) error {
+		used = append(used, key)
+		if key == "key1" {
+			return errors.New("Shodan returned status 429: too many requests")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if want := []string{"key1", "key2"}; !reflect.DeepEqual(used, want) {
+		t.Errorf("keys used = %v, want %v", used, want)
+	}
+
+	// key1 is cooling down, so the next call skips it and prefers the unused key3
+	used = nil
+	m.Execute(context.Background(), SourceShodan, func(ctx context.Context, key string) error {
+		used = append(used, key)
+		return nil
+	})
+	if want := []string{"key3"}; !reflect.DeepEqual(used, want) {
+		t.Errorf("keys used after cooldown = %v, want %v", used, want)
+	}
+
+	reports := m.Report()
+	if len(reports) != 1 {
+		t.Fatalf("Report() returned %d sources, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.Status != StatusAvailable || r.Keys != 3 || r.Available != 2 || r.Requests != 3 || r.RateLimits != 1 {
+		t.Errorf("Report() = %+v, want available 2/3 keys, 3 requests, 1 rate limit", r)
+	}
+}
+
+func TestManager_Execute_AllKeysRateLimited(t *testing.T) {
+	m := NewManager(true)
+	m.SetKeys(SourceCensys, []string{"a", "b"})
+
+	calls := 0
+	rateLimited := func(ctx context.Context, key string) error {
+		calls++
+		return errors.New("rate limit exceeded")
+	}
+
+	err := m.Execute(context.Background(), SourceCensys, rateLimited)
+	if !errors.Is(err, ErrKeysRateLimited) {
+		t.Fatalf("Execute() error = %v, want ErrKeysRateLimited", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	// Every key is cooling down: the source is skipped without a request
+	err = m.Execute(context.Background(), SourceCensys, rateLimited)
+	if !errors.Is(err, ErrKeysRateLimited) {
+		t.Errorf("second Execute() error = %v, want ErrKeysRateLimited", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls after cooldown = %d, want 2", calls)
+	}
+
+	status, _ := m.GetStatus(SourceCensys)
+	if status.Status != StatusRateLimited || status.RateLimitEnd.IsZero() {
+		t.Errorf("status = %s until %v, want rate_limited with an end time", status.Status, status.RateLimitEnd)
+	}
+	if r := m.Report()[0]; r.Available != 0 || r.CooldownUntil.IsZero() {
+		t.Errorf("Report() = %+v, want no available keys and a reset time", r)
+	}
+}
+
+func TestManager_Execute_Policies(t *testing.T) {
+	tests := []struct {
+		name      string
+		failover  bool
+		skip      bool
+		wantCalls []string
+		wantErr   bool
+	}{
+		{"failover tries every key", true, true, []string{"k1", "k2"}, false},
+		{"no failover stops at first key", false, true, []string{"k1"}, true},
+		{"resting keys tried last without skip", true, false, []string{"k2", "k1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(tt.failover)
+			m.SetCooldownPolicy(tt.skip, false, time.Hour)
+			m.SetKeys(SourceZoomEye, []string{"k1", "k2"})
+
+			var calls []string
+			limitedOnce := map[string]bool{"k1": true}
+			if !tt.skip {
+				// Put k1 in cooldown first; without skip it is still tried after k2
+				m.Execute(context.Background(), SourceZoomEye, func(ctx context.Context, key string) error {
+					if key == "k1" {
+						return errors.New("429")
+					}
+					return nil
+				})
+				limitedOnce = map[string]bool{"k2": true}
+			}
+
+			err := m.Execute(context.Background(), SourceZoomEye, func(ctx context.Context, key string) error {
+				calls = append(calls, key)
+				if limitedOnce[key] {
+					return errors.New("quota exceeded")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("keys used = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestManager_Execute_OtherErrorStops(t *testing.T) {
+	m := NewManager(true)
+	m.SetKeys(SourceVirusTotal, []string{"k1", "k2"})
+
+	calls := 0
+	err := m.Execute(context.Background(), SourceVirusTotal, func(ctx context.Context, key string) error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Execute() = %v after %d calls, want error after 1 call", err, calls)
+	}
+	if status, _ := m.GetStatus(SourceVirusTotal); status.Status != StatusError {
+		t.Errorf("status = %s, want error", status.Status)
+	}
+}
+
+func TestManager_Execute_RetryAfterCooldown(t *testing.T) {
+	m := NewManager(true)
+	m.SetCooldownPolicy(true, true, 20*time.Millisecond)
+	m.SetKeys(SourceViewDNS, []string{"only"})
+
+	calls := 0
+	err := m.Execute(context.Background(), SourceViewDNS, func(ctx context.Context, key string) error {
+		calls++
+		if calls == 1 {
+			return errors.New("rate limit")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Execute() = %v after %d calls, want success after waiting for cooldown", err, calls)
+	}
+}
+
+func TestManager_Execute_NoKeys(t *testing.T) {
+	m := NewManager(true)
+	err := m.Execute(context.Background(), SourceDNSDumpster, func(ctx context.Context, key string) error {
+		t.Fatal("fn called without keys")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "no API keys") {
+		t.Errorf("Execute() error = %v, want no API keys error", err)
+	}
+}
+
+func TestManager_State_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_state.json")
+
+	m := NewManager(true)
+	m.SetKeys(SourceSecurityTrails, []string{"secret-one", "secret-two"})
+	m.Execute(context.Background(), SourceSecurityTrails, func(ctx context.Context, key string) error {
+		if key == "secret-one" {
+			return errors.New("429")
+		}
+		return nil
+	})
+	if err := m.SaveState(path); err != nil {
+		t.Fatalf("SaveState() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "secret-one") {
+		t.Error("state file contains a raw API key")
+	}
+
+	// A new run restores the cooldown and skips the rate-limited key
+	restored := NewManager(true)
+	if err := restored.LoadState(path); err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	restored.SetKeys(SourceSecurityTrails, []string{"secret-one", "secret-two"})
+
+	var used []string
+	restored.Execute(context.Background(), SourceSecurityTrails, func(ctx context.Context, key string) error {
+		used = append(used, key)
+		return nil
+	})
+	if want := []string{"secret-two"}; !reflect.DeepEqual(used, want) {
+		t.Errorf("keys used after restore = %v, want %v", used, want)
+	}
+
+	if err := NewManager(true).LoadState(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadState() on missing file error: %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fp := Fingerprint("abc")
+	if len(fp) != 12 || fp == Fingerprint("abd") || fp != Fingerprint("abc") {
+		t.Errorf("Fingerprint() = %q, want a stable 12-char digest", fp)
+	}
+}
@@ -5,12 +5,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 // CensysV3Request represents the request body for Censys v3 Global Search API
@@ -39,11 +44,19 @@ type CensysResult struct {
 
 // CensysHit represents a single host result
 type CensysHit struct {
-	IP       string                 `json:"ip"`
-	Services []CensysService        `json:"services"`
-	Location CensysLocation         `json:"location"`
-	Names    []string               `json:"names"`
-	Metadata map[string]interface{} `json:"metadata"`
+	IP               string                 `json:"ip"`
+	Services         []CensysService        `json:"services"`
+	Location         CensysLocation         `json:"location"`
+	AutonomousSystem CensysAutonomousSystem `json:"autonomous_system"`
+	Names            []string               `json:"names"`
+	LastUpdatedAt    string                 `json:"last_updated_at"` // RFC 3339
+	Metadata         map[string]interface{} `json:"metadata"`
+}
+
+// CensysAutonomousSystem contains routing data for the host
+type CensysAutonomousSystem struct {
+	ASN  int    `json:"asn"`
+	Name string `json:"name"`
 }
 
 // CensysService represents a service on the host
@@ -76,6 +89,7 @@ type CensysHTTPResponse struct {
 // CensysLocation contains geolocation data
 type CensysLocation struct {
 	Country     string     `json:"country"`
+	CountryCode string     `json:"country_code"`
 	City        string     `json:"city"`
 	Coordinates [2]float64 `json:"coordinates"`
 }
@@ -86,10 +100,52 @@ type CensysLinks struct {
 	Prev string `json:"prev"`
 }
 
+// CensysCertResponse represents the JSON response of a certificate search
+type CensysCertResponse struct {
+	Result CensysCertResult `json:"result"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// CensysCertResult contains the certificate search results
+type CensysCertResult struct {
+	Total int             `json:"total"`
+	Hits  []CensysCertHit `json:"hits"`
+}
+
+// CensysCertHit represents a single certificate result
+type CensysCertHit struct {
+	FingerprintSHA256 string           `json:"fingerprint_sha256"`
+	Names             []string         `json:"names"`
+	Parsed            CensysCertParsed `json:"parsed"`
+}
+
+// CensysCertParsed contains parsed certificate fields
+type CensysCertParsed struct {
+	ValidityPeriod CensysValidityPeriod `json:"validity_period"`
+}
+
+// CensysValidityPeriod contains the certificate validity dates (RFC 3339)
+type CensysValidityPeriod struct {
+	NotBefore string `json:"not_before"`
+	NotAfter  string `json:"not_after"`
+}
+
+// issued returns when the certificate became valid, or the zero time if unknown
+func (c CensysCertHit) issued() time.Time {
+	t, _ := time.Parse(time.RFC3339, c.Parsed.ValidityPeriod.NotBefore)
+	return t
+}
+
+// maxCertificates caps how many certificates are looked up as hosts
+const maxCertificates = 10
+
+// apiURL is the Censys v3 Global Search endpoint (can be overridden in tests)
+var apiURL = "https://api.platform.censys.io/v3/global/search/query"
+
 // SearchHosts queries Censys for hosts matching the domain
-func SearchHosts(ctx context.Context, domain string, tokens []string, orgID string, timeout time.Duration) ([]string, error) {
+func SearchHosts(ctx context.Context, domain string, tokens []string, orgID string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(tokens) == 0 {
-		return []string{}, fmt.Errorf("no Censys PAT tokens provided")
+		return nil, fmt.Errorf("no Censys PAT tokens provided")
 	}
 
 	// Try each token until one works (rotation for rate limits)
@@ -100,7 +156,7 @@ func SearchHosts(ctx context.Context, domain string, tokens []string, orgID stri
 			continue
 		}
 
-		ips, err := searchWithToken(ctx, domain, token, orgID, timeout)
+		ips, err := SearchWithToken(ctx, domain, token, orgID, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -112,22 +168,116 @@ func SearchHosts(ctx context.Context, domain string, tokens []string, orgID stri
 		}
 
 		// For other errors, return immediately (invalid token, network issue, etc.)
-		return []string{}, fmt.Errorf("token %d/%d failed: %w", i+1, len(tokens), err)
+		return nil, fmt.Errorf("token %d/%d failed: %w", i+1, len(tokens), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d PAT tokens exhausted: %w", len(tokens), lastErr)
+		return nil, fmt.Errorf("all %d PAT tokens exhausted: %w", len(tokens), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid PAT tokens found")
+	return nil, fmt.Errorf("no valid PAT tokens found")
 }
 
-// searchWithToken performs the search with a single PAT token
-func searchWithToken(ctx context.Context, domain, token, orgID string, timeout time.Duration) ([]string, error) {
+// SearchWithToken performs the search with a single PAT token
+func SearchWithToken(ctx context.Context, domain, token, orgID string, timeout time.Duration) ([]core.PassiveIP, error) {
 	// Build CenQL query for v3 Global Search API
 	// Search for domain in certificate names: host.services.cert.names: "example.com"
 	query := fmt.Sprintf(`host.services.cert.names: "%s"`, domain)
+	return search(ctx, query, "Censys cert names", token, orgID, timeout)
+}
 
+// PivotQuery builds the CenQL query for a pivot and the description of its results
+func PivotQuery(pivot core.Pivot) (query, via string, err error) {
+	switch pivot.Kind {
+	case core.PivotFavicon:
+		return "host.services.endpoints.http.favicons.hash_shodan: " + pivot.Value, "Censys favicon hash", nil
+	case core.PivotTitle:
+		return "host.services.endpoints.http.html_title: " + strconv.Quote(pivot.Value), "Censys HTML title", nil
+	case core.PivotCertSHA256:
+		return "host.services.cert.fingerprint_sha256: " + strconv.Quote(pivot.Value), "Censys cert SHA-256", nil
+	case core.PivotCertSubject:
+		return "host.services.cert.parsed.subject.common_name: " + strconv.Quote(pivot.Value), "Censys cert subject", nil
+	}
+	return "", "", fmt.Errorf("%w: %s", core.ErrUnsupportedPivot, pivot.Kind)
+}
+
+// SearchPivotWithToken finds hosts sharing an attribute of the live site
+func SearchPivotWithToken(ctx context.Context, pivot core.Pivot, token, orgID string, timeout time.Duration) ([]core.PassiveIP, error) {
+	query, via, err := PivotQuery(pivot)
+	if err != nil {
+		return nil, err
+	}
+	records, err := search(ctx, query, via, token, orgID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Pivot = pivot.Kind
+		records[i].Metadata["pivot_value"] = pivot.Value
+	}
+	return records, nil
+}
+
+// SearchCertificatesWithToken finds certificates whose names include the domain and
+// returns the hosts presenting them. CT logs name a certificate's hosts; this finds
+// the IPs actually serving it. The newest maxCertificates certificates are looked up;
+// if some lookups fail, the hosts found by the others are returned with their errors.
+func SearchCertificatesWithToken(ctx context.Context, domain, token, orgID string, timeout time.Duration) ([]core.PassiveIP, error) {
+	body, err := post(ctx, fmt.Sprintf(`cert.names: "%s"`, domain), token, orgID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	var certResp CensysCertResponse
+	if err := json.Unmarshal(body, &certResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Censys response: %w", err)
+	}
+
+	var records []core.PassiveIP
+	var errs []error
+	certs := newestCertificates(certResp.Result.Hits, maxCertificates)
+	for _, cert := range certs {
+		query := "host.services.cert.fingerprint_sha256: " + strconv.Quote(cert.FingerprintSHA256)
+		hosts, err := search(ctx, query, "Censys certificate search", token, orgID, timeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("certificate %s: %w", cert.FingerprintSHA256, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		for i := range hosts {
+			hosts[i].Pivot = core.PivotCertSHA256
+			hosts[i].Metadata["pivot_value"] = cert.FingerprintSHA256
+			if len(cert.Names) > 0 {
+				hosts[i].Metadata["cert_names"] = cert.Names
+			}
+		}
+		records = append(records, hosts...)
+	}
+	if len(errs) > 0 {
+		return records, fmt.Errorf("%d of %d certificate lookups failed: %w", len(errs), len(certs), errors.Join(errs...))
+	}
+	return records, nil
+}
+
+// search runs a CenQL host query; via describes the query in the returned records
+func search(ctx context.Context, query, via, token, orgID string, timeout time.Duration) ([]core.PassiveIP, error) {
+	body, err := post(ctx, query, token, orgID, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse successful response
+	var censysResp CensysResponse
+	if err := json.Unmarshal(body, &censysResp); err != nil {
+		return nil, fmt.Errorf("failed to parse Censys response: %w", err)
+	}
+
+	return parseHits(censysResp.Result.Hits, via), nil
+}
+
+// post runs a CenQL query and returns the body of a successful response
+func post(ctx context.Context, query, token, orgID string, timeout time.Duration) ([]byte, error) {
 	// Create POST request body
 	reqBody := CensysV3Request{
 		Query:    query,
@@ -140,7 +290,7 @@ func searchWithToken(ctx context.Context, domain, token, orgID string, timeout t
 	}
 
 	// v3 Global Search API endpoint (POST)
-	url := "https://api.platform.censys.io/v3/global/search/query"
+	url := apiURL
 	// Add organization_id query parameter if provided (for paid plans)
 	if orgID != "" {
 		url += fmt.Sprintf("?organization_id=%s", orgID)
@@ -211,42 +361,72 @@ func searchWithToken(ctx context.Context, domain, token, orgID string, timeout t
 		return nil, fmt.Errorf("Censys returned status %d: %s", resp.StatusCode, strings.TrimSpace(bodyStr))
 	}
 
-	// Parse successful response
-	var censysResp CensysResponse
-	if err := json.Unmarshal(body, &censysResp); err != nil {
-		return nil, fmt.Errorf("failed to parse Censys response: %w", err)
+	// Check for API error in JSON (even with 200 OK)
+	var errResp CensysResponse
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		return nil, fmt.Errorf("Censys API error: %s", errResp.Error)
 	}
 
-	// Check for API error in JSON (even with 200 OK)
-	if censysResp.Error != "" {
-		return nil, fmt.Errorf("Censys API error: %s", censysResp.Error)
+	return body, nil
+}
+
+// newestCertificates returns up to limit distinct certificates, most recently issued first
+func newestCertificates(hits []CensysCertHit, limit int) []CensysCertHit {
+	seen := make(map[string]bool)
+	certs := make([]CensysCertHit, 0, len(hits))
+	for _, hit := range hits {
+		hit.FingerprintSHA256 = strings.ToLower(strings.TrimSpace(hit.FingerprintSHA256))
+		if hit.FingerprintSHA256 == "" || seen[hit.FingerprintSHA256] {
+			continue
+		}
+		seen[hit.FingerprintSHA256] = true
+		certs = append(certs, hit)
 	}
 
-	// Extract unique IPv4 addresses
-	ipSet := make(map[string]bool)
-	for _, hit := range censysResp.Result.Hits {
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].issued().After(certs[j].issued())
+	})
+	if len(certs) > limit {
+		certs = certs[:limit]
+	}
+	return certs
+}
+
+// parseHits converts search hits into passive IP records, one per IPv4 address
+func parseHits(hits []CensysHit, via string) []core.PassiveIP {
+	seen := make(map[string]bool)
+	records := make([]core.PassiveIP, 0, len(hits))
+
+	for _, hit := range hits {
 		ip := strings.TrimSpace(hit.IP)
-		if ip == "" {
+		if ip == "" || seen[ip] {
 			continue
 		}
 
 		// Validate and filter IPv4 only
 		parsedIP := net.ParseIP(ip)
-		if parsedIP == nil {
+		if parsedIP == nil || parsedIP.To4() == nil {
 			continue
 		}
+		seen[ip] = true
 
-		// Check if IPv4 (To4() returns nil for IPv6)
-		if parsedIP.To4() != nil {
-			ipSet[ip] = true
+		record := core.PassiveIP{IP: ip, Source: "censys", Via: via, Metadata: make(map[string]interface{})}
+		if updated, err := time.Parse(time.RFC3339, hit.LastUpdatedAt); err == nil {
+			record.LastSeen = updated
+		}
+		if as := hit.AutonomousSystem; as.ASN != 0 {
+			record.Metadata["asn"] = strings.TrimSpace(fmt.Sprintf("AS%d %s", as.ASN, as.Name))
+		}
+		if hit.Location.CountryCode != "" {
+			record.Metadata["country_code"] = hit.Location.CountryCode
+		}
+		if len(hit.Names) > 0 {
+			record.Hostname = hit.Names[0]
+			record.Hostnames = hit.Names
 		}
-	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+		records = append(records, record)
 	}
 
-	return ips, nil
+	return records
 }
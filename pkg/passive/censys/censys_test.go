@@ -3,10 +3,15 @@ package censys
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 func TestSearchHosts_NoTokens(t *testing.T) {
@@ -109,7 +114,7 @@ func TestSearchWithToken_HTTPError(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithToken(ctx, "example.com", "invalid_token", "", 1*time.Second)
+	_, err := SearchWithToken(ctx, "example.com", "invalid_token", "", 1*time.Second)
 	if err == nil {
 		t.Log("Expected error for invalid token")
 	}
@@ -123,7 +128,7 @@ func TestSearchWithToken_InvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
+	_, err := SearchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
 	if err == nil {
 		t.Log("Expected JSON parsing error")
 	}
@@ -214,7 +219,7 @@ func TestSearchWithToken_IPv6Filtering(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
+	_, err := SearchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
 	if err == nil {
 		t.Log("IPv6 filtering test completed")
 	}
@@ -238,7 +243,7 @@ func TestSearchWithToken_EmptyIP(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
+	_, err := SearchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
 	if err == nil {
 		t.Log("Empty IP filtering test completed")
 	}
@@ -291,7 +296,7 @@ func TestSearchWithToken_LongErrorBody(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
+	_, err := SearchWithToken(ctx, "example.com", "test_token", "", 1*time.Second)
 	if err == nil {
 		t.Log("Expected error for bad request")
 	}
@@ -311,3 +316,178 @@ func TestCensysLocation(t *testing.T) {
 		t.Errorf("Latitude = %f", loc.Coordinates[0])
 	}
 }
+
+func TestParseHits(t *testing.T) {
+	hits := []CensysHit{
+		{
+			IP:               "192.0.2.1",
+			Names:            []string{"origin.example.com"},
+			LastUpdatedAt:    "2024-05-01T12:00:00Z",
+			AutonomousSystem: CensysAutonomousSystem{ASN: 64500, Name: "EXAMPLE-NET"},
+			Location:         CensysLocation{Country: "Germany", CountryCode: "DE"},
+		},
+		{IP: "192.0.2.1"},
+		{IP: "2001:db8::1"},
+		{IP: "  "},
+		{IP: "192.0.2.2"},
+	}
+
+	records := parseHits(hits, "Censys cert names")
+
+	if len(records) != 2 {
+		t.Fatalf("parseHits() returned %d records, want 2", len(records))
+	}
+	first := records[0]
+	if first.Source != "censys" || first.IP != "192.0.2.1" || first.Hostname != "origin.example.com" {
+		t.Errorf("record = %s/%s, want 192.0.2.1/censys", first.IP, first.Source)
+	}
+	if first.LastSeen.Format(time.RFC3339) != "2024-05-01T12:00:00Z" {
+		t.Errorf("LastSeen = %v", first.LastSeen)
+	}
+	if first.Metadata["asn"] != "AS64500 EXAMPLE-NET" || first.Metadata["country_code"] != "DE" {
+		t.Errorf("unexpected metadata: %v", first.Metadata)
+	}
+	if len(records[1].Metadata) != 0 || !records[1].LastSeen.IsZero() {
+		t.Errorf("bare hit should carry no metadata or timestamp: %+v", records[1])
+	}
+}
+
+func TestPivotQuery(t *testing.T) {
+	tests := []struct {
+		pivot core.Pivot
+		query string
+	}{
+		{core.Pivot{Kind: core.PivotFavicon, Value: "-2059846915"}, "host.services.endpoints.http.favicons.hash_shodan: -2059846915"},
+		{core.Pivot{Kind: core.PivotTitle, Value: "Shop"}, `host.services.endpoints.http.html_title: "Shop"`},
+		{core.Pivot{Kind: core.PivotCertSHA256, Value: "ab12"}, `host.services.cert.fingerprint_sha256: "ab12"`},
+		{core.Pivot{Kind: core.PivotCertSubject, Value: "*.example.com"}, `host.services.cert.parsed.subject.common_name: "*.example.com"`},
+	}
+	for _, tt := range tests {
+		query, via, err := PivotQuery(tt.pivot)
+		if err != nil || query != tt.query || via == "" {
+			t.Errorf("PivotQuery(%v) = %q, %q, %v, want %q", tt.pivot, query, via, err, tt.query)
+		}
+	}
+	if _, _, err := PivotQuery(core.Pivot{Kind: "nope"}); !errors.Is(err, core.ErrUnsupportedPivot) {
+		t.Errorf("PivotQuery(nope) error = %v, want ErrUnsupportedPivot", err)
+	}
+}
+
+func TestSearchPivotWithToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody CensysV3Request
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if want := `host.services.cert.fingerprint_sha256: "ab12"`; reqBody.Query != want {
+			t.Errorf("query = %q, want %q", reqBody.Query, want)
+		}
+		if got := r.URL.Query().Get("organization_id"); got != "org-1" {
+			t.Errorf("organization_id = %q, want org-1", got)
+		}
+		json.NewEncoder(w).Encode(CensysResponse{Code: 200, Result: CensysResult{Hits: []CensysHit{{IP: "192.0.2.10"}}}})
+	}))
+	defer server.Close()
+
+	oldURL := apiURL
+	apiURL = server.URL
+	defer func() { apiURL = oldURL }()
+
+	pivot := core.Pivot{Kind: core.PivotCertSHA256, Value: "ab12"}
+	records, err := SearchPivotWithToken(context.Background(), pivot, "test_token", "org-1", time.Second)
+	if err != nil {
+		t.Fatalf("SearchPivotWithToken() error: %v", err)
+	}
+	if len(records) != 1 || records[0].IP != "192.0.2.10" || records[0].Via != "Censys cert SHA-256" || records[0].Pivot != core.PivotCertSHA256 {
+		t.Errorf("SearchPivotWithToken() = %+v, want 192.0.2.10 via the certificate fingerprint", records)
+	}
+}
+
+func TestSearchCertificatesWithToken(t *testing.T) {
+	var hostQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody CensysV3Request
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody.Query == `cert.names: "example.com"` {
+			json.NewEncoder(w).Encode(CensysCertResponse{Result: CensysCertResult{Hits: []CensysCertHit{
+				{FingerprintSHA256: "aaaa", Names: []string{"example.com"}, Parsed: CensysCertParsed{ValidityPeriod: CensysValidityPeriod{NotBefore: "2023-01-01T00:00:00Z"}}},
+				{FingerprintSHA256: "BBBB", Names: []string{"*.example.com"}, Parsed: CensysCertParsed{ValidityPeriod: CensysValidityPeriod{NotBefore: "2024-06-01T00:00:00Z"}}},
+				{FingerprintSHA256: "aaaa"},
+				{FingerprintSHA256: ""},
+			}}})
+			return
+		}
+		hostQueries = append(hostQueries, reqBody.Query)
+		ip := "192.0.2.1"
+		if strings.Contains(reqBody.Query, "bbbb") {
+			ip = "192.0.2.2"
+		}
+		json.NewEncoder(w).Encode(CensysResponse{Result: CensysResult{Hits: []CensysHit{{IP: ip}}}})
+	}))
+	defer server.Close()
+
+	oldURL := apiURL
+	apiURL = server.URL
+	defer func() { apiURL = oldURL }()
+
+	records, err := SearchCertificatesWithToken(context.Background(), "example.com", "test_token", "", time.Second)
+	if err != nil {
+		t.Fatalf("SearchCertificatesWithToken() error: %v", err)
+	}
+
+	// One host lookup per distinct certificate, newest first
+	wantQueries := []string{
+		`host.services.cert.fingerprint_sha256: "bbbb"`,
+		`host.services.cert.fingerprint_sha256: "aaaa"`,
+	}
+	if !reflect.DeepEqual(hostQueries, wantQueries) {
+		t.Errorf("host queries = %v, want %v", hostQueries, wantQueries)
+	}
+	if len(records) != 2 || records[0].IP != "192.0.2.2" || records[1].IP != "192.0.2.1" {
+		t.Fatalf("SearchCertificatesWithToken() = %+v, want 192.0.2.2 and 192.0.2.1", records)
+	}
+	first := records[0]
+	if first.Via != "Censys certificate search" || first.Pivot != core.PivotCertSHA256 || first.Metadata["pivot_value"] != "bbbb" {
+		t.Errorf("record = %+v, want the certificate pivot", first)
+	}
+	if names, _ := first.Metadata["cert_names"].([]string); !reflect.DeepEqual(names, []string{"*.example.com"}) {
+		t.Errorf("cert_names = %v, want [*.example.com]", first.Metadata["cert_names"])
+	}
+}
+
+func TestSearchCertificatesWithToken_PartialResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody CensysV3Request
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody.Query == `cert.names: "example.com"` {
+			json.NewEncoder(w).Encode(CensysCertResponse{Result: CensysCertResult{Hits: []CensysCertHit{
+				{FingerprintSHA256: "aaaa"},
+				{FingerprintSHA256: "bbbb"},
+			}}})
+			return
+		}
+		if strings.Contains(reqBody.Query, "aaaa") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(CensysResponse{Result: CensysResult{Hits: []CensysHit{{IP: "192.0.2.2"}}}})
+	}))
+	defer server.Close()
+
+	oldURL := apiURL
+	apiURL = server.URL
+	defer func() { apiURL = oldURL }()
+
+	records, err := SearchCertificatesWithToken(context.Background(), "example.com", "test_token", "", time.Second)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 certificate lookups failed") {
+		t.Errorf("SearchCertificatesWithToken() error = %v, want the failed lookup", err)
+	}
+	if len(records) != 1 || records[0].IP != "192.0.2.2" {
+		t.Errorf("SearchCertificatesWithToken() = %+v, want the host of the other certificate", records)
+	}
+}
+
+func TestNewestCertificates(t *testing.T) {
+	hits := []CensysCertHit{{FingerprintSHA256: "a"}, {FingerprintSHA256: "b"}, {FingerprintSHA256: "c"}}
+	if got := newestCertificates(hits, 2); len(got) != 2 || got[0].FingerprintSHA256 != "a" {
+		t.Errorf("newestCertificates() = %v, want the first 2 when dates are unknown", got)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // CTEntry represents a certificate transparency log entry
@@ -24,8 +26,12 @@ type CTEntry struct {
 	NotAfter       string `json:"not_after"`
 }
 
+// notBeforeLayout is the format of CTEntry.NotBefore
+const notBeforeLayout = "2006-01-02T15:04:05"
+
 // SearchCrtSh queries crt.sh for certificates matching the domain
-func SearchCrtSh(ctx context.Context, domain string, timeout time.Duration) ([]string, error) {
+// Each IP records the certificate SAN that resolved to it
+func SearchCrtSh(ctx context.Context, domain string, timeout time.Duration) ([]core.PassiveIP, error) {
 	// Use JSON API endpoint (not HTML)
 	url := fmt.Sprintf("https://crt.sh/json?q=%s", domain)
 
@@ -33,16 +39,16 @@ func SearchCrtSh(ctx context.Context, domain string, timeout time.Duration) ([]s
 	if err != nil {
 		// Check if crt.sh is down (502/503/504)
 		if strings.Contains(err.Error(), "502") || strings.Contains(err.Error(), "503") || strings.Contains(err.Error(), "504") {
-			return []string{}, fmt.Errorf("crt.sh appears to be down (gateway error). Please try again later: %w", err)
+			return nil, fmt.Errorf("crt.sh appears to be down (gateway error). Please try again later: %w", err)
 		}
-		return []string{}, err
+		return nil, err
 	}
 
 	return ips, nil
 }
 
 // searchCrtShURL performs the actual HTTP request and parsing
-func searchCrtShURL(ctx context.Context, url, domain string, timeout time.Duration) ([]string, error) {
+func searchCrtShURL(ctx context.Context, url, domain string, timeout time.Duration) ([]core.PassiveIP, error) {
 	client := &http.Client{
 		Timeout: timeout,
 	}
@@ -87,58 +93,82 @@ func searchCrtShURL(ctx context.Context, url, domain string, timeout time.Durati
 		return nil, fmt.Errorf("failed to parse CT logs: %w", err)
 	}
 
-	// Extract unique subdomains
-	subdomainSet := make(map[string]bool)
+	// Extract unique subdomains, remembering when each was first certified
+	firstCertified := make(map[string]time.Time)
+	var subdomains []string
 	for _, entry := range entries {
+		notBefore, _ := time.Parse(notBeforeLayout, entry.NotBefore)
+
 		// Parse name_value field which contains SANs (Subject Alternative Names)
 		names := strings.Split(entry.NameValue, "\n")
 		for _, name := range names {
 			name = strings.TrimSpace(strings.ToLower(name))
 			// Skip wildcards and add valid subdomains
-			if name != "" && !strings.HasPrefix(name, "*") && strings.HasSuffix(name, domain) {
-				subdomainSet[name] = true
+			if name == "" || strings.HasPrefix(name, "*") || !strings.HasSuffix(name, domain) {
+				continue
+			}
+			first, seen := firstCertified[name]
+			if !seen {
+				subdomains = append(subdomains, name)
+				firstCertified[name] = notBefore
+			} else if !notBefore.IsZero() && (first.IsZero() || notBefore.Before(first)) {
+				firstCertified[name] = notBefore
 			}
 		}
 	}
 
-	// Convert to slice
-	subdomains := make([]string, 0, len(subdomainSet))
-	for subdomain := range subdomainSet {
-		subdomains = append(subdomains, subdomain)
-	}
+	return resolveCertified(ctx, subdomains, firstCertified, timeout)
+}
 
-	// Resolve subdomains to IPs
-	return resolveSubdomainsToIPs(ctx, subdomains, timeout)
+// resolveCertified resolves certificate names to IPs, dating each IP from when its
+// name was first certified
+func resolveCertified(ctx context.Context, names []string, firstCertified map[string]time.Time, timeout time.Duration) ([]core.PassiveIP, error) {
+	records, err := resolveSubdomainsToIPs(ctx, names, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		if first := firstCertified[records[i].Hostname]; !first.IsZero() {
+			records[i].FirstSeen = first
+		}
+	}
+	return records, nil
 }
 
 // resolveSubdomainsToIPs resolves a list of subdomains to their IP addresses
-func resolveSubdomainsToIPs(ctx context.Context, subdomains []string, timeout time.Duration) ([]string, error) {
-	ipSet := make(map[string]bool)
-	resolver := &net.Resolver{}
+// Each SAN/IP pair is reported once, so names sharing an IP are all kept
+func resolveSubdomainsToIPs(ctx context.Context, subdomains []string, timeout time.Duration) ([]core.PassiveIP, error) {
+	seen := make(map[string]bool)
+	result := make([]core.PassiveIP, 0)
 
 	for _, subdomain := range subdomains {
 		// Create context with timeout for each lookup
 		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
 
-		ips, err := resolver.LookupIP(lookupCtx, "ip4", subdomain)
+		ips, err := resolver.Default().LookupHost(lookupCtx, subdomain)
 		cancel()
 
 		if err != nil {
 			continue // Skip failed resolutions
 		}
 
+		// Resolved addresses are current as of now
+		now := time.Now()
 		for _, ip := range ips {
-			if ip.To4() != nil {
-				ipSet[ip.String()] = true
+			if seen[subdomain+"|"+ip] {
+				continue
 			}
+			seen[subdomain+"|"+ip] = true
+			result = append(result, core.PassiveIP{
+				IP:        ip,
+				Source:    "ct",
+				Hostname:  subdomain,
+				Via:       "CT SAN",
+				FirstSeen: now,
+				LastSeen:  now,
+			})
 		}
 	}
 
-	// Convert to slice
-	result := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		result = append(result, ip)
-	}
-
 	return result, nil
 }
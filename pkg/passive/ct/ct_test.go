@@ -188,10 +188,10 @@ func TestResolveSubdomainsToIPs_Deduplication(t *testing.T) {
 	// Should deduplicate IPs
 	seen := make(map[string]bool)
 	for _, ip := range ips {
-		if seen[ip] {
-			t.Errorf("Duplicate IP found: %s", ip)
+		if seen[ip.IP] {
+			t.Errorf("Duplicate IP found: %s", ip.IP)
 		}
-		seen[ip] = true
+		seen[ip.IP] = true
 	}
 }
 
@@ -219,12 +219,32 @@ func TestResolveSubdomainsToIPs_IPv4Only(t *testing.T) {
 
 	// All IPs should be IPv4 format
 	for _, ip := range ips {
-		if len(ip) > 15 { // IPv6 addresses are longer
-			t.Errorf("Expected IPv4 only, got: %s", ip)
+		if len(ip.IP) > 15 { // IPv6 addresses are longer
+			t.Errorf("Expected IPv4 only, got: %s", ip.IP)
 		}
 	}
 }
 
+func TestResolveSubdomainsToIPs_Provenance(t *testing.T) {
+	ctx := context.Background()
+
+	ips, err := resolveSubdomainsToIPs(ctx, []string{"localhost"}, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ips) == 0 {
+		t.Skip("localhost did not resolve to IPv4")
+	}
+
+	ip := ips[0]
+	if ip.Source != "ct" || ip.Via != "CT SAN" || ip.Hostname != "localhost" {
+		t.Errorf("record = %+v, want ct/CT SAN/localhost", ip)
+	}
+	if ip.LastSeen.IsZero() {
+		t.Error("LastSeen should be set to resolution time")
+	}
+}
+
 // Helper functions to avoid importing strings package in tests
 func containsStr(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 || indexOf(s, substr) >= 0)
@@ -0,0 +1,131 @@
+// Package cttest provides an in-memory RFC 6962 Certificate Transparency log for tests
+package cttest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Log is a test server speaking the RFC 6962 get-sth and get-entries endpoints
+type Log struct {
+	*httptest.Server
+
+	// MaxEntries caps the entries served per get-entries call (0 = no cap)
+	MaxEntries int
+
+	mu     sync.Mutex
+	leaves [][]byte
+}
+
+// NewLog starts an empty log; the caller must Close it
+func NewLog() *Log {
+	l := &Log{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ct/v1/get-sth", l.getSTH)
+	mux.HandleFunc("/ct/v1/get-entries", l.getEntries)
+	l.Server = httptest.NewServer(mux)
+	return l
+}
+
+// AddCert appends a certificate entry logged at timestamp
+func (l *Log) AddCert(der []byte, timestamp time.Time) {
+	l.AddLeaf(Leaf(0, timestamp, opaque24(der)))
+}
+
+// AddPrecert appends a precertificate entry for a TBSCertificate logged at timestamp
+func (l *Log) AddPrecert(tbs []byte, timestamp time.Time) {
+	var issuerKeyHash [32]byte
+	l.AddLeaf(Leaf(1, timestamp, append(issuerKeyHash[:], opaque24(tbs)...)))
+}
+
+// AddLeaf appends a raw Merkle tree leaf
+func (l *Log) AddLeaf(leaf []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leaves = append(l.leaves, leaf)
+}
+
+// Leaf encodes a timestamped MerkleTreeLeaf with an empty extensions field
+func Leaf(entryType uint16, timestamp time.Time, entry []byte) []byte {
+	leaf := make([]byte, 12, 12+len(entry)+2)
+	binary.BigEndian.PutUint64(leaf[2:10], uint64(timestamp.UnixMilli()))
+	binary.BigEndian.PutUint16(leaf[10:12], entryType)
+	leaf = append(leaf, entry...)
+	return append(leaf, 0, 0)
+}
+
+// Certificate returns a self-signed certificate for names (the first is also the CN)
+func Certificate(names []string, notBefore time.Time) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notBefore.UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(90 * 24 * time.Hour),
+	}
+	return x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+}
+
+// getSTH serves the tree size of the latest signed tree head
+func (l *Log) getSTH(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	size := len(l.leaves)
+	l.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tree_size":           size,
+		"timestamp":           time.Now().UnixMilli(),
+		"sha256_root_hash":    "",
+		"tree_head_signature": "",
+	})
+}
+
+// getEntries serves leaves start..end inclusive, capped at MaxEntries
+func (l *Log) getEntries(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start, err1 := strconv.Atoi(r.URL.Query().Get("start"))
+	end, err2 := strconv.Atoi(r.URL.Query().Get("end"))
+	if err1 != nil || err2 != nil || start < 0 || end < start || start >= len(l.leaves) {
+		http.Error(w, `{"error":"invalid range"}`, http.StatusBadRequest)
+		return
+	}
+	if end >= len(l.leaves) {
+		end = len(l.leaves) - 1
+	}
+	if l.MaxEntries > 0 && end-start+1 > l.MaxEntries {
+		end = start + l.MaxEntries - 1
+	}
+
+	type entry struct {
+		LeafInput []byte `json:"leaf_input"`
+		ExtraData []byte `json:"extra_data"`
+	}
+	entries := make([]entry, 0, end-start+1)
+	for _, leaf := range l.leaves[start : end+1] {
+		entries = append(entries, entry{LeafInput: leaf, ExtraData: []byte{}})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+}
+
+// opaque24 encodes a TLS opaque<1..2^24-1> value
+func opaque24(data []byte) []byte {
+	n := len(data)
+	return append([]byte{byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
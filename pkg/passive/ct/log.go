@@ -0,0 +1,289 @@
+package ct
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// RFC 6962 log entry types
+const (
+	X509Entry    = 0
+	PrecertEntry = 1
+)
+
+// DefaultWindow is how many of each log's newest entries SearchLogs reads unless
+// configured otherwise (ct_window / --ct-window)
+const DefaultWindow = 16384
+
+// batchSize is the number of entries requested per get-entries call
+// Logs may return fewer; reading continues after the last entry returned.
+const batchSize = 256
+
+// DefaultLogs are the RFC 6962 logs read when crt.sh fails and none are configured.
+// Logs are sharded by certificate expiry, so the list needs updating over time.
+var DefaultLogs = []string{
+	"https://ct.googleapis.com/logs/us1/argon2026h2/",
+	"https://ct.googleapis.com/logs/eu1/xenon2026h2/",
+	"https://ct.cloudflare.com/logs/nimbus2026/",
+}
+
+// LogEntry is a certificate read from a CT log
+type LogEntry struct {
+	Index     uint64
+	Timestamp time.Time // When the log accepted the certificate
+	Precert   bool
+	Names     []string // Lowercased subject CN and DNS SANs
+	NotBefore time.Time
+}
+
+// LogClient reads an RFC 6962 Certificate Transparency log
+type LogClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewLogClient returns a client for the log at url, e.g. "https://ct.example.com/2026/"
+func NewLogClient(url string, timeout time.Duration) *LogClient {
+	return &LogClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// TreeSize returns the number of entries in the log's latest signed tree head
+func (c *LogClient) TreeSize(ctx context.Context) (uint64, error) {
+	var sth struct {
+		TreeSize uint64 `json:"tree_size"`
+	}
+	if err := c.get(ctx, "/ct/v1/get-sth", &sth); err != nil {
+		return 0, err
+	}
+	return sth.TreeSize, nil
+}
+
+// Entries returns the log entries from start to end inclusive. Logs may return
+// fewer entries than asked for. Entries that cannot be parsed have no names.
+func (c *LogClient) Entries(ctx context.Context, start, end uint64) ([]LogEntry, error) {
+	var resp struct {
+		Entries []struct {
+			LeafInput []byte `json:"leaf_input"`
+		} `json:"entries"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("/ct/v1/get-entries?start=%d&end=%d", start, end), &resp); err != nil {
+		return nil, err
+	}
+
+	entries := make([]LogEntry, 0, len(resp.Entries))
+	for i, raw := range resp.Entries {
+		entry, err := ParseLeaf(raw.LeafInput)
+		if err != nil {
+			entry = &LogEntry{}
+		}
+		entry.Index = start + uint64(i)
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// get fetches a log endpoint and decodes its JSON response
+func (c *LogClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "origindive/1.0")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("CT log request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyStr := strings.TrimSpace(string(body))
+		if len(bodyStr) > 200 {
+			bodyStr = bodyStr[:200] + "..."
+		}
+		return fmt.Errorf("CT log returned status %d: %s", resp.StatusCode, bodyStr)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse CT log response: %w", err)
+	}
+	return nil
+}
+
+// ParseLeaf decodes a get-entries leaf_input (an RFC 6962 MerkleTreeLeaf) holding
+// a certificate or a precertificate
+func ParseLeaf(leaf []byte) (*LogEntry, error) {
+	// version(1) leaf_type(1) timestamp(8) entry_type(2)
+	if len(leaf) < 12 || leaf[0] != 0 || leaf[1] != 0 {
+		return nil, errors.New("invalid Merkle tree leaf")
+	}
+	entry := &LogEntry{Timestamp: time.UnixMilli(int64(binary.BigEndian.Uint64(leaf[2:10]))).UTC()}
+
+	var cert *x509.Certificate
+	var err error
+	switch binary.BigEndian.Uint16(leaf[10:12]) {
+	case X509Entry:
+		var der []byte
+		if der, err = opaque24(leaf[12:]); err == nil {
+			cert, err = x509.ParseCertificate(der)
+		}
+	case PrecertEntry:
+		// issuer_key_hash(32) followed by the TBSCertificate
+		if len(leaf) < 12+32 {
+			return nil, errors.New("truncated precertificate entry")
+		}
+		var tbs []byte
+		if tbs, err = opaque24(leaf[12+32:]); err == nil {
+			cert, err = parseTBS(tbs)
+		}
+		entry.Precert = true
+	default:
+		return nil, fmt.Errorf("unknown log entry type %d", binary.BigEndian.Uint16(leaf[10:12]))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate in log entry: %w", err)
+	}
+
+	entry.NotBefore = cert.NotBefore
+	seen := make(map[string]bool)
+	for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			entry.Names = append(entry.Names, name)
+		}
+	}
+	return entry, nil
+}
+
+// opaque24 reads a TLS opaque<1..2^24-1> value
+func opaque24(data []byte) ([]byte, error) {
+	if len(data) < 3 {
+		return nil, errors.New("truncated length")
+	}
+	n := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	if len(data)-3 < n {
+		return nil, errors.New("truncated value")
+	}
+	return data[3 : 3+n], nil
+}
+
+// parseTBS parses a precertificate's TBSCertificate by wrapping it in a certificate
+// with the same signature algorithm and an empty signature
+func parseTBS(tbs []byte) (*x509.Certificate, error) {
+	var prefix struct {
+		Version   int `asn1:"optional,explicit,default:0,tag:0"`
+		Serial    asn1.RawValue
+		Algorithm asn1.RawValue
+	}
+	if _, err := asn1.Unmarshal(tbs, &prefix); err != nil {
+		return nil, err
+	}
+	der, err := asn1.Marshal(struct {
+		TBS       asn1.RawValue
+		Algorithm asn1.RawValue
+		Signature asn1.BitString
+	}{asn1.RawValue{FullBytes: tbs}, prefix.Algorithm, asn1.BitString{}})
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// SearchLogs reads the newest window entries of each RFC 6962 log directly and
+// resolves the certificate names under domain, like SearchCrtSh.
+// Logs that cannot be read are skipped, and the names in a log read only partly
+// (e.g. until ctx expired) are kept. It fails if no log could be read or no
+// certificate in the window names the domain.
+func SearchLogs(ctx context.Context, domain string, logs []string, window uint64, timeout time.Duration) ([]core.PassiveIP, error) {
+	domain = strings.ToLower(domain)
+	firstCertified := make(map[string]time.Time)
+	var names []string
+	var lastErr error
+	read := 0
+
+	for _, url := range logs {
+		entries := 0
+		err := readTail(ctx, NewLogClient(url, timeout), window, func(entry LogEntry) {
+			entries++
+			for _, name := range entry.Names {
+				if strings.HasPrefix(name, "*") || (name != domain && !strings.HasSuffix(name, "."+domain)) {
+					continue
+				}
+				first, seen := firstCertified[name]
+				if !seen {
+					names = append(names, name)
+					firstCertified[name] = entry.NotBefore
+				} else if !entry.NotBefore.IsZero() && (first.IsZero() || entry.NotBefore.Before(first)) {
+					firstCertified[name] = entry.NotBefore
+				}
+			}
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", url, err)
+			if entries == 0 {
+				continue
+			}
+		}
+		read++
+	}
+
+	if read == 0 {
+		if lastErr == nil {
+			return nil, errors.New("no CT logs configured")
+		}
+		return nil, fmt.Errorf("no CT log could be read: %w", lastErr)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no certificate for %s in the newest %d entries of %d CT logs", domain, window, read)
+	}
+	return resolveCertified(ctx, names, firstCertified, timeout)
+}
+
+// readTail passes each of the newest window entries of a log to visit
+func readTail(ctx context.Context, log *LogClient, window uint64, visit func(LogEntry)) error {
+	size, err := log.TreeSize(ctx)
+	if err != nil {
+		return err
+	}
+	start := uint64(0)
+	if size > window {
+		start = size - window
+	}
+
+	for start < size {
+		end := start + batchSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		entries, err := log.Entries(ctx, start, end)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return errors.New("log returned no entries")
+		}
+		for _, entry := range entries {
+			visit(entry)
+		}
+		start += uint64(len(entries))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package ct
+
+import (
+	"context"
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/passive/ct/cttest"
+)
+
+// testCert returns a DER certificate and its TBSCertificate
+func testCert(t *testing.T, notBefore time.Time, names ...string) ([]byte, []byte) {
+	t.Helper()
+	der, err := cttest.Certificate(names, notBefore)
+	if err != nil {
+		t.Fatalf("Certificate() error: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error: %v", err)
+	}
+	return der, cert.RawTBSCertificate
+}
+
+func TestParseLeaf(t *testing.T) {
+	logged := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	notBefore := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)
+	der, tbs := testCert(t, notBefore, "WWW.Example.com", "example.com", "www.example.com")
+
+	entry, err := ParseLeaf(cttest.Leaf(X509Entry, logged, append([]byte{byte(len(der) >> 16), byte(len(der) >> 8), byte(len(der))}, der...)))
+	if err != nil {
+		t.Fatalf("ParseLeaf(x509) error: %v", err)
+	}
+	if want := []string{"www.example.com", "example.com"}; !reflect.DeepEqual(entry.Names, want) || entry.Precert {
+		t.Errorf("ParseLeaf(x509) = %+v, want names %v", entry, want)
+	}
+	if !entry.Timestamp.Equal(logged) || !entry.NotBefore.Equal(notBefore) {
+		t.Errorf("Timestamp/NotBefore = %v/%v, want %v/%v", entry.Timestamp, entry.NotBefore, logged, notBefore)
+	}
+
+	precert := append(make([]byte, 32), append([]byte{byte(len(tbs) >> 16), byte(len(tbs) >> 8), byte(len(tbs))}, tbs...)...)
+	entry, err = ParseLeaf(cttest.Leaf(PrecertEntry, logged, precert))
+	if err != nil {
+		t.Fatalf("ParseLeaf(precert) error: %v", err)
+	}
+	if want := []string{"www.example.com", "example.com"}; !reflect.DeepEqual(entry.Names, want) || !entry.Precert {
+		t.Errorf("ParseLeaf(precert) = %+v, want precert names %v", entry, want)
+	}
+
+	invalid := [][]byte{
+		nil,
+		{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, // Unknown version
+		cttest.Leaf(7, logged, nil),          // Unknown entry type
+		cttest.Leaf(X509Entry, logged, []byte{0, 0, 9, 1}), // Truncated certificate
+		cttest.Leaf(PrecertEntry, logged, []byte{1, 2}),    // Truncated issuer key hash
+	}
+	for i, leaf := range invalid {
+		if _, err := ParseLeaf(leaf); err == nil {
+			t.Errorf("ParseLeaf(invalid #%d) should fail", i)
+		}
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	log := cttest.NewLog()
+	defer log.Close()
+	log.MaxEntries = 2 // Force short get-entries responses
+
+	logged := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"old.example.com", "a.example.com", "b.example.com"} {
+		der, _ := testCert(t, logged, name)
+		log.AddCert(der, logged.Add(time.Duration(i)*time.Hour))
+	}
+	_, tbs := testCert(t, logged, "c.example.com")
+	log.AddPrecert(tbs, logged)
+	log.AddLeaf([]byte("garbage"))
+
+	var indexes []uint64
+	var names []string
+	err := readTail(context.Background(), NewLogClient(log.URL+"/", time.Second), 4, func(entry LogEntry) {
+		indexes = append(indexes, entry.Index)
+		names = append(names, entry.Names...)
+	})
+	if err != nil {
+		t.Fatalf("readTail() error: %v", err)
+	}
+	if want := []uint64{1, 2, 3, 4}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("indexes = %v, want %v", indexes, want)
+	}
+	if want := []string{"a.example.com", "b.example.com", "c.example.com"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestSearchLogs(t *testing.T) {
+	log := cttest.NewLog()
+	defer log.Close()
+
+	der, _ := testCert(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "localhost")
+	log.AddCert(der, time.Now())
+	_, tbs := testCert(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "localhost", "*.localhost", "notlocalhost")
+	log.AddPrecert(tbs, time.Now())
+
+	// An unreachable log is skipped
+	records, err := SearchLogs(context.Background(), "localhost", []string{"http://127.0.0.1:1", log.URL}, DefaultWindow, 2*time.Second)
+	if err != nil {
+		t.Fatalf("SearchLogs() error: %v", err)
+	}
+	if len(records) == 0 {
+		t.Skip("localhost did not resolve to IPv4")
+	}
+	if records[0].Hostname != "localhost" || records[0].Source != "ct" {
+		t.Errorf("record = %+v, want ct localhost", records[0])
+	}
+	if got := records[0].FirstSeen.Format("2006-01-02"); got != "2025-01-01" {
+		t.Errorf("FirstSeen = %s, want the earliest certificate (2025-01-01)", got)
+	}
+
+	if _, err := SearchLogs(context.Background(), "localhost", []string{"http://127.0.0.1:1"}, DefaultWindow, time.Second); err == nil {
+		t.Error("SearchLogs() with no readable log should fail")
+	}
+	if _, err := SearchLogs(context.Background(), "localhost", nil, DefaultWindow, time.Second); err == nil {
+		t.Error("SearchLogs() without logs should fail")
+	}
+	if _, err := SearchLogs(context.Background(), "example.com", []string{log.URL}, DefaultWindow, time.Second); err == nil {
+		t.Error("SearchLogs() without a certificate for the domain should fail")
+	}
+}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+)
+
+// DKIMSelectors are the DKIM selectors LookupDKIM checks by default: generic names
+// and those of common mail providers (Microsoft 365, Google Workspace, Mailchimp, ...)
+var DKIMSelectors = []string{
+	"default", "dkim", "mail", "email", "smtp", "mx", "s1", "s2", "k1", "k2",
+	"selector1", "selector2", "google", "mandrill", "mailjet", "pm", "zoho", "everlytickey1",
+}
+
+// DMARCRecord is the DMARC policy of a domain
+type DMARCRecord struct {
+	Record  string
+	Policy  string   // p= tag: none, quarantine or reject
+	Reports []string // Hosts receiving aggregate (rua) and failure (ruf) reports
+}
+
+// DKIMKey is a DKIM public key record published under a selector
+type DKIMKey struct {
+	Selector string
+	Host     string // <selector>._domainkey.<domain>
+	Record   string
+}
+
+// LookupDMARC fetches the DMARC record published at _dmarc.<domain>
+func LookupDMARC(ctx context.Context, domain string, timeout time.Duration) (*DMARCRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	txt, err := resolver.Default().LookupTXT(ctx, "_dmarc."+domain)
+	if err != nil {
+		return nil, fmt.Errorf("DMARC lookup failed: %w", err)
+	}
+	for _, record := range txt {
+		tags := parseTags(record)
+		if !strings.EqualFold(tags["v"], "DMARC1") {
+			continue
+		}
+
+		dmarc := &DMARCRecord{Record: record, Policy: strings.ToLower(tags["p"])}
+		for _, uri := range strings.Split(tags["rua"]+","+tags["ruf"], ",") {
+			// mailto:dmarc@example.com!10m
+			uri, _, _ = strings.Cut(strings.TrimSpace(uri), "!")
+			_, host, ok := strings.Cut(uri, "@")
+			host = strings.ToLower(host)
+			if ok && host != "" && !containsString(dmarc.Reports, host) {
+				dmarc.Reports = append(dmarc.Reports, host)
+			}
+		}
+		return dmarc, nil
+	}
+	return nil, fmt.Errorf("no DMARC record found for %s", domain)
+}
+
+// LookupDKIM checks which selectors publish a DKIM key for domain
+func LookupDKIM(ctx context.Context, domain string, selectors []string, timeout time.Duration) []DKIMKey {
+	var keys []DKIMKey
+	for _, selector := range selectors {
+		host := selector + "._domainkey." + domain
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		txt, err := resolver.Default().LookupTXT(lookupCtx, host)
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, record := range txt {
+			// v=DKIM1 is optional, but every key record has a p= tag
+			if _, ok := parseTags(record)["p"]; ok {
+				keys = append(keys, DKIMKey{Selector: selector, Host: host, Record: record})
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// parseTags parses a "tag=value; tag=value" record, lowercasing the tag names
+func parseTags(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if ok {
+			tags[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+	return tags
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
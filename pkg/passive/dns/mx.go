@@ -4,8 +4,9 @@ package dns
 import (
 	"context"
 	"fmt"
-	"net"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // MXRecord represents an MX record with resolved IPs
@@ -17,15 +18,11 @@ type MXRecord struct {
 
 // LookupMX queries MX records and resolves them to IPs
 func LookupMX(ctx context.Context, domain string, timeout time.Duration) ([]MXRecord, error) {
-	resolver := &net.Resolver{
-		PreferGo: true,
-	}
-
 	mxCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Lookup MX records
-	mxRecords, err := resolver.LookupMX(mxCtx, domain)
+	mxRecords, err := resolver.Default().LookupMX(mxCtx, domain)
 	if err != nil {
 		return nil, fmt.Errorf("MX lookup failed: %w", err)
 	}
@@ -53,29 +50,12 @@ func LookupMX(ctx context.Context, domain string, timeout time.Duration) ([]MXRe
 	return results, nil
 }
 
-// resolveHost resolves a hostname to IPv4 addresses
+// resolveHost resolves a hostname to its IPv4 and IPv6 addresses
 func resolveHost(ctx context.Context, host string, timeout time.Duration) ([]string, error) {
-	resolver := &net.Resolver{
-		PreferGo: true,
-	}
-
 	hostCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	ips, err := resolver.LookupHost(hostCtx, host)
-	if err != nil {
-		return nil, err
-	}
-
-	// Filter IPv4 only
-	var ipv4s []string
-	for _, ip := range ips {
-		if net.ParseIP(ip).To4() != nil {
-			ipv4s = append(ipv4s, ip)
-		}
-	}
-
-	return ipv4s, nil
+	return resolver.Default().LookupHost(hostCtx, host)
 }
 
 // GetAllMXIPs extracts all unique IPs from MX records
@@ -2,8 +2,12 @@ package dns
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
 )
 
 func TestLookupMX_ValidDomain(t *testing.T) {
@@ -102,22 +106,25 @@ func TestResolveHost_InvalidHost(t *testing.T) {
 	}
 }
 
-func TestResolveHost_IPv4Only(t *testing.T) {
-	ctx := context.Background()
-	timeout := 5 * time.Second
+func TestLookupMX_IPv6(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+	zone.AddMX("example.com", "mail.example.com")
+	zone.AddA("mail.example.com", "192.0.2.25")
+	zone.AddAAAA("mail.example.com", "2001:db8::25")
 
-	// Use host that has both IPv4 and IPv6
-	ips, err := resolveHost(ctx, "google.com", timeout)
+	records, err := LookupMX(context.Background(), "example.com", time.Second)
 	if err != nil {
-		t.Logf("Resolve failed (expected in test env): %v", err)
-		return
+		t.Fatalf("LookupMX() error: %v", err)
 	}
-
-	// All returned IPs should be IPv4
-	for _, ip := range ips {
-		if len(ip) > 15 { // IPv6 addresses are longer
-			t.Errorf("Expected IPv4 only, got: %s", ip)
-		}
+	if len(records) != 1 {
+		t.Fatalf("LookupMX() = %+v, want one record", records)
+	}
+	ips := records[0].IPs
+	sort.Strings(ips)
+	if !reflect.DeepEqual(ips, []string{"192.0.2.25", "2001:db8::25"}) {
+		t.Errorf("IPs = %v, want both address families", ips)
 	}
 }
 
@@ -0,0 +1,242 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+)
+
+// maxSPFLookups is the RFC 7208 limit on DNS-querying terms (include, a, mx, ptr,
+// exists and redirect) evaluated for one domain
+const maxSPFLookups = 10
+
+// maxSPFMXHosts is the RFC 7208 limit on MX hosts resolved for one mx mechanism
+const maxSPFMXHosts = 10
+
+// maxSPFExpandBits bounds the networks expanded into single addresses (/24 for
+// IPv4, /120 for IPv6); larger ones are listed in SPFResult.Networks
+const maxSPFExpandBits = 8
+
+// SPFAddress is an address an SPF policy authorizes to send mail
+type SPFAddress struct {
+	IP        string
+	Domain    string // Domain whose record authorized it (the target, an include or a redirect)
+	Mechanism string // Term that produced it, e.g. "ip4:192.0.2.0/28" or "mx"
+}
+
+// SPFResult is the expanded SPF policy of a domain
+type SPFResult struct {
+	Record       string       // The domain's own v=spf1 record
+	Addresses    []SPFAddress // Authorized addresses, in policy order
+	Networks     []SPFAddress // Authorized networks too large to expand (IP holds the prefix)
+	Includes     []string     // Domains reached through include: and redirect=, in order
+	Lookups      int          // DNS-querying terms evaluated
+	LimitReached bool         // Evaluation stopped at maxSPFLookups
+}
+
+// LookupSPF fetches the SPF record of domain and expands it into the addresses it
+// authorizes: ip4/ip6 networks (up to 256 addresses each), a and mx mechanisms
+// resolved, include: and redirect= followed. Each domain is visited once, so
+// include loops end, and at most maxSPFLookups DNS-querying terms are evaluated.
+// Terms with the fail qualifier (-) and macros are skipped.
+func LookupSPF(ctx context.Context, domain string, timeout time.Duration) (*SPFResult, error) {
+	w := &spfWalker{
+		ctx:     ctx,
+		timeout: timeout,
+		result:  &SPFResult{},
+		visited: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+	record, err := w.record(domain)
+	if err != nil {
+		return nil, err
+	}
+	w.result.Record = record
+	w.walk(domain, record)
+	return w.result, nil
+}
+
+// spfWalker carries the state of one SPF expansion
+type spfWalker struct {
+	ctx     context.Context
+	timeout time.Duration
+	result  *SPFResult
+	visited map[string]bool // domains whose record was evaluated
+	seen    map[string]bool // addresses and networks already reported
+}
+
+// record returns the v=spf1 TXT record of domain
+func (w *spfWalker) record(domain string) (string, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
+	defer cancel()
+
+	txt, err := resolver.Default().LookupTXT(ctx, domain)
+	if err != nil {
+		return "", fmt.Errorf("SPF lookup failed: %w", err)
+	}
+	for _, record := range txt {
+		if fields := strings.Fields(record); len(fields) > 0 && strings.EqualFold(fields[0], "v=spf1") {
+			return record, nil
+		}
+	}
+	return "", fmt.Errorf("no SPF record found for %s", domain)
+}
+
+// walk expands the terms of domain's SPF record
+func (w *spfWalker) walk(domain, record string) {
+	w.visited[strings.ToLower(domain)] = true
+
+	var redirect string
+	hasAll := false
+	for _, term := range strings.Fields(record)[1:] {
+		if strings.Contains(term, "%") {
+			continue // Macros depend on the connecting client
+		}
+		if name, value, ok := strings.Cut(term, "="); ok {
+			if strings.EqualFold(name, "redirect") {
+				redirect = value
+			}
+			continue
+		}
+
+		qualifier := byte('+')
+		if strings.IndexByte("+-~?", term[0]) >= 0 {
+			qualifier, term = term[0], term[1:]
+		}
+		mechanism, arg, _ := strings.Cut(term, ":")
+		mechanism = strings.ToLower(mechanism)
+		if mechanism == "all" {
+			hasAll = true
+			continue
+		}
+		if qualifier == '-' {
+			continue // Explicitly not a sender
+		}
+
+		// a and mx take an optional domain and CIDR lengths: a, a/24, a:host/24
+		if before, _, ok := strings.Cut(mechanism, "/"); ok {
+			mechanism = before
+		}
+		target, _, _ := strings.Cut(arg, "/")
+		if target == "" {
+			target = domain
+		}
+
+		switch mechanism {
+		case "ip4", "ip6":
+			w.addNetwork(domain, term, arg)
+		case "a":
+			if w.lookup() {
+				w.addHost(domain, term, target)
+			}
+		case "mx":
+			if w.lookup() {
+				w.addMX(domain, term, target)
+			}
+		case "include":
+			if w.lookup() {
+				w.follow(arg)
+			}
+		case "ptr", "exists":
+			w.lookup()
+		}
+	}
+
+	if redirect != "" && !hasAll && w.lookup() {
+		w.follow(redirect)
+	}
+}
+
+// lookup counts a DNS-querying term, reporting false once the limit is reached
+func (w *spfWalker) lookup() bool {
+	if w.result.Lookups >= maxSPFLookups {
+		w.result.LimitReached = true
+		return false
+	}
+	w.result.Lookups++
+	return true
+}
+
+// follow evaluates the record of an included or redirected domain once
+func (w *spfWalker) follow(domain string) {
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" || w.visited[strings.ToLower(domain)] {
+		return
+	}
+	w.result.Includes = append(w.result.Includes, domain)
+	if record, err := w.record(domain); err == nil {
+		w.walk(domain, record)
+	} else {
+		w.visited[strings.ToLower(domain)] = true
+	}
+}
+
+// addNetwork adds an ip4/ip6 address, or every address of a network of at most
+// 2^maxSPFExpandBits addresses; larger networks are listed as networks
+func (w *spfWalker) addNetwork(domain, term, value string) {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		w.add(domain, term, addr)
+		return
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return
+	}
+	prefix = prefix.Masked()
+	if prefix.Addr().BitLen()-prefix.Bits() > maxSPFExpandBits {
+		if !w.seen[prefix.String()] {
+			w.seen[prefix.String()] = true
+			w.result.Networks = append(w.result.Networks, SPFAddress{IP: prefix.String(), Domain: domain, Mechanism: term})
+		}
+		return
+	}
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		w.add(domain, term, addr)
+	}
+}
+
+// addHost adds the addresses of host
+func (w *spfWalker) addHost(domain, term, host string) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
+	defer cancel()
+
+	addrs, err := resolver.Default().LookupHost(ctx, host)
+	if err != nil {
+		return
+	}
+	for _, a := range addrs {
+		if addr, err := netip.ParseAddr(a); err == nil {
+			w.add(domain, term, addr)
+		}
+	}
+}
+
+// addMX adds the addresses of the mail exchangers of host
+func (w *spfWalker) addMX(domain, term, host string) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.timeout)
+	mxRecords, err := resolver.Default().LookupMX(ctx, host)
+	cancel()
+	if err != nil {
+		return
+	}
+	for i, mx := range mxRecords {
+		if i == maxSPFMXHosts {
+			break
+		}
+		w.addHost(domain, term, strings.TrimSuffix(mx.Host, "."))
+	}
+}
+
+// add records an authorized address once
+func (w *spfWalker) add(domain, term string, addr netip.Addr) {
+	ip := addr.Unmap().String()
+	if w.seen[ip] {
+		return
+	}
+	w.seen[ip] = true
+	w.result.Addresses = append(w.result.Addresses, SPFAddress{IP: ip, Domain: domain, Mechanism: term})
+}
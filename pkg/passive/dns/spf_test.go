@@ -0,0 +1,145 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
+)
+
+// useZone makes zone the default resolver for the rest of the test
+func useZone(t *testing.T, zone *resolvertest.Server) {
+	t.Helper()
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	original := resolver.Default()
+	t.Cleanup(func() { resolver.SetDefault(original) })
+	resolver.SetDefault(r)
+}
+
+func TestLookupSPF(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+
+	zone.AddTXT("example.com", "google-site-verification=abc", "v=spf1 ip4:192.0.2.10 a mx:mail.example.com include:_spf.example.com -ip4:192.0.2.99 ~all")
+	zone.AddA("example.com", "192.0.2.1")
+	zone.AddMX("mail.example.com", "mx1.example.com")
+	zone.AddA("mx1.example.com", "192.0.2.2")
+	zone.AddTXT("_spf.example.com", "v=spf1 ip4:198.51.100.0/30 ip4:203.0.113.0/16 ip6:2001:db8::1 include:example.com redirect=_spf2.example.com")
+	zone.AddTXT("_spf2.example.com", "v=spf1 a:relay.example.com/24 exists:%{i}.spf.example.com")
+	zone.AddA("relay.example.com", "192.0.2.3")
+
+	result, err := LookupSPF(context.Background(), "example.com", time.Second)
+	if err != nil {
+		t.Fatalf("LookupSPF() error: %v", err)
+	}
+
+	var got []string
+	for _, addr := range result.Addresses {
+		got = append(got, addr.IP+" "+addr.Domain+" "+addr.Mechanism)
+	}
+	want := []string{
+		"192.0.2.10 example.com ip4:192.0.2.10",
+		"192.0.2.1 example.com a",
+		"192.0.2.2 example.com mx:mail.example.com",
+		"198.51.100.0 _spf.example.com ip4:198.51.100.0/30",
+		"198.51.100.1 _spf.example.com ip4:198.51.100.0/30",
+		"198.51.100.2 _spf.example.com ip4:198.51.100.0/30",
+		"198.51.100.3 _spf.example.com ip4:198.51.100.0/30",
+		"2001:db8::1 _spf.example.com ip6:2001:db8::1",
+		"192.0.2.3 _spf2.example.com a:relay.example.com/24",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Addresses =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(result.Networks) != 1 || result.Networks[0].IP != "203.0.0.0/16" {
+		t.Errorf("Networks = %+v, want the /16 left unexpanded", result.Networks)
+	}
+	// The include back to example.com is a loop and is not followed
+	if !reflect.DeepEqual(result.Includes, []string{"_spf.example.com", "_spf2.example.com"}) {
+		t.Errorf("Includes = %v", result.Includes)
+	}
+	// a, mx, both includes, redirect and a:relay; the exists macro is skipped
+	if result.Lookups != 6 || result.LimitReached {
+		t.Errorf("Lookups = %d (limit reached %v), want 6", result.Lookups, result.LimitReached)
+	}
+	if !strings.HasPrefix(result.Record, "v=spf1 ip4:192.0.2.10") {
+		t.Errorf("Record = %q", result.Record)
+	}
+}
+
+func TestLookupSPF_LookupLimit(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+
+	// A chain of includes longer than the RFC 7208 limit
+	for i := 0; i < 15; i++ {
+		zone.AddTXT(fmt.Sprintf("spf%d.example.com", i), fmt.Sprintf("v=spf1 ip4:192.0.2.%d include:spf%d.example.com", i, i+1))
+	}
+
+	result, err := LookupSPF(context.Background(), "spf0.example.com", time.Second)
+	if err != nil {
+		t.Fatalf("LookupSPF() error: %v", err)
+	}
+	if !result.LimitReached || result.Lookups != maxSPFLookups || len(result.Addresses) != maxSPFLookups+1 {
+		t.Errorf("Lookups = %d, addresses = %d, limit reached = %v; want evaluation to stop after %d lookups",
+			result.Lookups, len(result.Addresses), result.LimitReached, maxSPFLookups)
+	}
+}
+
+func TestLookupSPF_NoRecord(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+	zone.AddTXT("example.com", "google-site-verification=abc")
+
+	if _, err := LookupSPF(context.Background(), "example.com", time.Second); err == nil {
+		t.Error("LookupSPF() without a v=spf1 record should fail")
+	}
+}
+
+func TestLookupDMARC(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+	zone.AddTXT("_dmarc.example.com", "v=DMARC1; p=Reject; rua=mailto:dmarc@reports.example.com!10m, mailto:agg@dmarc.vendor.net; ruf=mailto:forensic@reports.example.com")
+
+	dmarc, err := LookupDMARC(context.Background(), "example.com", time.Second)
+	if err != nil {
+		t.Fatalf("LookupDMARC() error: %v", err)
+	}
+	if dmarc.Policy != "reject" || !reflect.DeepEqual(dmarc.Reports, []string{"reports.example.com", "dmarc.vendor.net"}) {
+		t.Errorf("LookupDMARC() = %+v", dmarc)
+	}
+
+	if _, err := LookupDMARC(context.Background(), "example.net", time.Second); err == nil {
+		t.Error("LookupDMARC() without a record should fail")
+	}
+}
+
+func TestLookupDKIM(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	useZone(t, zone)
+	zone.AddTXT("selector1._domainkey.example.com", "v=DKIM1; k=rsa; p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQ")
+	zone.AddTXT("google._domainkey.example.com", "k=rsa; p=MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQ")
+	zone.AddTXT("mail._domainkey.example.com", "not a key")
+
+	var got []string
+	for _, key := range LookupDKIM(context.Background(), "example.com", DKIMSelectors, time.Second) {
+		got = append(got, key.Host)
+	}
+	want := []string{"selector1._domainkey.example.com", "google._domainkey.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LookupDKIM() = %v, want %v", got, want)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 // DNSDumpsterResponse represents the API response structure
@@ -45,9 +47,9 @@ type IPDetail struct {
 var apiBaseURL = "https://api.dnsdumpster.com/domain/"
 
 // SearchDomain queries DNSDumpster API for domain information
-func SearchDomain(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+func SearchDomain(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no DNSDumpster API keys provided")
+		return nil, fmt.Errorf("no DNSDumpster API keys provided")
 	}
 
 	// Try each API key until one works
@@ -58,7 +60,7 @@ func SearchDomain(ctx context.Context, domain string, apiKeys []string, timeout
 			continue
 		}
 
-		ips, err := searchWithKey(ctx, domain, apiKey, timeout)
+		ips, err := SearchWithKey(ctx, domain, apiKey, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -70,18 +72,18 @@ func SearchDomain(ctx context.Context, domain string, apiKeys []string, timeout
 		}
 
 		// For other errors, return immediately
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
 }
 
-// searchWithKey performs the search with a single API key
-func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
+// SearchWithKey performs the search with a single API key
+func SearchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
 	url := fmt.Sprintf("%s%s", apiBaseURL, domain)
 
 	client := &http.Client{
@@ -135,46 +137,56 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		return nil, fmt.Errorf("DNSDumpster API error: %s", dnsResp.Error)
 	}
 
-	// Extract unique IPv4 addresses from all record types
-	ipSet := make(map[string]bool)
-
-	// Process A records
-	for _, record := range dnsResp.A {
-		for _, ipDetail := range record.IPs {
-			ip := strings.TrimSpace(ipDetail.IP)
-			if isValidIPv4(ip) {
-				ipSet[ip] = true
-			}
-		}
+	// Extract unique IPv4 addresses from A, MX and NS records (first record wins)
+	seen := make(map[string]bool)
+	records := make([]core.PassiveIP, 0)
+	now := time.Now()
+
+	recordSets := []struct {
+		via     string
+		records []DNSRecord
+	}{
+		{"DNSDumpster A record", dnsResp.A},
+		{"DNSDumpster MX record", dnsResp.MX},
+		{"DNSDumpster NS record", dnsResp.NS},
 	}
-
-	// Process MX records
-	for _, record := range dnsResp.MX {
-		for _, ipDetail := range record.IPs {
-			ip := strings.TrimSpace(ipDetail.IP)
-			if isValidIPv4(ip) {
-				ipSet[ip] = true
+	for _, set := range recordSets {
+		for _, record := range set.records {
+			for _, ipDetail := range record.IPs {
+				ip := strings.TrimSpace(ipDetail.IP)
+				if !isValidIPv4(ip) || seen[ip] {
+					continue
+				}
+				seen[ip] = true
+				records = append(records, core.PassiveIP{
+					IP:        ip,
+					Source:    "dnsdumpster",
+					Hostname:  record.Host,
+					Via:       set.via,
+					FirstSeen: now,
+					LastSeen:  now,
+					Metadata:  ipMetadata(ipDetail),
+				})
 			}
 		}
 	}
 
-	// Process NS records
-	for _, record := range dnsResp.NS {
-		for _, ipDetail := range record.IPs {
-			ip := strings.TrimSpace(ipDetail.IP)
-			if isValidIPv4(ip) {
-				ipSet[ip] = true
-			}
-		}
-	}
+	return records, nil
+}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+// ipMetadata converts the ASN, country and PTR details of an IP into record metadata
+func ipMetadata(detail IPDetail) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if asn := strings.TrimSpace(detail.ASN + " " + detail.ASNName); asn != "" {
+		metadata["asn"] = asn
 	}
-
-	return ips, nil
+	if detail.CountryCode != "" {
+		metadata["country_code"] = detail.CountryCode
+	}
+	if detail.PTR != "" {
+		metadata["ptr_record"] = detail.PTR
+	}
+	return metadata
 }
 
 // isValidIPv4 validates an IPv4 address
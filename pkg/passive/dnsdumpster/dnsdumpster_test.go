@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 func TestSearchDomain_NoAPIKeys(t *testing.T) {
@@ -145,7 +147,7 @@ func TestSearchWithKey_StatusCodes(t *testing.T) {
 			defer func() { apiBaseURL = oldURL }()
 
 			ctx := context.Background()
-			_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+			_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 			if err == nil {
 				t.Errorf("Expected error for status %d", tt.statusCode)
@@ -169,7 +171,7 @@ func TestSearchWithKey_InvalidJSON(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected JSON parsing error")
@@ -194,7 +196,7 @@ func TestSearchWithKey_APIErrorIn200Response(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected API error")
@@ -221,7 +223,7 @@ func TestSearchWithKey_AllRecordTypes(t *testing.T) {
 				{
 					Host: "mail.example.com",
 					IPs: []IPDetail{
-						{IP: "192.168.2.1"},
+						{IP: "192.168.2.1", ASN: "AS64500", ASNName: "EXAMPLE", PTR: "mail.example.com"},
 						{IP: "192.168.1.1"}, // Duplicate (should dedupe)
 					},
 				},
@@ -250,7 +252,7 @@ func TestSearchWithKey_AllRecordTypes(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -262,17 +264,29 @@ func TestSearchWithKey_AllRecordTypes(t *testing.T) {
 	}
 
 	// Check specific IPs are present
-	ipMap := make(map[string]bool)
+	ipMap := make(map[string]core.PassiveIP)
 	for _, ip := range ips {
-		ipMap[ip] = true
+		ipMap[ip.IP] = ip
 	}
 
 	expectedIPs := []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.2.1", "192.168.3.1"}
 	for _, expectedIP := range expectedIPs {
-		if !ipMap[expectedIP] {
+		if _, ok := ipMap[expectedIP]; !ok {
 			t.Errorf("Expected IP %s not found in results", expectedIP)
 		}
 	}
+
+	// Provenance: the duplicate keeps its A record origin; MX details become metadata
+	if r := ipMap["192.168.1.1"]; r.Hostname != "www.example.com" || r.Via != "DNSDumpster A record" {
+		t.Errorf("192.168.1.1 provenance = %q, want DNSDumpster A record www.example.com", r.Provenance())
+	}
+	mx := ipMap["192.168.2.1"]
+	if mx.Hostname != "mail.example.com" || mx.Via != "DNSDumpster MX record" {
+		t.Errorf("192.168.2.1 provenance = %q", mx.Provenance())
+	}
+	if mx.Metadata["asn"] != "AS64500 EXAMPLE" || mx.Metadata["ptr_record"] != "mail.example.com" {
+		t.Errorf("unexpected metadata: %v", mx.Metadata)
+	}
 }
 
 func TestSearchWithKey_ContextCancellation(t *testing.T) {
@@ -292,7 +306,7 @@ func TestSearchWithKey_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err == nil {
 		t.Error("Expected context cancellation error")
@@ -317,7 +331,7 @@ func TestSearchWithKey_Timeout(t *testing.T) {
 	ctx := context.Background()
 
 	// Very short timeout to force failure
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
 
 	if err == nil {
 		t.Error("Expected timeout error")
@@ -343,7 +357,7 @@ func TestSearchWithKey_LongBodyTruncation(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected error for bad request")
@@ -374,7 +388,7 @@ func TestSearchWithKey_EmptyResponse(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -486,7 +500,7 @@ func TestSearchWithKey_MultipleRecordTypes(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Multiple record types test completed")
 	}
@@ -506,7 +520,7 @@ func TestSearchWithKey_DuplicateIPs(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Duplicate IP deduplication test completed")
 	}
@@ -543,7 +557,7 @@ func TestSearchWithKey_LongErrorBody(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Expected error for bad request")
 	}
@@ -582,7 +596,7 @@ func TestSearchWithKey_EmptyIPLists(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Empty IP lists test completed")
 	}
@@ -608,7 +622,7 @@ func TestSearchWithKey_IPv6InResults(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("IPv6 AAAA records test completed")
 	}
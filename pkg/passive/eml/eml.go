@@ -0,0 +1,117 @@
+// Package eml extracts the addresses of the servers a mail message passed through
+// from its headers, to find the sending organization's mail servers
+package eml
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"net/netip"
+	"net/textproto"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Hop is a public IP address a message was relayed from
+type Hop struct {
+	IP     string
+	Host   string // Name the server announced or resolved to, if any
+	Header string // Header it was read from, e.g. "Received"
+}
+
+// originatingHeaders carry the client address webmail and submission servers saw
+var originatingHeaders = []string{"X-Originating-IP", "X-Sender-IP", "X-Client-IP"}
+
+// addressPattern matches candidate IPv4 and IPv6 literals: [192.0.2.1], (192.0.2.1),
+// [IPv6:2001:db8::1] or bare 192.0.2.1
+var addressPattern = regexp.MustCompile(`(?i)(?:ipv6:)?[0-9a-f]*[:.][0-9a-f:.]+`)
+
+// ParseFile reads the headers of an .eml file; see Parse
+func ParseFile(path string) ([]Hop, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Parse returns the public addresses in the from clause of each Received header,
+// earliest hop first, followed by those of X-Originating-IP style headers.
+// Private, loopback and link-local addresses are skipped; each IP is reported once.
+func Parse(r io.Reader) ([]Hop, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mail headers: %w", err)
+	}
+
+	var hops []Hop
+	seen := make(map[string]bool)
+	add := func(ip, host, header string) {
+		if !seen[ip] {
+			seen[ip] = true
+			hops = append(hops, Hop{IP: ip, Host: host, Header: header})
+		}
+	}
+
+	// Each relay prepends its Received header, so the last one is the first hop
+	received := msg.Header["Received"]
+	for i := len(received) - 1; i >= 0; i-- {
+		host, clause := fromClause(received[i])
+		for _, ip := range publicAddresses(clause) {
+			add(ip, host, "Received")
+		}
+	}
+	for _, name := range originatingHeaders {
+		for _, value := range msg.Header[textproto.CanonicalMIMEHeaderKey(name)] {
+			for _, ip := range publicAddresses(value) {
+				add(ip, "", name)
+			}
+		}
+	}
+	return hops, nil
+}
+
+// fromClause returns the host a Received header says the message came from and
+// the text of its from clause, up to the by/with/id/for clauses
+func fromClause(header string) (string, string) {
+	fields := strings.Fields(header)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "from") {
+		return "", ""
+	}
+	var clause []string
+	for _, field := range fields[1:] {
+		switch strings.ToLower(field) {
+		case "by", "with", "id", "for", "via":
+			return strings.ToLower(fields[1]), strings.Join(clause, " ")
+		}
+		clause = append(clause, field)
+	}
+	return strings.ToLower(fields[1]), strings.Join(clause, " ")
+}
+
+// publicAddresses returns the globally routable IP addresses in text
+func publicAddresses(text string) []string {
+	var ips []string
+	for _, match := range addressPattern.FindAllString(text, -1) {
+		if len(match) > 5 && strings.EqualFold(match[:5], "ipv6:") {
+			match = match[5:]
+		}
+		match = strings.TrimRight(match, ".")
+		addr, err := netip.ParseAddr(match)
+		if err != nil {
+			addrPort, err := netip.ParseAddrPort(match) // 192.0.2.1:25
+			if err != nil {
+				continue
+			}
+			addr = addrPort.Addr()
+		}
+		addr = addr.Unmap()
+		if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+			continue
+		}
+		ips = append(ips, addr.String())
+	}
+	return ips
+}
@@ -0,0 +1,77 @@
+package eml
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testMessage was relayed by an internal host, the sender's outbound server and
+// the recipient's provider
+const testMessage = "Received: from mail.example.com (mail.example.com [192.0.2.25])\r\n" +
+	"\tby mx.recipient.net (Postfix) with ESMTPS id 4F2A1\r\n" +
+	"\tfor <user@recipient.net>; Tue, 1 Jul 2025 10:15:32 +0000 (UTC)\r\n" +
+	"Received: from app01.internal (unknown [10.0.0.12])\r\n" +
+	"\tby mail.example.com (Postfix) with ESMTP id 1B3C2; Tue, 1 Jul 2025 10:15:31 +0000\r\n" +
+	"Received: from web.example.com ([IPv6:2001:db8:10::5]) by app01.internal with SMTP; Tue, 1 Jul 2025 10:15:30 +0000\r\n" +
+	"Received: by 2002:a05:6a10:1234 with SMTP id abc; Tue, 1 Jul 2025 10:15:29 +0000\r\n" +
+	"X-Originating-IP: [198.51.100.7]\r\n" +
+	"X-Sender-IP: 127.0.0.1\r\n" +
+	"From: Shop <noreply@example.com>\r\n" +
+	"Subject: Your order\r\n" +
+	"\r\n" +
+	"Thanks for your order from 203.0.113.9.\r\n"
+
+func TestParse(t *testing.T) {
+	hops, err := Parse(strings.NewReader(testMessage))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []Hop{
+		{IP: "2001:db8:10::5", Host: "web.example.com", Header: "Received"},
+		{IP: "192.0.2.25", Host: "mail.example.com", Header: "Received"},
+		{IP: "198.51.100.7", Header: "X-Originating-IP"},
+	}
+	if !reflect.DeepEqual(hops, want) {
+		t.Errorf("Parse() = %+v, want %+v", hops, want)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.eml")
+	if err := os.WriteFile(path, []byte(testMessage), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hops, err := ParseFile(path)
+	if err != nil || len(hops) != 3 {
+		t.Errorf("ParseFile() = %+v, %v; want 3 hops", hops, err)
+	}
+
+	if _, err := ParseFile(filepath.Join(t.TempDir(), "missing.eml")); err == nil {
+		t.Error("ParseFile() of a missing file should fail")
+	}
+	if _, err := Parse(strings.NewReader("not a mail message")); err == nil {
+		t.Error("Parse() of a message without headers should fail")
+	}
+}
+
+func TestPublicAddresses(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"mail.example.com [192.0.2.25]", []string{"192.0.2.25"}},
+		{"(HELO relay) (192.0.2.1:2525)", []string{"192.0.2.1"}},
+		{"[IPv6:2001:db8::1]", []string{"2001:db8::1"}},
+		{"[::ffff:192.0.2.8]", []string{"192.0.2.8"}},
+		{"unknown [10.1.2.3] [172.16.0.1] [192.168.1.1] [127.0.0.1] [fe80::1]", nil},
+		{"example.com", nil},
+	}
+	for _, tt := range tests {
+		if got := publicAddresses(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("publicAddresses(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Package history merges the dated A-record history reported by passive sources
+// into one timeline per IP
+package history
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/waf"
+)
+
+// MetadataKey is the PassiveIP metadata entry holding the []Period a source observed
+const MetadataKey = "a_record_history"
+
+// mergeGap joins periods of one IP that are at most a day apart, since most
+// sources report history with day granularity
+const mergeGap = 24 * time.Hour
+
+// cdnOrganizations are substrings of the organizations that run CDNs and WAFs
+var cdnOrganizations = []string{
+	"cloudflare",
+	"akamai",
+	"fastly",
+	"incapsula",
+	"imperva",
+	"sucuri",
+	"stackpath",
+	"cdn77",
+	"edgecast",
+	"limelight",
+	"ddos-guard",
+}
+
+// PriorityVia is the provenance given to the IPs that served the domain last before
+// it moved onto a CDN
+const PriorityVia = "last A record before CDN"
+
+// Period is a span during which an IP was a public A record of the domain
+// A single sighting has From equal to To.
+type Period struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Sources []string  `json:"sources,omitempty"`
+}
+
+// Timeline is the merged A-record history of one IP
+type Timeline struct {
+	IP           string
+	Hostname     string
+	Organization string   // Owner reported by the sources, if any
+	Periods      []Period // Non-overlapping, oldest first
+}
+
+// FirstSeen returns when the IP was first an A record
+func (t Timeline) FirstSeen() time.Time {
+	if len(t.Periods) == 0 {
+		return time.Time{}
+	}
+	return t.Periods[0].From
+}
+
+// LastSeen returns when the IP was last an A record
+func (t Timeline) LastSeen() time.Time {
+	if len(t.Periods) == 0 {
+		return time.Time{}
+	}
+	return t.Periods[len(t.Periods)-1].To
+}
+
+// LastSeenBefore returns the last time the IP was an A record before when,
+// or the zero time if it was not one until then
+func (t Timeline) LastSeenBefore(when time.Time) time.Time {
+	for i := len(t.Periods) - 1; i >= 0; i-- {
+		period := t.Periods[i]
+		if !period.From.Before(when) {
+			continue
+		}
+		if period.To.After(when) {
+			return when
+		}
+		return period.To
+	}
+	return time.Time{}
+}
+
+// Sources returns the sources that reported the IP, sorted
+func (t Timeline) Sources() []string {
+	var sources []string
+	for _, period := range t.Periods {
+		sources = mergeSources(sources, period.Sources)
+	}
+	return sources
+}
+
+// History collects dated A-record sightings from passive records
+type History struct {
+	byIP  map[string]*Timeline
+	order []string
+}
+
+// New returns an empty history
+func New() *History {
+	return &History{byIP: make(map[string]*Timeline)}
+}
+
+// Add merges the A-record periods in a record's metadata into the IP's timeline.
+// It reports whether the record held any history.
+func (h *History) Add(record core.PassiveIP) bool {
+	periods, _ := record.Metadata[MetadataKey].([]Period)
+	if len(periods) == 0 {
+		return false
+	}
+
+	timeline, ok := h.byIP[record.IP]
+	if !ok {
+		timeline = &Timeline{IP: record.IP, Hostname: record.Hostname}
+		h.byIP[record.IP] = timeline
+		h.order = append(h.order, record.IP)
+	}
+	if timeline.Organization == "" {
+		for _, key := range []string{"organization", "asn"} {
+			if org, ok := record.Metadata[key].(string); ok && org != "" {
+				timeline.Organization = org
+				break
+			}
+		}
+	}
+
+	for _, period := range periods {
+		if period.From.IsZero() || period.To.Before(period.From) {
+			continue
+		}
+		if len(period.Sources) == 0 {
+			period.Sources = []string{record.Source}
+		}
+		timeline.Periods = append(timeline.Periods, period)
+	}
+	timeline.Periods = merge(timeline.Periods)
+	return true
+}
+
+// Len returns the number of IPs with history
+func (h *History) Len() int {
+	return len(h.order)
+}
+
+// Timelines returns the timelines, most recently seen first
+func (h *History) Timelines() []Timeline {
+	timelines := make([]Timeline, 0, len(h.order))
+	for _, ip := range h.order {
+		timelines = append(timelines, *h.byIP[ip])
+	}
+	sort.SliceStable(timelines, func(i, j int) bool {
+		return timelines[i].LastSeen().After(timelines[j].LastSeen())
+	})
+	return timelines
+}
+
+// Cutover returns when the domain moved onto a CDN: the first CDN sighting after
+// the last time a non-CDN IP started serving it. It returns the zero time if the
+// history does not show such a move.
+func (h *History) Cutover(isCDN func(Timeline) bool) time.Time {
+	var lastOrigin time.Time
+	seenOrigin := false
+	for _, ip := range h.order {
+		timeline := h.byIP[ip]
+		if isCDN(*timeline) {
+			continue
+		}
+		seenOrigin = true
+		for _, period := range timeline.Periods {
+			if period.From.After(lastOrigin) {
+				lastOrigin = period.From
+			}
+		}
+	}
+	if !seenOrigin {
+		return time.Time{}
+	}
+
+	var cutover time.Time
+	for _, ip := range h.order {
+		timeline := h.byIP[ip]
+		if !isCDN(*timeline) {
+			continue
+		}
+		for _, period := range timeline.Periods {
+			if period.From.After(lastOrigin) && (cutover.IsZero() || period.From.Before(cutover)) {
+				cutover = period.From
+			}
+		}
+	}
+	return cutover
+}
+
+// BeforeCutover returns the non-CDN timelines seen before cutover, the IP that
+// served the domain last before it first
+func (h *History) BeforeCutover(cutover time.Time, isCDN func(Timeline) bool) []Timeline {
+	var timelines []Timeline
+	for _, timeline := range h.Timelines() {
+		if !isCDN(timeline) && !timeline.LastSeenBefore(cutover).IsZero() {
+			timelines = append(timelines, timeline)
+		}
+	}
+	sort.SliceStable(timelines, func(i, j int) bool {
+		return timelines[i].LastSeenBefore(cutover).After(timelines[j].LastSeenBefore(cutover))
+	})
+	return timelines
+}
+
+// Migration is a move of the domain onto a CDN found in its A-record history
+type Migration struct {
+	Date    time.Time  // When the CDN first served the domain
+	CDN     Timeline   // The CDN IP seen on Date
+	Origins []Timeline // Non-CDN IPs that were the A record last before Date
+}
+
+// Migration returns the domain's latest move onto a CDN, or nil if the history
+// shows none. Origins holds every non-CDN IP last seen within a day of the last one.
+func (h *History) Migration(isCDN func(Timeline) bool) *Migration {
+	cutover := h.Cutover(isCDN)
+	if cutover.IsZero() {
+		return nil
+	}
+	m := &Migration{Date: cutover}
+	for _, timeline := range h.Timelines() {
+		if !isCDN(timeline) {
+			continue
+		}
+		for _, period := range timeline.Periods {
+			if period.From.Equal(cutover) {
+				m.CDN = timeline
+			}
+		}
+	}
+
+	before := h.BeforeCutover(cutover, isCDN)
+	for _, timeline := range before {
+		if before[0].LastSeenBefore(cutover).Sub(timeline.LastSeenBefore(cutover)) > mergeGap {
+			break
+		}
+		m.Origins = append(m.Origins, timeline)
+	}
+	return m
+}
+
+// Prioritize moves the migration's origins to the front of ranked passive results
+// with full confidence, since the domain's own DNS history is direct evidence.
+// Origins missing from ranked (e.g. filtered by confidence) are added.
+func (m *Migration) Prioritize(ranked []core.PassiveIP) []core.PassiveIP {
+	byIP := make(map[string]int, len(ranked))
+	for i, record := range ranked {
+		byIP[record.IP] = i
+	}
+
+	prioritized := make([]core.PassiveIP, 0, len(ranked)+len(m.Origins))
+	moved := make(map[string]bool)
+	for _, origin := range m.Origins {
+		var record core.PassiveIP
+		if i, ok := byIP[origin.IP]; ok {
+			record = ranked[i]
+			record.Metadata = cloneMetadata(record.Metadata)
+		} else {
+			record = core.PassiveIP{
+				IP:        origin.IP,
+				Source:    origin.Sources()[0],
+				FirstSeen: origin.FirstSeen(),
+				LastSeen:  origin.LastSeen(),
+				Sources:   origin.Sources(),
+				Metadata:  make(map[string]interface{}),
+			}
+		}
+		record.Hostname = origin.Hostname
+		record.Via = PriorityVia
+		record.Pivot = ""
+		record.Confidence = 1.0
+		record.Metadata["cdn_cutover"] = m.Date.Format("2006-01-02")
+		record.Metadata["last_seen_before_cdn"] = origin.LastSeenBefore(m.Date).Format("2006-01-02")
+		record.FoundVia = append(append([]string(nil), record.FoundVia...), PriorityVia)
+		record.Hostnames = mergeHostname(record.Hostnames, origin.Hostname)
+
+		prioritized = append(prioritized, record)
+		moved[origin.IP] = true
+	}
+	for _, record := range ranked {
+		if !moved[record.IP] {
+			prioritized = append(prioritized, record)
+		}
+	}
+	return prioritized
+}
+
+// InRanges returns a CDN test matching IPs in a WAF/CDN range set (which may be
+// nil) and, failing that, organizations that run a CDN
+func InRanges(ranges *waf.RangeSet) func(Timeline) bool {
+	return func(t Timeline) bool {
+		if ranges != nil {
+			if ip := net.ParseIP(t.IP); ip != nil && ranges.Contains(ip) {
+				return true
+			}
+		}
+		return IsCDNOrganization(t)
+	}
+}
+
+// IsCDNOrganization reports whether the timeline's organization runs a CDN or WAF
+func IsCDNOrganization(t Timeline) bool {
+	org := strings.ToLower(t.Organization)
+	for _, name := range cdnOrganizations {
+		if strings.Contains(org, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge sorts periods and joins those that overlap or are at most mergeGap apart
+func merge(periods []Period) []Period {
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].From.Before(periods[j].From)
+	})
+
+	merged := periods[:0]
+	for _, period := range periods {
+		if n := len(merged); n > 0 && period.From.Sub(merged[n-1].To) <= mergeGap {
+			last := &merged[n-1]
+			if period.To.After(last.To) {
+				last.To = period.To
+			}
+			last.Sources = mergeSources(last.Sources, period.Sources)
+			continue
+		}
+		period.Sources = mergeSources(nil, period.Sources)
+		merged = append(merged, period)
+	}
+	return merged
+}
+
+// mergeSources returns the sorted union of two source lists
+func mergeSources(a, b []string) []string {
+	union := append([]string(nil), a...)
+	for _, source := range b {
+		found := false
+		for _, existing := range union {
+			if existing == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			union = append(union, source)
+		}
+	}
+	sort.Strings(union)
+	return union
+}
+
+// mergeHostname returns a copy of hostnames with hostname appended if missing
+func mergeHostname(hostnames []string, hostname string) []string {
+	merged := append([]string(nil), hostnames...)
+	for _, existing := range merged {
+		if existing == hostname {
+			return merged
+		}
+	}
+	return append(merged, hostname)
+}
+
+// cloneMetadata returns a shallow copy of a metadata map (never nil)
+func cloneMetadata(metadata map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(metadata)+3)
+	for k, v := range metadata {
+		clone[k] = v
+	}
+	return clone
+}
@@ -0,0 +1,209 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/waf"
+)
+
+// date parses a YYYY-MM-DD test date
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// record returns a passive record holding A-record periods given as from/to date pairs
+func record(ip, source, org string, dates ...string) core.PassiveIP {
+	var periods []Period
+	for i := 0; i+1 < len(dates); i += 2 {
+		periods = append(periods, Period{From: date(dates[i]), To: date(dates[i+1])})
+	}
+	metadata := map[string]interface{}{MetadataKey: periods}
+	if org != "" {
+		metadata["organization"] = org
+	}
+	return core.PassiveIP{IP: ip, Source: source, Hostname: "example.com", Metadata: metadata}
+}
+
+func TestAdd(t *testing.T) {
+	h := New()
+	if h.Add(core.PassiveIP{IP: "192.0.2.9", Source: "shodan"}) {
+		t.Error("Add() of a record without history should report false")
+	}
+
+	h.Add(record("192.0.2.1", "securitytrails", "", "2020-01-01", "2020-06-30", "2021-01-01", "2021-03-01"))
+	// Sightings inside, adjacent to and apart from the SecurityTrails periods
+	h.Add(record("192.0.2.1", "virustotal", "Example Hosting", "2020-03-15", "2020-03-15", "2020-07-01", "2020-07-01", "2022-05-05", "2022-05-05"))
+
+	timelines := h.Timelines()
+	if len(timelines) != 1 || h.Len() != 1 {
+		t.Fatalf("Timelines() = %d, want 1", len(timelines))
+	}
+	timeline := timelines[0]
+	want := []Period{
+		{From: date("2020-01-01"), To: date("2020-07-01"), Sources: []string{"securitytrails", "virustotal"}},
+		{From: date("2021-01-01"), To: date("2021-03-01"), Sources: []string{"securitytrails"}},
+		{From: date("2022-05-05"), To: date("2022-05-05"), Sources: []string{"virustotal"}},
+	}
+	if !reflect.DeepEqual(timeline.Periods, want) {
+		t.Errorf("Periods = %+v, want %+v", timeline.Periods, want)
+	}
+	if !timeline.FirstSeen().Equal(date("2020-01-01")) || !timeline.LastSeen().Equal(date("2022-05-05")) {
+		t.Errorf("seen range = %v..%v", timeline.FirstSeen(), timeline.LastSeen())
+	}
+	if got := timeline.Sources(); !reflect.DeepEqual(got, []string{"securitytrails", "virustotal"}) {
+		t.Errorf("Sources() = %v", got)
+	}
+	if timeline.Organization != "Example Hosting" {
+		t.Errorf("Organization = %q, want the first one reported", timeline.Organization)
+	}
+}
+
+func TestLastSeenBefore(t *testing.T) {
+	timeline := Timeline{Periods: []Period{
+		{From: date("2019-01-01"), To: date("2019-12-31")},
+		{From: date("2021-01-01"), To: date("2021-06-30")},
+	}}
+
+	tests := []struct {
+		when string
+		want string
+	}{
+		{"2018-06-01", ""},           // Not yet an A record
+		{"2020-06-01", "2019-12-31"}, // Between periods
+		{"2021-03-01", "2021-03-01"}, // Still the A record at the time
+		{"2023-01-01", "2021-06-30"},
+	}
+	for _, tt := range tests {
+		got := timeline.LastSeenBefore(date(tt.when))
+		if tt.want == "" {
+			if !got.IsZero() {
+				t.Errorf("LastSeenBefore(%s) = %v, want zero", tt.when, got)
+			}
+			continue
+		}
+		if !got.Equal(date(tt.want)) {
+			t.Errorf("LastSeenBefore(%s) = %v, want %s", tt.when, got, tt.want)
+		}
+	}
+}
+
+func TestCutover(t *testing.T) {
+	h := New()
+	h.Add(record("104.16.1.1", "securitytrails", "Cloudflare, Inc.", "2016-01-01", "2016-12-31", "2021-03-01", "2026-10-01"))
+	h.Add(record("198.51.100.7", "securitytrails", "Old Hosting", "2017-01-01", "2019-06-30"))
+	h.Add(record("203.0.113.5", "securitytrails", "Example Hosting", "2019-07-01", "2021-03-04"))
+
+	cutover := h.Cutover(IsCDNOrganization)
+	if !cutover.Equal(date("2021-03-01")) {
+		t.Fatalf("Cutover() = %v, want the latest move onto the CDN (2021-03-01)", cutover)
+	}
+
+	origins := h.BeforeCutover(cutover, IsCDNOrganization)
+	if len(origins) != 2 || origins[0].IP != "203.0.113.5" || origins[1].IP != "198.51.100.7" {
+		t.Fatalf("BeforeCutover() = %+v, want 203.0.113.5 then 198.51.100.7", origins)
+	}
+	if got := origins[0].LastSeenBefore(cutover); !got.Equal(cutover) {
+		t.Errorf("LastSeenBefore(cutover) = %v, want %v", got, cutover)
+	}
+
+	// Never behind a CDN, or never anywhere else
+	direct := New()
+	direct.Add(record("203.0.113.5", "virustotal", "", "2020-01-01", "2020-01-01"))
+	if got := direct.Cutover(IsCDNOrganization); !got.IsZero() {
+		t.Errorf("Cutover() without a CDN = %v, want zero", got)
+	}
+	cdnOnly := New()
+	cdnOnly.Add(record("104.16.1.1", "securitytrails", "Cloudflare, Inc.", "2020-01-01", "2026-01-01"))
+	if got := cdnOnly.Cutover(IsCDNOrganization); !got.IsZero() {
+		t.Errorf("Cutover() without an origin = %v, want zero", got)
+	}
+}
+
+func TestMigration(t *testing.T) {
+	ranges := waf.NewRangeSet()
+	if err := ranges.AddProvider(&waf.Provider{ID: "cloudflare", Ranges: []string{"104.16.0.0/13"}}); err != nil {
+		t.Fatalf("AddProvider() error: %v", err)
+	}
+	isCDN := InRanges(ranges)
+
+	h := New()
+	// VirusTotal sightings carry no organization; the ranges classify them
+	h.Add(record("104.16.1.1", "virustotal", "", "2021-03-02", "2021-03-02", "2024-01-01", "2024-01-01"))
+	h.Add(record("203.0.113.5", "securitytrails", "Example Hosting", "2019-07-01", "2021-03-01"))
+	h.Add(record("203.0.113.6", "virustotal", "", "2021-02-28", "2021-02-28"))
+	h.Add(record("198.51.100.7", "securitytrails", "Old Hosting", "2017-01-01", "2019-06-30"))
+
+	if h.Migration(IsCDNOrganization) != nil {
+		t.Error("Migration() without range data should find no CDN")
+	}
+	m := h.Migration(isCDN)
+	if m == nil {
+		t.Fatal("Migration() = nil, want a move onto the CDN")
+	}
+	if !m.Date.Equal(date("2021-03-02")) || m.CDN.IP != "104.16.1.1" {
+		t.Errorf("Migration() = %s via %s, want 2021-03-02 via 104.16.1.1", m.Date, m.CDN.IP)
+	}
+	var origins []string
+	for _, origin := range m.Origins {
+		origins = append(origins, origin.IP)
+	}
+	// 198.51.100.7 stopped serving the domain long before the move
+	if want := []string{"203.0.113.5", "203.0.113.6"}; !reflect.DeepEqual(origins, want) {
+		t.Errorf("Origins = %v, want %v", origins, want)
+	}
+
+	ranked := []core.PassiveIP{
+		{IP: "192.0.2.50", Source: "shodan", Confidence: 0.9},
+		{IP: "203.0.113.5", Source: "securitytrails", Hostname: "www.example.com", Via: "historical A record", Confidence: 0.4,
+			FoundVia: []string{"historical A record www.example.com"}},
+	}
+	prioritized := m.Prioritize(ranked)
+	if len(prioritized) != 3 || prioritized[0].IP != "203.0.113.5" || prioritized[1].IP != "203.0.113.6" || prioritized[2].IP != "192.0.2.50" {
+		t.Fatalf("Prioritize() order = %+v", prioritized)
+	}
+	first := prioritized[0]
+	if first.Confidence != 1.0 || first.Via != PriorityVia || first.Hostname != "example.com" {
+		t.Errorf("prioritized origin = %+v", first)
+	}
+	if first.Metadata["cdn_cutover"] != "2021-03-02" || first.Metadata["last_seen_before_cdn"] != "2021-03-01" {
+		t.Errorf("metadata = %v", first.Metadata)
+	}
+	if len(first.FoundVia) != 2 || first.FoundVia[1] != PriorityVia {
+		t.Errorf("found_via = %v", first.FoundVia)
+	}
+	if !reflect.DeepEqual(first.Hostnames, []string{"example.com"}) {
+		t.Errorf("hostnames = %v", first.Hostnames)
+	}
+	if _, ok := ranked[1].Metadata["cdn_cutover"]; ok {
+		t.Error("Prioritize() should not modify the ranked records")
+	}
+	// An origin that was not ranked is added from its timeline
+	if added := prioritized[1]; added.Source != "virustotal" || !added.LastSeen.Equal(date("2021-02-28")) {
+		t.Errorf("added origin = %+v", added)
+	}
+}
+
+func TestIsCDNOrganization(t *testing.T) {
+	tests := []struct {
+		org  string
+		want bool
+	}{
+		{"Cloudflare, Inc.", true},
+		{"AKAMAI-AS", true},
+		{"Fastly", true},
+		{"DigitalOcean, LLC", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsCDNOrganization(Timeline{Organization: tt.org}); got != tt.want {
+			t.Errorf("IsCDNOrganization(%q) = %v, want %v", tt.org, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package passive
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/subdomain"
+)
+
+// maxExpandRounds bounds how many times resolved permutations are permuted again
+const maxExpandRounds = 3
+
+// maxExpandNames bounds the names resolved by one expansion, source hostnames and
+// permutations together (can be overridden in tests)
+var maxExpandNames = 2000
+
+// ExpandHostnames feeds the hostnames under domain that any source reported back
+// into DNS. Every name is resolved again, since a source may have resolved only
+// some of its names or kept only their historical addresses, and with
+// config.Permutations the names that resolve are permuted; the permutations that
+// resolve are permuted in turn until a round finds no new name (or after
+// maxExpandRounds). At most maxExpandNames names are resolved and expansion stops
+// when ctx is done, returning what was found so far. Names answered only by a
+// wildcard record are ignored. Only hostname/IP pairs missing from records are
+// returned, as "dns" records.
+func ExpandHostnames(ctx context.Context, config *core.Config, domain string, records []core.PassiveIP) []core.PassiveIP {
+	known := make(map[string]bool)       // hostname|IP pairs already reported
+	foundBy := make(map[string][]string) // relative name -> sources that reported it
+	via := make(map[string]string)       // relative name -> provenance of its records
+	var queue []string
+	for _, record := range records {
+		known[strings.ToLower(record.Hostname)+"|"+record.IP] = true
+		name := relativeName(record.Hostname, domain)
+		if name == "" {
+			continue
+		}
+		if _, ok := foundBy[name]; !ok {
+			queue = append(queue, name)
+		}
+		if !containsString(foundBy[name], record.Source) {
+			foundBy[name] = append(foundBy[name], record.Source)
+		}
+	}
+	sort.Strings(queue)
+	for _, name := range queue {
+		via[name] = "hostname from " + strings.Join(foundBy[name], ", ")
+	}
+
+	scanner := subdomain.NewScanner(domain, 20, Timeout(config))
+	if len(queue) > 0 {
+		scanner.DetectWildcard(ctx)
+	}
+
+	var expanded []core.PassiveIP
+	budget := maxExpandNames
+	for round := 0; len(queue) > 0 && round < maxExpandRounds && budget > 0; round++ {
+		if len(queue) > budget {
+			queue = queue[:budget]
+		}
+		budget -= len(queue)
+		results, err := scanner.Scan(ctx, queue)
+		if err != nil {
+			break
+		}
+
+		var resolved []string
+		now := time.Now()
+		for _, name := range queue {
+			ips, ok := results[name]
+			if !ok {
+				continue
+			}
+			resolved = append(resolved, name)
+			hostname := name + "." + strings.ToLower(domain)
+			for _, ip := range ips {
+				if known[hostname+"|"+ip] {
+					continue
+				}
+				known[hostname+"|"+ip] = true
+				expanded = append(expanded, core.PassiveIP{
+					IP:        ip,
+					Source:    "dns",
+					Hostname:  hostname,
+					Via:       via[name],
+					FirstSeen: now,
+					LastSeen:  now,
+				})
+			}
+		}
+
+		if !config.Permutations || ctx.Err() != nil {
+			break
+		}
+		queue = nil
+		for _, parent := range resolved {
+			for _, name := range subdomain.Permutations([]string{parent}) {
+				if _, ok := via[name]; !ok {
+					via[name] = "permutation of " + parent + "." + strings.ToLower(domain)
+					queue = append(queue, name)
+				}
+			}
+		}
+	}
+	return expanded
+}
+
+// Inventory lists the domain and every hostname under it that records mention,
+// sorted by name, with the IPs and sources reported for it. cdn returns the
+// WAF/CDN provider whose ranges contain an IP, or ""; a hostname is marked with
+// the provider when all of its IPs are inside CDN ranges.
+func Inventory(domain string, records []core.PassiveIP, cdn func(ip string) string) []core.Hostname {
+	byName := make(map[string]*core.Hostname)
+	for _, record := range records {
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(record.Hostname), "."))
+		if name != strings.ToLower(domain) && relativeName(name, domain) == "" {
+			continue
+		}
+		host, ok := byName[name]
+		if !ok {
+			host = &core.Hostname{Name: name}
+			byName[name] = host
+		}
+		if !containsString(host.IPs, record.IP) {
+			host.IPs = append(host.IPs, record.IP)
+		}
+		if !containsString(host.Sources, record.Source) {
+			host.Sources = append(host.Sources, record.Source)
+		}
+	}
+
+	inventory := make([]core.Hostname, 0, len(byName))
+	for _, host := range byName {
+		sort.Strings(host.Sources)
+		if cdn != nil {
+			for i, ip := range host.IPs {
+				provider := cdn(ip)
+				if provider == "" {
+					host.CDN = ""
+					break
+				}
+				if i == 0 {
+					host.CDN = provider
+				}
+			}
+		}
+		inventory = append(inventory, *host)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Name < inventory[j].Name
+	})
+	return inventory
+}
+
+// relativeName returns hostname relative to domain ("api.eu" for api.eu.example.com),
+// or "" for the domain itself, wildcard names and names outside it
+func relativeName(hostname, domain string) string {
+	hostname = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(hostname), "."))
+	suffix := "." + strings.ToLower(domain)
+	if !strings.HasSuffix(hostname, suffix) || strings.Contains(hostname, "*") {
+		return ""
+	}
+	return strings.TrimSuffix(hostname, suffix)
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
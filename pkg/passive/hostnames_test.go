@@ -0,0 +1,155 @@
+package passive
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
+)
+
+func TestExpandHostnames(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("*.example.com", "192.0.2.100")
+	zone.AddA("www.example.com", "192.0.2.1")
+	zone.AddA("api.example.com", "192.0.2.2", "192.0.2.3")
+	zone.AddA("legacy.app.example.com", "192.0.2.7")
+	zone.AddA("dev-legacy.app.example.com", "192.0.2.8")
+	zone.AddA("dev-legacy-old.app.example.com", "192.0.2.9")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	defer resolver.SetDefault(resolver.Default())
+	resolver.SetDefault(r)
+
+	records := []core.PassiveIP{
+		{IP: "192.0.2.1", Source: "ct", Hostname: "www.example.com"},
+		{IP: "192.0.2.2", Source: "virustotal", Hostname: "API.example.com"},
+		{IP: "192.0.2.2", Source: "wayback", Hostname: "api.example.com"},
+		{IP: "198.51.100.1", Source: "securitytrails", Hostname: "legacy.app.example.com"}, // Historical
+		{IP: "198.51.100.2", Source: "securitytrails", Hostname: "example.com"},
+		{IP: "198.51.100.3", Source: "shodan", Hostname: "www.example.net"},
+	}
+
+	tests := []struct {
+		name         string
+		permutations bool
+		maxNames     int
+		cancelled    bool
+		want         map[string]string
+	}{
+		{
+			name: "resolve only",
+			want: map[string]string{
+				"192.0.2.3": "api.example.com hostname from virustotal, wayback",
+				"192.0.2.7": "legacy.app.example.com hostname from securitytrails",
+			},
+		},
+		{
+			name:         "permutations until no new name",
+			permutations: true,
+			want: map[string]string{
+				"192.0.2.3": "api.example.com hostname from virustotal, wayback",
+				"192.0.2.7": "legacy.app.example.com hostname from securitytrails",
+				"192.0.2.8": "dev-legacy.app.example.com permutation of legacy.app.example.com",
+				"192.0.2.9": "dev-legacy-old.app.example.com permutation of dev-legacy.app.example.com",
+			},
+		},
+		{
+			name:         "name limit stops permutations",
+			permutations: true,
+			maxNames:     3,
+			want: map[string]string{
+				"192.0.2.3": "api.example.com hostname from virustotal, wayback",
+				"192.0.2.7": "legacy.app.example.com hostname from securitytrails",
+			},
+		},
+		{
+			name:         "cancelled",
+			permutations: true,
+			cancelled:    true,
+			want:         map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := core.DefaultConfig()
+			config.Permutations = tt.permutations
+			if tt.maxNames > 0 {
+				original := maxExpandNames
+				defer func() { maxExpandNames = original }()
+				maxExpandNames = tt.maxNames
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			got := make(map[string]string)
+			for _, record := range ExpandHostnames(ctx, config, "example.com", records) {
+				if record.Source != "dns" {
+					t.Errorf("record %s source = %q, want dns", record.IP, record.Source)
+				}
+				got[record.IP] = record.Hostname + " " + record.Via
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandHostnames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	tests := map[string]string{
+		"api.example.com":        "api",
+		"Origin.EU.example.com.": "origin.eu",
+		"example.com":            "",
+		"*.example.com":          "",
+		"api.example.net":        "",
+		"badexample.com":         "",
+	}
+	for hostname, want := range tests {
+		if got := relativeName(hostname, "example.com"); got != want {
+			t.Errorf("relativeName(%q) = %q, want %q", hostname, got, want)
+		}
+	}
+}
+
+func TestInventory(t *testing.T) {
+	records := []core.PassiveIP{
+		{IP: "104.16.1.1", Source: "ct", Hostname: "www.example.com"},
+		{IP: "104.16.1.1", Source: "wayback", Hostname: "WWW.example.com."},
+		{IP: "192.0.2.7", Source: "securitytrails", Hostname: "legacy.app.example.com"},
+		{IP: "192.0.2.8", Source: "dns", Hostname: "legacy.app.example.com"},
+		{IP: "104.16.1.2", Source: "dns", Hostname: "api.example.com"},
+		{IP: "192.0.2.9", Source: "dns", Hostname: "api.example.com"},
+		{IP: "198.51.100.2", Source: "securitytrails", Hostname: "example.com"},
+		{IP: "198.51.100.3", Source: "shodan", Hostname: "www.example.net"},
+		{IP: "198.51.100.4", Source: "censys"},
+	}
+	cdn := func(ip string) string {
+		if strings.HasPrefix(ip, "104.16.") {
+			return "cloudflare"
+		}
+		return ""
+	}
+
+	want := []core.Hostname{
+		{Name: "api.example.com", IPs: []string{"104.16.1.2", "192.0.2.9"}, Sources: []string{"dns"}}, // Partly outside the CDN
+		{Name: "example.com", IPs: []string{"198.51.100.2"}, Sources: []string{"securitytrails"}},
+		{Name: "legacy.app.example.com", IPs: []string{"192.0.2.7", "192.0.2.8"}, Sources: []string{"dns", "securitytrails"}},
+		{Name: "www.example.com", IPs: []string{"104.16.1.1"}, Sources: []string{"ct", "wayback"}, CDN: "cloudflare"},
+	}
+	if got := Inventory("example.com", records, cdn); !reflect.DeepEqual(got, want) {
+		t.Errorf("Inventory() = %+v, want %+v", got, want)
+	}
+}
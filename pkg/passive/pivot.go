@@ -0,0 +1,128 @@
+package passive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/favicon"
+	"github.com/jhaxce/origindive/v3/pkg/page"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+)
+
+// fetchPivots collects the live site's pivots (replaced in tests)
+var fetchPivots = TargetPivots
+
+// targetPivots memoizes pivot lookups so the pivoting sources fetch each site once
+var targetPivots sync.Map // domain -> *pivotLookup
+
+// pivotLookup is the pivot list of a domain, kept once a fetch succeeds
+type pivotLookup struct {
+	mu     sync.Mutex
+	done   bool
+	pivots []core.Pivot
+}
+
+// cdnCertDomains are the domains of CDN edge certificates, whose subjects are
+// shared by unrelated sites and never presented by the origin
+var cdnCertDomains = []string{
+	"akamai.net", "akamaized.net", "azureedge.net", "b-cdn.net", "cloudflare.com", "cloudflaressl.com",
+	"cloudfront.net", "edgekey.net", "fastly.net", "incapdns.net", "incapsula.com", "sucuri.net",
+}
+
+// TargetPivots fetches https://domain/ the way a visitor reaches it and returns the
+// attributes hidden hosts may share with it: favicon hash, HTML title and TLS
+// certificate. The certificate subject is left out when it is the domain itself,
+// since the hostname searches already cover it, or a CDN edge certificate.
+func TargetPivots(ctx context.Context, domain string, timeout time.Duration) ([]core.Pivot, error) {
+	client := favicon.NewClient(timeout)
+	resp, body, err := favicon.Get(ctx, client, "https://"+domain+"/", favicon.ReadLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var pivots []core.Pivot
+	if hash, err := favicon.Fetch(ctx, client, resp.Request.URL, body); err == nil {
+		pivots = append(pivots, core.Pivot{Kind: core.PivotFavicon, Value: strconv.Itoa(int(hash))})
+	}
+	if resp.StatusCode == http.StatusOK {
+		if title := strings.Join(strings.Fields(html.UnescapeString(page.Title(body))), " "); title != "" {
+			pivots = append(pivots, core.Pivot{Kind: core.PivotTitle, Value: title})
+		}
+	}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		sum := sha256.Sum256(cert.Raw)
+		pivots = append(pivots, core.Pivot{Kind: core.PivotCertSHA256, Value: hex.EncodeToString(sum[:])})
+		if cn := cert.Subject.CommonName; cn != "" && !strings.EqualFold(cn, domain) && !isCDNCertSubject(cn) {
+			pivots = append(pivots, core.Pivot{Kind: core.PivotCertSubject, Value: cn})
+		}
+	}
+	return pivots, nil
+}
+
+// domainPivots returns the pivots of the domain's live site, fetching them once per
+// domain. A failed fetch (e.g. one whose caller was cancelled) is not kept, so the
+// next caller fetches again.
+func domainPivots(ctx context.Context, domain string, timeout time.Duration) ([]core.Pivot, error) {
+	value, _ := targetPivots.LoadOrStore(domain, &pivotLookup{})
+	lookup := value.(*pivotLookup)
+	lookup.mu.Lock()
+	defer lookup.mu.Unlock()
+	if !lookup.done {
+		pivots, err := fetchPivots(ctx, domain, timeout)
+		if err != nil {
+			return nil, err
+		}
+		lookup.pivots, lookup.done = pivots, true
+	}
+	return lookup.pivots, nil
+}
+
+// isCDNCertSubject reports whether a certificate common name belongs to a CDN edge
+// certificate, e.g. sni.cloudflaressl.com
+func isCDNCertSubject(cn string) bool {
+	cn = strings.ToLower(strings.TrimPrefix(cn, "*."))
+	for _, domain := range cdnCertDomains {
+		if cn == domain || strings.HasSuffix(cn, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// withPivots follows a keyed hostname search with a search for each pivot of the
+// live site. Pivots are skipped when the site cannot be fetched, and only a
+// rate-limited pivot fails the attempt so the key manager can rotate.
+func withPivots(search func(ctx context.Context, domain, key string, timeout time.Duration) ([]core.PassiveIP, error),
+	pivot func(ctx context.Context, pivot core.Pivot, key string, timeout time.Duration) ([]core.PassiveIP, error)) func(ctx context.Context, domain, key string, timeout time.Duration) ([]core.PassiveIP, error) {
+	return func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+		ips, err := search(ctx, domain, key, t)
+		if err != nil {
+			return nil, err
+		}
+
+		pivots, err := domainPivots(ctx, domain, t)
+		if err != nil {
+			return ips, nil
+		}
+		for _, p := range pivots {
+			found, err := pivot(ctx, p, key, t)
+			if err != nil {
+				if api.IsRateLimitError(err) {
+					return nil, err
+				}
+				continue
+			}
+			ips = append(ips, found...)
+		}
+		return ips, nil
+	}
+}
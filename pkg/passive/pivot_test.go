@@ -0,0 +1,115 @@
+package passive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+)
+
+func TestTargetPivots(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/favicon.ico" {
+			w.Write([]byte("icon-bytes"))
+			return
+		}
+		w.Write([]byte("<html><head><title>\n  Example &amp; Shop\n</title></head></html>"))
+	}))
+	defer server.Close()
+
+	pivots, err := TargetPivots(context.Background(), strings.TrimPrefix(server.URL, "https://"), 5*time.Second)
+	if err != nil {
+		t.Fatalf("TargetPivots() error: %v", err)
+	}
+	sum := sha256.Sum256(server.Certificate().Raw)
+	want := []core.Pivot{
+		{Kind: core.PivotFavicon, Value: "-2059846915"},
+		{Kind: core.PivotTitle, Value: "Example & Shop"},
+		{Kind: core.PivotCertSHA256, Value: hex.EncodeToString(sum[:])},
+	}
+	if !reflect.DeepEqual(pivots, want) {
+		t.Errorf("TargetPivots() = %v, want %v", pivots, want)
+	}
+
+	if _, err := TargetPivots(context.Background(), "127.0.0.1:1", time.Second); err == nil {
+		t.Error("TargetPivots() on a closed port should fail")
+	}
+}
+
+func TestWithPivots(t *testing.T) {
+	oldFetch := fetchPivots
+	defer func() { fetchPivots = oldFetch }()
+	fetches := make(map[string]int)
+	fetchPivots = func(ctx context.Context, domain string, timeout time.Duration) ([]core.Pivot, error) {
+		fetches[domain]++
+		if domain == "down.example" {
+			return nil, errors.New("connection refused")
+		}
+		return []core.Pivot{{Kind: core.PivotFavicon, Value: "-42"}, {Kind: core.PivotTitle, Value: "Shop"}}, nil
+	}
+
+	hostname := func(ctx context.Context, domain, key string, timeout time.Duration) ([]core.PassiveIP, error) {
+		return []core.PassiveIP{{IP: "192.0.2.1", Via: "cert"}}, nil
+	}
+	pivotErrs := map[string]error{}
+	search := withPivots(hostname, func(ctx context.Context, pivot core.Pivot, key string, timeout time.Duration) ([]core.PassiveIP, error) {
+		if err := pivotErrs[pivot.Kind]; err != nil {
+			return nil, err
+		}
+		return []core.PassiveIP{{IP: "192.0.2.2", Pivot: pivot.Kind}}, nil
+	})
+
+	ips, err := search(context.Background(), "site.example", "key", time.Second)
+	if err != nil || len(ips) != 3 || ips[1].Pivot != core.PivotFavicon || ips[2].Pivot != core.PivotTitle {
+		t.Errorf("search() = %+v, %v, want the hostname result and one per pivot", ips, err)
+	}
+	search(context.Background(), "site.example", "key", time.Second)
+	if fetches["site.example"] != 1 {
+		t.Errorf("pivots fetched %d times, want 1 per domain", fetches["site.example"])
+	}
+
+	// An unreachable site or a failed pivot keeps the other results
+	if ips, err := search(context.Background(), "down.example", "key", time.Second); err != nil || len(ips) != 1 {
+		t.Errorf("search() without pivots = %+v, %v, want the hostname results", ips, err)
+	}
+	search(context.Background(), "down.example", "key", time.Second)
+	if fetches["down.example"] != 2 {
+		t.Errorf("failed pivot fetch made %d times, want it retried", fetches["down.example"])
+	}
+	pivotErrs[core.PivotTitle] = errors.New("Shodan returned status 500")
+	if ips, err := search(context.Background(), "site.example", "key", time.Second); err != nil || len(ips) != 2 {
+		t.Errorf("search() with a failed pivot = %+v, %v, want the other results", ips, err)
+	}
+
+	// A rate-limited pivot fails the attempt so the key rotates
+	pivotErrs[core.PivotFavicon] = errors.New("Shodan returned status 429: rate limit reached")
+	if _, err := search(context.Background(), "site.example", "key", time.Second); !api.IsRateLimitError(err) {
+		t.Errorf("search() with a rate-limited pivot error = %v, want the rate limit error", err)
+	}
+}
+
+func TestIsCDNCertSubject(t *testing.T) {
+	tests := map[string]bool{
+		"sni.cloudflaressl.com":  true,
+		"*.cloudfront.net":       true,
+		"a248.e.akamai.net":      true,
+		"incapsula.com":          true,
+		"www.example.com":        false,
+		"notcloudflaressl.com":   false,
+		"cloudflaressl.com.evil": false,
+	}
+	for cn, want := range tests {
+		if got := isCDNCertSubject(cn); got != want {
+			t.Errorf("isCDNCertSubject(%q) = %v, want %v", cn, got, want)
+		}
+	}
+}
@@ -2,11 +2,14 @@
 package scoring
 
 import (
-	"net"
+	"context"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // Scorer calculates confidence scores for passive IPs
@@ -59,6 +62,7 @@ func DefaultScoringConfig() *ScoringConfig {
 			"viewdns":        0.6, // Moderate (reverse IP lookup)
 			"dnsdumpster":    0.5, // Moderate (public DNS)
 			"dns":            0.8, // Reliable (current DNS)
+			"mail":           0.8, // Reliable (SPF-authorized and relaying mail servers)
 			"subdomain":      0.4, // Lower (derived from other sources)
 		},
 		RecentThreshold:     30,
@@ -92,6 +96,11 @@ func NewScorer(domain string, config *ScoringConfig) *Scorer {
 
 // ScoreIP calculates confidence score for a single IP
 func (s *Scorer) ScoreIP(ip *core.PassiveIP, allIPs []core.PassiveIP) float64 {
+	return s.score(ip, s.countSources(ip.IP, allIPs))
+}
+
+// score calculates the confidence score of ip, reported by sourceCount distinct sources
+func (s *Scorer) score(ip *core.PassiveIP, sourceCount int) float64 {
 	score := s.config.BaseScore
 
 	// Factor 1: Source weight
@@ -99,7 +108,6 @@ func (s *Scorer) ScoreIP(ip *core.PassiveIP, allIPs []core.PassiveIP) float64 {
 	score += sourceWeight * 0.2 // Scale source weight to reasonable range
 
 	// Factor 2: Multiple sources (aggregation bonus)
-	sourceCount := s.countSources(ip.IP, allIPs)
 	if sourceCount > 1 {
 		// Bonus increases with more sources, but caps at 2 additional sources
 		bonusSources := min(sourceCount-1, 2)
@@ -145,9 +153,10 @@ func (s *Scorer) ScoreIP(ip *core.PassiveIP, allIPs []core.PassiveIP) float64 {
 // ScoreAll calculates confidence scores for all passive IPs
 func (s *Scorer) ScoreAll(ips []core.PassiveIP) []core.PassiveIP {
 	scored := make([]core.PassiveIP, 0, len(ips))
+	counts := sourceCounts(ips)
 
 	for _, ip := range ips {
-		score := s.ScoreIP(&ip, ips)
+		score := s.score(&ip, counts[ip.IP])
 		ip.Confidence = score
 
 		// Filter by minimum confidence
@@ -159,6 +168,181 @@ func (s *Scorer) ScoreAll(ips []core.PassiveIP) []core.PassiveIP {
 	return scored
 }
 
+// Rank scores per-source records, merges them into one entry per IP and
+// orders the result by confidence (highest first).
+// A merged entry keeps its best-scoring record's source and provenance, lists every
+// reporting source in Sources, every distinct provenance in FoundVia and every
+// hostname in Hostnames.
+// IPs below MinConfidence are dropped.
+func (s *Scorer) Rank(ips []core.PassiveIP) []core.PassiveIP {
+	records := make([]core.PassiveIP, len(ips))
+	for i, ip := range ips {
+		ip.Metadata = cloneMetadata(ip.Metadata)
+		records[i] = ip
+	}
+	s.resolveReverseDNS(records)
+	counts := sourceCounts(records)
+
+	byIP := make(map[string]*core.PassiveIP)
+	sources := make(map[string]map[string]bool)
+	foundVia := make(map[string][]string)
+	hostnames := make(map[string][]string)
+	var order []string
+
+	for i := range records {
+		record := &records[i]
+		record.Confidence = s.score(record, counts[record.IP])
+
+		if provenance := record.Provenance(); !containsString(foundVia[record.IP], provenance) {
+			foundVia[record.IP] = append(foundVia[record.IP], provenance)
+		}
+		for _, name := range append([]string{record.Hostname}, record.Hostnames...) {
+			if name != "" && !containsString(hostnames[record.IP], name) {
+				hostnames[record.IP] = append(hostnames[record.IP], name)
+			}
+		}
+
+		best, ok := byIP[record.IP]
+		if !ok {
+			merged := *record
+			merged.Metadata = cloneMetadata(record.Metadata)
+			byIP[record.IP] = &merged
+			sources[record.IP] = map[string]bool{record.Source: true}
+			order = append(order, record.IP)
+			continue
+		}
+
+		sources[record.IP][record.Source] = true
+		if record.Confidence > best.Confidence {
+			best.Confidence = record.Confidence
+			best.Source = record.Source
+			best.Hostname = record.Hostname
+			best.Via = record.Via
+			best.Pivot = record.Pivot
+		}
+		if !record.FirstSeen.IsZero() && (best.FirstSeen.IsZero() || record.FirstSeen.Before(best.FirstSeen)) {
+			best.FirstSeen = record.FirstSeen
+		}
+		if record.LastSeen.After(best.LastSeen) {
+			best.LastSeen = record.LastSeen
+		}
+		if best.Network == "" {
+			best.Network = record.Network
+		}
+		if best.WAF == "" {
+			best.WAF = record.WAF
+		}
+		for k, v := range record.Metadata {
+			if _, exists := best.Metadata[k]; !exists {
+				best.Metadata[k] = v
+			}
+		}
+	}
+
+	ranked := make([]core.PassiveIP, 0, len(order))
+	for _, addr := range order {
+		ip := byIP[addr]
+		if ip.Confidence < s.config.MinConfidence {
+			continue
+		}
+
+		names := make([]string, 0, len(sources[addr]))
+		for name := range sources[addr] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		ip.Sources = names
+		ip.FoundVia = foundVia[addr]
+		ip.Hostnames = hostnames[addr]
+
+		// Drop negative reverse DNS cache entries from the output
+		if rdns, ok := ip.Metadata["reverse_dns"].(string); ok && rdns == "" {
+			delete(ip.Metadata, "reverse_dns")
+		}
+
+		ranked = append(ranked, *ip)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Confidence > ranked[j].Confidence
+	})
+
+	return ranked
+}
+
+// resolveReverseDNS looks up PTR records once per IP, in parallel, and caches
+// the result in each record's metadata so ScoreIP does not repeat the lookup
+func (s *Scorer) resolveReverseDNS(records []core.PassiveIP) {
+	var pending []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if seen[r.IP] {
+			continue
+		}
+		seen[r.IP] = true
+		if _, ok := r.Metadata["reverse_dns"].(string); ok {
+			continue
+		}
+		if _, ok := r.Metadata["ptr_record"].(string); ok {
+			continue
+		}
+		pending = append(pending, r.IP)
+	}
+
+	names := make(map[string]string, len(pending))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+
+	for i := 0; i < min(reverseDNSWorkers, len(pending)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				name := s.performReverseDNS(ip)
+				mu.Lock()
+				names[ip] = name
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ip := range pending {
+		jobs <- ip
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := range records {
+		name, ok := names[records[i].IP]
+		if !ok {
+			continue
+		}
+		if records[i].Metadata == nil {
+			records[i].Metadata = make(map[string]interface{})
+		}
+		records[i].Metadata["reverse_dns"] = name
+	}
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneMetadata returns a shallow copy of a metadata map (never nil)
+func cloneMetadata(m map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
 // getSourceWeight returns the weight for a given source
 func (s *Scorer) getSourceWeight(source string) float64 {
 	if weight, ok := s.config.SourceWeights[source]; ok {
@@ -178,6 +362,19 @@ func (s *Scorer) countSources(ip string, allIPs []core.PassiveIP) int {
 	return len(sources)
 }
 
+// sourceCounts counts the distinct sources reporting each IP in one pass
+func sourceCounts(ips []core.PassiveIP) map[string]int {
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, ip := range ips {
+		if key := ip.IP + "|" + ip.Source; !seen[key] {
+			seen[key] = true
+			counts[ip.IP]++
+		}
+	}
+	return counts
+}
+
 // calculateRecency returns a score based on how recent the sighting is
 func (s *Scorer) calculateRecency(lastSeen time.Time) float64 {
 	if lastSeen.IsZero() {
@@ -231,9 +428,17 @@ func (s *Scorer) hasReverseDNSMatch(ip *core.PassiveIP) bool {
 	return false
 }
 
+// reverseDNSWorkers bounds concurrent PTR lookups in Rank
+const reverseDNSWorkers = 20
+
+// lookupAddr performs PTR lookups (can be overridden in tests)
+var lookupAddr = func(ip string) ([]string, error) {
+	return resolver.Default().LookupAddr(context.Background(), ip)
+}
+
 // performReverseDNS performs actual reverse DNS lookup
 func (s *Scorer) performReverseDNS(ip string) string {
-	names, err := net.LookupAddr(ip)
+	names, err := lookupAddr(ip)
 	if err != nil || len(names) == 0 {
 		return ""
 	}
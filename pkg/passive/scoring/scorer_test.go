@@ -2,6 +2,8 @@
 package scoring
 
 import (
+	"net"
+	"reflect"
 	"testing"
 	"time"
 
@@ -166,6 +168,21 @@ func TestCountSources(t *testing.T) {
 	}
 }
 
+func TestSourceCounts(t *testing.T) {
+	allIPs := []core.PassiveIP{
+		{IP: "192.0.2.1", Source: "shodan"},
+		{IP: "192.0.2.1", Source: "censys"},
+		{IP: "192.0.2.1", Source: "shodan"}, // Duplicate source
+		{IP: "192.0.2.2", Source: "virustotal"},
+	}
+
+	counts := sourceCounts(allIPs)
+	want := map[string]int{"192.0.2.1": 2, "192.0.2.2": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("sourceCounts() = %v, want %v", counts, want)
+	}
+}
+
 // Test reverse DNS match
 func TestHasReverseDNSMatch(t *testing.T) {
 	scorer := NewScorer("example.com", nil)
@@ -795,3 +812,98 @@ func TestHasReverseDNSMatch_WithMetadataNoReverseDNS(t *testing.T) {
 		t.Error("Original metadata should be preserved")
 	}
 }
+
+func TestRank(t *testing.T) {
+	orig := lookupAddr
+	lookups := 0
+	lookupAddr = func(ip string) ([]string, error) {
+		lookups++
+		if ip == "192.0.2.1" {
+			return []string{"origin.example.com."}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: ip, IsNotFound: true}
+	}
+	defer func() { lookupAddr = orig }()
+
+	now := time.Now()
+	first := now.Add(-90 * 24 * time.Hour)
+	ips := []core.PassiveIP{
+		{IP: "192.0.2.2", Source: "ct", LastSeen: now},
+		{IP: "192.0.2.1", Source: "dns", Hostname: "mail.example.com", Via: "MX record", LastSeen: now},
+		{IP: "192.0.2.1", Source: "securitytrails", Hostname: "example.com", Via: "historical A record", FirstSeen: first, LastSeen: now.Add(-24 * time.Hour),
+			Metadata: map[string]interface{}{"asn": "AS64500 Example Inc"}},
+		{IP: "192.0.2.3", Source: "wayback", LastSeen: now.Add(-400 * 24 * time.Hour)},
+	}
+
+	ranked := NewScorer("example.com", nil).Rank(ips)
+
+	if len(ranked) != 3 {
+		t.Fatalf("Rank() returned %d IPs, want 3", len(ranked))
+	}
+	if lookups != 3 {
+		t.Errorf("reverse DNS lookups = %d, want one per IP (3)", lookups)
+	}
+
+	top := ranked[0]
+	if top.IP != "192.0.2.1" {
+		t.Fatalf("top IP = %s, want 192.0.2.1", top.IP)
+	}
+	if top.Source != "securitytrails" || top.Provenance() != "historical A record example.com" {
+		t.Errorf("top = %s %q, want best-scoring securitytrails record", top.Source, top.Provenance())
+	}
+	wantVia := []string{"MX record mail.example.com", "historical A record example.com"}
+	if !reflect.DeepEqual(top.FoundVia, wantVia) {
+		t.Errorf("found_via = %v, want %v", top.FoundVia, wantVia)
+	}
+	if want := []string{"mail.example.com", "example.com"}; !reflect.DeepEqual(top.Hostnames, want) {
+		t.Errorf("hostnames = %v, want %v", top.Hostnames, want)
+	}
+	if len(ranked[1].Hostnames) != 0 {
+		t.Error("IPs without hostnames should have no hostnames")
+	}
+	if !reflect.DeepEqual(top.Sources, []string{"dns", "securitytrails"}) {
+		t.Errorf("sources = %v, want [dns securitytrails]", top.Sources)
+	}
+	if !top.FirstSeen.Equal(first) || !top.LastSeen.Equal(now) {
+		t.Errorf("seen = %v..%v, want %v..%v", top.FirstSeen, top.LastSeen, first, now)
+	}
+	if top.Metadata["reverse_dns"] != "origin.example.com." || top.Metadata["asn"] == nil {
+		t.Errorf("metadata not merged: %v", top.Metadata)
+	}
+
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Confidence > ranked[i-1].Confidence {
+			t.Errorf("results not ordered by confidence: %v", ranked)
+		}
+	}
+	if _, ok := ranked[2].Metadata["reverse_dns"]; ok {
+		t.Error("empty reverse DNS result should not be reported")
+	}
+
+	// Input records must not be mutated
+	if ips[0].Confidence != 0 || ips[0].Metadata != nil {
+		t.Error("Rank() mutated its input")
+	}
+}
+
+func TestRank_MinConfidence(t *testing.T) {
+	config := DefaultScoringConfig()
+	config.MinConfidence = 0.6
+	scorer := NewScorer("example.com", config)
+
+	now := time.Now()
+	ips := []core.PassiveIP{
+		{IP: "192.0.2.1", Source: "shodan", LastSeen: now, Metadata: map[string]interface{}{"reverse_dns": ""}},
+		{IP: "192.0.2.1", Source: "censys", LastSeen: now, Metadata: map[string]interface{}{"reverse_dns": ""}},
+		{IP: "192.0.2.2", Source: "ct", LastSeen: now.Add(-400 * 24 * time.Hour), Metadata: map[string]interface{}{"reverse_dns": ""}},
+	}
+
+	ranked := scorer.Rank(ips)
+
+	if len(ranked) != 1 || ranked[0].IP != "192.0.2.1" {
+		t.Fatalf("Rank() = %v, want only 192.0.2.1", ranked)
+	}
+	if ranked[0].Confidence < config.MinConfidence {
+		t.Errorf("confidence %f below threshold", ranked[0].Confidence)
+	}
+}
@@ -10,6 +10,10 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/history"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // SubdomainResponse represents the JSON response from SecurityTrails subdomains API
@@ -44,10 +48,13 @@ type Value struct {
 	ASNOrganization string `json:"asn_organization"`
 }
 
+// dateLayout is the format of HistoryRecord.FirstSeen and LastSeen
+const dateLayout = "2006-01-02"
+
 // SearchSubdomainsAndHistory queries SecurityTrails for subdomains and historical IPs
-func SearchSubdomainsAndHistory(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+func SearchSubdomainsAndHistory(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no SecurityTrails API keys provided")
+		return nil, fmt.Errorf("no SecurityTrails API keys provided")
 	}
 
 	// Try each API key until one works
@@ -58,7 +65,7 @@ func SearchSubdomainsAndHistory(ctx context.Context, domain string, apiKeys []st
 			continue
 		}
 
-		ips, err := searchWithKey(ctx, domain, apiKey, timeout)
+		ips, err := SearchWithKey(ctx, domain, apiKey, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -70,19 +77,21 @@ func SearchSubdomainsAndHistory(ctx context.Context, domain string, apiKeys []st
 		}
 
 		// For other errors, return immediately
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
 }
 
-// searchWithKey performs the search with a single API key
-func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
-	ipSet := make(map[string]bool)
+// SearchWithKey performs the search with a single API key
+func SearchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	// Records are keyed by hostname|IP so every subdomain sharing an IP is kept
+	byName := make(map[string]*core.PassiveIP)
+	var order []string
 
 	// Step 1: Get subdomains
 	subdomains, err := getSubdomains(ctx, domain, apiKey, timeout)
@@ -96,8 +105,10 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		// Non-fatal: log but continue
 		// Some domains may not have history
 	} else {
-		for _, ip := range histIPs {
-			ipSet[ip] = true
+		for i := range histIPs {
+			key := domain + "|" + histIPs[i].IP
+			byName[key] = &histIPs[i]
+			order = append(order, key)
 		}
 	}
 
@@ -110,24 +121,39 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		}
 
 		fullDomain := subdomain + "." + domain
-		ips, err := resolveToIPv4(ctx, fullDomain, 5*time.Second)
+		ips, err := resolveIPs(ctx, fullDomain, 5*time.Second)
 		if err != nil {
 			continue // Skip failed resolutions
 		}
 
+		// Resolved addresses are current as of now
+		now := time.Now()
 		for _, ip := range ips {
-			ipSet[ip] = true
+			key := fullDomain + "|" + ip
+			record, ok := byName[key]
+			if !ok {
+				record = &core.PassiveIP{
+					IP:        ip,
+					Source:    "securitytrails",
+					Hostname:  fullDomain,
+					Via:       "SecurityTrails subdomain",
+					FirstSeen: now,
+					Metadata:  make(map[string]interface{}),
+				}
+				byName[key] = record
+				order = append(order, key)
+			}
+			record.LastSeen = now
 		}
 		resolveCount++
 	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+	records := make([]core.PassiveIP, 0, len(order))
+	for _, key := range order {
+		records = append(records, *byName[key])
 	}
 
-	return ips, nil
+	return records, nil
 }
 
 // getSubdomains fetches subdomains from SecurityTrails API
@@ -177,7 +203,7 @@ func getSubdomains(ctx context.Context, domain, apiKey string, timeout time.Dura
 }
 
 // getHistoricalIPs fetches historical A records from SecurityTrails API
-func getHistoricalIPs(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
+func getHistoricalIPs(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
 	url := fmt.Sprintf("https://api.securitytrails.com/v1/history/%s/dns/a", domain)
 
 	client := &http.Client{Timeout: timeout}
@@ -203,57 +229,82 @@ func getHistoricalIPs(ctx context.Context, domain, apiKey string, timeout time.D
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return []string{}, nil // Non-fatal: no history available
+		return nil, nil // Non-fatal: no history available
 	}
 
 	var histResp HistoryResponse
 	if err := json.Unmarshal(body, &histResp); err != nil {
-		return []string{}, nil // Non-fatal: parsing error
+		return nil, nil // Non-fatal: parsing error
 	}
 
-	// Extract IPs from history records
-	ipSet := make(map[string]bool)
-	for _, record := range histResp.Records {
+	return parseHistory(domain, histResp.Records), nil
+}
+
+// parseHistory converts historical A records of domain into passive IP records, one per IPv4 address.
+// The first/last seen dates span every record the IP appeared in; each record's
+// dates are also kept in Metadata[history.MetadataKey].
+func parseHistory(domain string, entries []HistoryRecord) []core.PassiveIP {
+	byIP := make(map[string]*core.PassiveIP)
+	var order []string
+
+	for _, record := range entries {
+		firstSeen, _ := time.Parse(dateLayout, record.FirstSeen)
+		lastSeen, _ := time.Parse(dateLayout, record.LastSeen)
+
 		for _, value := range record.Values {
 			ip := strings.TrimSpace(value.IP)
-			if ip != "" {
-				// Validate IPv4
-				parsedIP := net.ParseIP(ip)
-				if parsedIP != nil && parsedIP.To4() != nil {
-					ipSet[ip] = true
+			if ip == "" {
+				continue
+			}
+			// Validate IPv4
+			parsedIP := net.ParseIP(ip)
+			if parsedIP == nil || parsedIP.To4() == nil {
+				continue
+			}
+
+			passiveIP, ok := byIP[ip]
+			if !ok {
+				passiveIP = &core.PassiveIP{
+					IP:       ip,
+					Source:   "securitytrails",
+					Hostname: domain,
+					Via:      "historical A record",
+					Metadata: make(map[string]interface{}),
 				}
+				byIP[ip] = passiveIP
+				order = append(order, ip)
+			}
+
+			if !firstSeen.IsZero() && (passiveIP.FirstSeen.IsZero() || firstSeen.Before(passiveIP.FirstSeen)) {
+				passiveIP.FirstSeen = firstSeen
+			}
+			if lastSeen.After(passiveIP.LastSeen) {
+				passiveIP.LastSeen = lastSeen
+			}
+			if !firstSeen.IsZero() && !lastSeen.IsZero() {
+				periods, _ := passiveIP.Metadata[history.MetadataKey].([]history.Period)
+				passiveIP.Metadata[history.MetadataKey] = append(periods, history.Period{From: firstSeen, To: lastSeen})
+			}
+			if _, exists := passiveIP.Metadata["asn"]; !exists && value.ASNOrganization != "" {
+				passiveIP.Metadata["asn"] = value.ASNOrganization
+			}
+			if _, exists := passiveIP.Metadata["organization"]; !exists && len(record.Organizations) > 0 {
+				passiveIP.Metadata["organization"] = record.Organizations[0]
 			}
 		}
 	}
 
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+	records := make([]core.PassiveIP, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
 	}
-
-	return ips, nil
+	return records
 }
 
-// resolveToIPv4 resolves a domain to IPv4 addresses
-func resolveToIPv4(ctx context.Context, domain string, timeout time.Duration) ([]string, error) {
-	resolver := &net.Resolver{}
-
+// resolveIPs resolves a domain to its IPv4 and IPv6 addresses
+func resolveIPs(ctx context.Context, domain string, timeout time.Duration) ([]string, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	addrs, err := resolver.LookupHost(ctxWithTimeout, domain)
-	if err != nil {
-		return nil, err
-	}
-
-	// Filter IPv4 only
-	var ipv4s []string
-	for _, addr := range addrs {
-		ip := net.ParseIP(addr)
-		if ip != nil && ip.To4() != nil {
-			ipv4s = append(ipv4s, addr)
-		}
-	}
-
-	return ipv4s, nil
+	return resolver.Default().LookupHost(ctxWithTimeout, domain)
 }
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/passive/history"
 )
 
 func TestSearchSubdomainsAndHistory_NoAPIKeys(t *testing.T) {
@@ -51,9 +53,9 @@ func TestSearchSubdomainsAndHistory_ContextCancellation(t *testing.T) {
 	}
 }
 
-func TestResolveToIPv4(t *testing.T) {
+func TestResolveIPs(t *testing.T) {
 	ctx := context.Background()
-	_, err := resolveToIPv4(ctx, "localhost", 2*time.Second)
+	_, err := resolveIPs(ctx, "localhost", 2*time.Second)
 	if err != nil {
 		t.Logf("Resolve failed: %v", err)
 	}
@@ -131,7 +133,7 @@ func TestGetSubdomains_MockServer(t *testing.T) {
 	}
 }
 
-// TestSearchWithKey_ErrorHandling tests error handling in searchWithKey
+// TestSearchWithKey_ErrorHandling tests error handling in SearchWithKey
 func TestSearchWithKey_ErrorHandling(t *testing.T) {
 	ctx := context.Background()
 
@@ -154,9 +156,9 @@ func TestSearchWithKey_ErrorHandling(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := searchWithKey(ctx, tt.domain, tt.apiKey, 100*time.Millisecond)
+			_, err := SearchWithKey(ctx, tt.domain, tt.apiKey, 100*time.Millisecond)
 			if err == nil {
-				t.Log("searchWithKey returned no error (may depend on API)")
+				t.Log("SearchWithKey returned no error (may depend on API)")
 			}
 		})
 	}
@@ -175,12 +177,12 @@ func TestGetHistoricalIPs(t *testing.T) {
 	}
 }
 
-// TestResolveToIPv4_ValidDomain tests resolution of a known domain
-func TestResolveToIPv4_ValidDomain(t *testing.T) {
+// TestResolveIPs_ValidDomain tests resolution of a known domain
+func TestResolveIPs_ValidDomain(t *testing.T) {
 	ctx := context.Background()
 
 	// Test with localhost which should always resolve
-	ips, err := resolveToIPv4(ctx, "localhost", 2*time.Second)
+	ips, err := resolveIPs(ctx, "localhost", 2*time.Second)
 	if err != nil {
 		t.Errorf("Failed to resolve localhost: %v", err)
 	}
@@ -197,24 +199,24 @@ func TestResolveToIPv4_ValidDomain(t *testing.T) {
 	}
 }
 
-// TestResolveToIPv4_InvalidDomain tests resolution of invalid domain
-func TestResolveToIPv4_InvalidDomain(t *testing.T) {
+// TestResolveIPs_InvalidDomain tests resolution of invalid domain
+func TestResolveIPs_InvalidDomain(t *testing.T) {
 	ctx := context.Background()
 
-	_, err := resolveToIPv4(ctx, "this-domain-definitely-does-not-exist-12345.invalid", 2*time.Second)
+	_, err := resolveIPs(ctx, "this-domain-definitely-does-not-exist-12345.invalid", 2*time.Second)
 	if err == nil {
 		t.Error("Expected error for invalid domain")
 	}
 }
 
-// TestResolveToIPv4_ContextTimeout tests context timeout handling
-func TestResolveToIPv4_ContextTimeout(t *testing.T) {
+// TestResolveIPs_ContextTimeout tests context timeout handling
+func TestResolveIPs_ContextTimeout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
 	defer cancel()
 
 	time.Sleep(10 * time.Millisecond) // Ensure timeout
 
-	_, err := resolveToIPv4(ctx, "example.com", 5*time.Second)
+	_, err := resolveIPs(ctx, "example.com", 5*time.Second)
 	if err == nil {
 		t.Error("Expected timeout error")
 	}
@@ -243,3 +245,44 @@ func TestHistoricalResponse_Structure(t *testing.T) {
 		t.Errorf("Expected IP 192.168.1.1, got %s", resp.Records[0].Values[0].IP)
 	}
 }
+
+// TestParseHistory tests conversion of historical A records
+func TestParseHistory(t *testing.T) {
+	entries := []HistoryRecord{
+		{
+			Type:          "a",
+			FirstSeen:     "2021-06-01",
+			LastSeen:      "2022-03-15",
+			Organizations: []string{"Example Hosting"},
+			Values:        []Value{{IP: "192.0.2.1", ASNOrganization: "EXAMPLE-AS"}, {IP: "2001:db8::1"}},
+		},
+		{
+			Type:      "a",
+			FirstSeen: "2020-01-10",
+			LastSeen:  "2021-05-31",
+			Values:    []Value{{IP: "192.0.2.1"}, {IP: "192.0.2.2"}, {IP: ""}},
+		},
+	}
+
+	records := parseHistory("example.com", entries)
+
+	if len(records) != 2 {
+		t.Fatalf("parseHistory() returned %d records, want 2", len(records))
+	}
+	first := records[0]
+	if first.IP != "192.0.2.1" || first.Source != "securitytrails" || first.Hostname != "example.com" {
+		t.Errorf("record = %s/%s, want 192.0.2.1/securitytrails", first.IP, first.Source)
+	}
+	if first.FirstSeen.Format(dateLayout) != "2020-01-10" || first.LastSeen.Format(dateLayout) != "2022-03-15" {
+		t.Errorf("seen range = %v..%v, want 2020-01-10..2022-03-15", first.FirstSeen, first.LastSeen)
+	}
+	if first.Metadata["asn"] != "EXAMPLE-AS" || first.Metadata["organization"] != "Example Hosting" {
+		t.Errorf("unexpected metadata: %v", first.Metadata)
+	}
+	if periods, _ := first.Metadata[history.MetadataKey].([]history.Period); len(periods) != 2 || periods[1].From.Format(dateLayout) != "2020-01-10" {
+		t.Errorf("history = %v, want both records' periods", first.Metadata[history.MetadataKey])
+	}
+	if records[1].IP != "192.0.2.2" || records[1].LastSeen.Format(dateLayout) != "2021-05-31" {
+		t.Errorf("unexpected second record: %+v", records[1])
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 // ShodanResponse represents the JSON response from Shodan API
@@ -21,17 +25,33 @@ type ShodanResponse struct {
 
 // ShodanMatch represents a single host result
 type ShodanMatch struct {
-	IPStr     string   `json:"ip_str"`
-	Hostnames []string `json:"hostnames"`
-	Domains   []string `json:"domains"`
-	Port      int      `json:"port"`
-	Transport string   `json:"transport"`
+	IPStr     string         `json:"ip_str"`
+	Hostnames []string       `json:"hostnames"`
+	Domains   []string       `json:"domains"`
+	Port      int            `json:"port"`
+	Transport string         `json:"transport"`
+	Org       string         `json:"org"`
+	ISP       string         `json:"isp"`
+	ASN       string         `json:"asn"`
+	Timestamp string         `json:"timestamp"` // Time the banner was collected
+	Location  ShodanLocation `json:"location"`
+}
+
+// ShodanLocation contains geolocation data
+type ShodanLocation struct {
+	CountryCode string `json:"country_code"`
 }
 
+// timestampLayout is the format of ShodanMatch.Timestamp
+const timestampLayout = "2006-01-02T15:04:05.999999"
+
+// apiURL is the Shodan host search endpoint (can be overridden in tests)
+var apiURL = "https://api.shodan.io/shodan/host/search"
+
 // SearchHostname queries Shodan for hosts matching the domain using hostname filter
-func SearchHostname(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+func SearchHostname(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no Shodan API keys provided")
+		return nil, fmt.Errorf("no Shodan API keys provided")
 	}
 
 	// Try each API key until one works (rotation for rate limits)
@@ -42,7 +62,7 @@ func SearchHostname(ctx context.Context, domain string, apiKeys []string, timeou
 			continue
 		}
 
-		ips, err := searchWithKey(ctx, domain, apiKey, timeout)
+		ips, err := SearchWithKey(ctx, domain, apiKey, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -54,28 +74,65 @@ func SearchHostname(ctx context.Context, domain string, apiKeys []string, timeou
 		}
 
 		// For other errors, return immediately (invalid key, network issue, etc.)
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
 }
 
-// searchWithKey performs the search with a single API key
-func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
+// SearchWithKey performs the search with a single API key
+func SearchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
 	// Build query: ssl.cert.subject.cn:"domain.com"
 	// This searches for SSL certificates with the domain in the Common Name field
 	query := fmt.Sprintf(`ssl.cert.subject.cn:"%s"`, domain)
-	url := fmt.Sprintf("https://api.shodan.io/shodan/host/search?query=%s&key=%s", query, apiKey)
+	return search(ctx, query, "Shodan SSL cert CN", apiKey, timeout)
+}
+
+// PivotQuery builds the Shodan query for a pivot and the description of its results
+func PivotQuery(pivot core.Pivot) (query, via string, err error) {
+	switch pivot.Kind {
+	case core.PivotFavicon:
+		return "http.favicon.hash:" + pivot.Value, "Shodan favicon hash", nil
+	case core.PivotTitle:
+		return "http.title:" + strconv.Quote(pivot.Value), "Shodan HTML title", nil
+	case core.PivotCertSHA256:
+		return "ssl.cert.fingerprint:" + pivot.Value, "Shodan cert SHA-256", nil
+	case core.PivotCertSubject:
+		return "ssl.cert.subject.cn:" + strconv.Quote(pivot.Value), "Shodan cert subject", nil
+	}
+	return "", "", fmt.Errorf("%w: %s", core.ErrUnsupportedPivot, pivot.Kind)
+}
+
+// SearchPivotWithKey finds hosts sharing an attribute of the live site
+func SearchPivotWithKey(ctx context.Context, pivot core.Pivot, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	query, via, err := PivotQuery(pivot)
+	if err != nil {
+		return nil, err
+	}
+	records, err := search(ctx, query, via, apiKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Pivot = pivot.Kind
+		records[i].Metadata["pivot_value"] = pivot.Value
+	}
+	return records, nil
+}
+
+// search runs a host search query; via describes the query in the returned records
+func search(ctx context.Context, query, via, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	endpoint := fmt.Sprintf("%s?query=%s&key=%s", apiURL, url.QueryEscape(query), url.QueryEscape(apiKey))
 
 	client := &http.Client{
 		Timeout: timeout,
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -114,9 +171,16 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		return nil, fmt.Errorf("Shodan API error: %s", shodanResp.Error)
 	}
 
-	// Extract unique IPv4 addresses
-	ipSet := make(map[string]bool)
-	for _, match := range shodanResp.Matches {
+	return parseMatches(shodanResp.Matches, via), nil
+}
+
+// parseMatches converts host matches into passive IP records, one per IPv4 address.
+// Banner timestamps become the first/last seen range; ASN, org and location go to metadata.
+func parseMatches(matches []ShodanMatch, via string) []core.PassiveIP {
+	byIP := make(map[string]*core.PassiveIP)
+	var order []string
+
+	for _, match := range matches {
 		ip := strings.TrimSpace(match.IPStr)
 		if ip == "" {
 			continue
@@ -124,21 +188,51 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 
 		// Validate and filter IPv4 only
 		parsedIP := net.ParseIP(ip)
-		if parsedIP == nil {
+		if parsedIP == nil || parsedIP.To4() == nil {
 			continue
 		}
 
-		// Check if IPv4 (To4() returns nil for IPv6)
-		if parsedIP.To4() != nil {
-			ipSet[ip] = true
+		record, ok := byIP[ip]
+		if !ok {
+			record = &core.PassiveIP{IP: ip, Source: "shodan", Via: via, Metadata: make(map[string]interface{})}
+			byIP[ip] = record
+			order = append(order, ip)
+		}
+
+		if seen, err := time.Parse(timestampLayout, match.Timestamp); err == nil {
+			if record.FirstSeen.IsZero() || seen.Before(record.FirstSeen) {
+				record.FirstSeen = seen
+			}
+			if seen.After(record.LastSeen) {
+				record.LastSeen = seen
+			}
+		}
+
+		setMetadata(record.Metadata, "asn", match.ASN)
+		setMetadata(record.Metadata, "organization", match.Org)
+		setMetadata(record.Metadata, "hosting_provider", match.ISP)
+		setMetadata(record.Metadata, "country_code", match.Location.CountryCode)
+		if record.Hostname == "" && len(match.Hostnames) > 0 {
+			record.Hostname = match.Hostnames[0]
+		}
+		if len(record.Hostnames) == 0 {
+			record.Hostnames = match.Hostnames
 		}
 	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+	records := make([]core.PassiveIP, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
 	}
+	return records
+}
 
-	return ips, nil
+// setMetadata stores a non-empty value unless the key is already set
+func setMetadata(metadata map[string]interface{}, key, value string) {
+	if value == "" {
+		return
+	}
+	if _, exists := metadata[key]; !exists {
+		metadata[key] = value
+	}
 }
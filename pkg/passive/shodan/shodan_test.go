@@ -3,10 +3,13 @@ package shodan
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 func TestSearchHostname_NoAPIKeys(t *testing.T) {
@@ -130,7 +133,7 @@ func TestSearchWithKey_HTTPError(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "invalid_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "invalid_key", 1*time.Second)
 	if err == nil {
 		t.Log("Expected error for invalid key")
 	}
@@ -144,7 +147,7 @@ func TestSearchWithKey_InvalidJSON(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Expected JSON parsing error")
 	}
@@ -160,7 +163,7 @@ func TestSearchWithKey_APIErrorInJSON(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Expected API error from JSON")
 	}
@@ -221,7 +224,7 @@ func TestSearchWithKey_IPv6Filtering(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("IPv6 filtering test completed")
 	}
@@ -242,7 +245,7 @@ func TestSearchWithKey_EmptyIPStr(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Empty IP filtering test completed")
 	}
@@ -263,7 +266,7 @@ func TestSearchWithKey_InvalidIPFormat(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Invalid IP filtering test completed")
 	}
@@ -295,7 +298,7 @@ func TestSearchWithKey_DuplicateIPs(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Duplicate IP deduplication test completed")
 	}
@@ -312,7 +315,7 @@ func TestSearchHostname_WhitespaceKeys(t *testing.T) {
 
 func TestSearchWithKey_EmptyDomain(t *testing.T) {
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Log("Expected error for empty domain")
@@ -341,3 +344,86 @@ func TestShodanMatch_AllFields(t *testing.T) {
 		t.Errorf("Port = %d, want 25", match.Port)
 	}
 }
+
+func TestParseMatches(t *testing.T) {
+	matches := []ShodanMatch{
+		{IPStr: "192.0.2.1", Port: 443, Timestamp: "2024-03-01T10:00:00.000000", ASN: "AS64500", Org: "Example Inc",
+			Location: ShodanLocation{CountryCode: "US"}, Hostnames: []string{"origin.example.com"}},
+		{IPStr: "192.0.2.1", Port: 80, Timestamp: "2024-01-15T08:30:00.123456"},
+		{IPStr: "2001:db8::1"},
+		{IPStr: ""},
+		{IPStr: "192.0.2.2", Timestamp: "not-a-time"},
+	}
+
+	records := parseMatches(matches, "Shodan SSL cert CN")
+
+	if len(records) != 2 {
+		t.Fatalf("parseMatches() returned %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if first.IP != "192.0.2.1" || first.Source != "shodan" || first.Hostname != "origin.example.com" {
+		t.Errorf("record = %s/%s, want 192.0.2.1/shodan", first.IP, first.Source)
+	}
+	if got := first.FirstSeen.Format("2006-01-02"); got != "2024-01-15" {
+		t.Errorf("FirstSeen = %s, want 2024-01-15", got)
+	}
+	if got := first.LastSeen.Format("2006-01-02"); got != "2024-03-01" {
+		t.Errorf("LastSeen = %s, want 2024-03-01", got)
+	}
+	if first.Metadata["asn"] != "AS64500" || first.Metadata["organization"] != "Example Inc" || first.Metadata["country_code"] != "US" {
+		t.Errorf("unexpected metadata: %v", first.Metadata)
+	}
+
+	if !records[1].LastSeen.IsZero() {
+		t.Errorf("unparseable timestamp should leave LastSeen zero, got %v", records[1].LastSeen)
+	}
+}
+
+func TestPivotQuery(t *testing.T) {
+	tests := []struct {
+		pivot core.Pivot
+		query string
+	}{
+		{core.Pivot{Kind: core.PivotFavicon, Value: "-2059846915"}, "http.favicon.hash:-2059846915"},
+		{core.Pivot{Kind: core.PivotTitle, Value: `Shop "Main"`}, `http.title:"Shop \"Main\""`},
+		{core.Pivot{Kind: core.PivotCertSHA256, Value: "ab12"}, "ssl.cert.fingerprint:ab12"},
+		{core.Pivot{Kind: core.PivotCertSubject, Value: "*.example.com"}, `ssl.cert.subject.cn:"*.example.com"`},
+	}
+	for _, tt := range tests {
+		query, via, err := PivotQuery(tt.pivot)
+		if err != nil || query != tt.query || via == "" {
+			t.Errorf("PivotQuery(%v) = %q, %q, %v, want %q", tt.pivot, query, via, err, tt.query)
+		}
+	}
+	if _, _, err := PivotQuery(core.Pivot{Kind: "nope"}); !errors.Is(err, core.ErrUnsupportedPivot) {
+		t.Errorf("PivotQuery(nope) error = %v, want ErrUnsupportedPivot", err)
+	}
+}
+
+func TestSearchPivotWithKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("query"); got != "http.favicon.hash:-2059846915" {
+			t.Errorf("query = %q, want http.favicon.hash:-2059846915", got)
+		}
+		if got := r.URL.Query().Get("key"); got != "test_key" {
+			t.Errorf("key = %q, want test_key", got)
+		}
+		json.NewEncoder(w).Encode(ShodanResponse{Total: 1, Matches: []ShodanMatch{{IPStr: "192.0.2.10"}}})
+	}))
+	defer server.Close()
+
+	oldURL := apiURL
+	apiURL = server.URL
+	defer func() { apiURL = oldURL }()
+
+	pivot := core.Pivot{Kind: core.PivotFavicon, Value: "-2059846915"}
+	records, err := SearchPivotWithKey(context.Background(), pivot, "test_key", time.Second)
+	if err != nil {
+		t.Fatalf("SearchPivotWithKey() error: %v", err)
+	}
+	if len(records) != 1 || records[0].IP != "192.0.2.10" || records[0].Via != "Shodan favicon hash" ||
+		records[0].Pivot != core.PivotFavicon || records[0].Metadata["pivot_value"] != pivot.Value {
+		t.Errorf("SearchPivotWithKey() = %+v, want 192.0.2.10 via the favicon hash", records)
+	}
+}
@@ -0,0 +1,87 @@
+// Package passive defines the common interface implemented by passive reconnaissance sources
+package passive
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+)
+
+// Source is a passive intelligence source that discovers IPs for a domain
+type Source interface {
+	// Name returns the identifier used in --passive-sources (e.g. "ct")
+	Name() string
+
+	// Search returns the IPs found for domain, each with its provenance
+	// (hostname, how it was found, first/last seen and raw metadata)
+	Search(ctx context.Context, domain string) ([]core.PassiveIP, error)
+}
+
+// Factory builds a source from the scan configuration (API keys, timeouts)
+// Keyed sources draw their keys through the shared api.Manager
+type Factory func(config *core.Config, keys *api.Manager) Source
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a source available under name
+// Registering the same name twice panics
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name = strings.ToLower(name)
+	if factory == nil {
+		panic("passive: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("passive: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns the registered source for name (case-insensitive)
+// A nil manager gives keyed sources a private one with default failover
+func New(name string, config *core.Config, keys *api.Manager) (Source, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrUnknownSource, name)
+	}
+	if keys == nil {
+		keys = api.NewManager(true)
+	}
+	return factory(config, keys), nil
+}
+
+// Names returns the names of all registered sources, sorted
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Timeout returns the timeout to use for passive intelligence sources.
+// It is at least 30s, but follows the CLI `-t` value if greater.
+func Timeout(config *core.Config) time.Duration {
+	if config.Timeout > 30*time.Second {
+		return config.Timeout
+	}
+	return 30 * time.Second
+}
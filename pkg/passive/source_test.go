@@ -0,0 +1,275 @@
+package passive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
+)
+
+func TestBuiltinSourcesRegistered(t *testing.T) {
+	want := []string{"censys", "ct", "dns", "dnsdumpster", "mail", "securitytrails", "shodan", "viewdns", "virustotal", "wayback", "zoomeye"}
+	for _, name := range want {
+		src, err := New(name, core.DefaultConfig(), nil)
+		if err != nil {
+			t.Errorf("New(%q) error = %v", name, err)
+			continue
+		}
+		if src.Name() != name {
+			t.Errorf("New(%q).Name() = %q", name, src.Name())
+		}
+	}
+
+	// Lookup is case-insensitive
+	if _, err := New("Shodan", core.DefaultConfig(), nil); err != nil {
+		t.Errorf("New(\"Shodan\") error = %v", err)
+	}
+}
+
+func TestNew_UnknownSource(t *testing.T) {
+	_, err := New("nope", core.DefaultConfig(), nil)
+	if !errors.Is(err, core.ErrUnknownSource) {
+		t.Fatalf("New(\"nope\") error = %v, want ErrUnknownSource", err)
+	}
+	if !strings.Contains(err.Error(), "nope") {
+		t.Errorf("error %q should name the source", err)
+	}
+}
+
+func TestKeyedSources_NoKeys(t *testing.T) {
+	tests := map[string]string{
+		"shodan":         "no Shodan API keys configured",
+		"censys":         "no Censys PAT tokens configured",
+		"securitytrails": "no SecurityTrails API keys configured",
+		"zoomeye":        "no ZoomEye API keys configured",
+		"virustotal":     "no VirusTotal API keys configured",
+		"viewdns":        "no ViewDNS API keys configured",
+		"dnsdumpster":    "no DNSDumpster API keys configured",
+	}
+
+	for name, wantErr := range tests {
+		src, err := New(name, core.DefaultConfig(), nil)
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", name, err)
+		}
+		_, err = src.Search(context.Background(), "example.com")
+		if err == nil || err.Error() != wantErr {
+			t.Errorf("%s.Search() error = %v, want %q", name, err, wantErr)
+		}
+	}
+}
+
+func TestKeyedSearchFunc_RotatesThroughManager(t *testing.T) {
+	manager := api.NewManager(true)
+	src := keyedSearchFunc("shodan", core.DefaultConfig(), manager, []string{"limited", "good"}, "Shodan API keys", "Shodan search failed",
+		func(ctx context.Context, domain, key string, timeout time.Duration) ([]core.PassiveIP, error) {
+			if key == "limited" {
+				return nil, errors.New("Shodan returned status 429: rate limit reached")
+			}
+			return []core.PassiveIP{{IP: "192.0.2.1", Source: "shodan"}}, nil
+		})
+
+	ips, err := src.Search(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(ips) != 1 || ips[0].IP != "192.0.2.1" {
+		t.Errorf("Search() = %v, want the result from the second key", ips)
+	}
+
+	report := manager.Report()
+	if len(report) != 1 || report[0].Source != api.SourceShodan || report[0].RateLimits != 1 || report[0].Available != 1 {
+		t.Errorf("Report() = %+v, want shodan with 1 rate limit and 1 available key", report)
+	}
+}
+
+// stubSource is a fixed-result source for registry tests
+type stubSource struct{ records []core.PassiveIP }
+
+func (s *stubSource) Name() string { return "stub" }
+
+func (s *stubSource) Search(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+	return s.records, nil
+}
+
+func TestRegister(t *testing.T) {
+	want := []core.PassiveIP{{IP: "192.0.2.1", Source: "stub", Hostname: "api.example.com", Via: "stub record"}}
+	Register("stub", func(config *core.Config, keys *api.Manager) Source { return &stubSource{records: want} })
+
+	found := false
+	for _, name := range Names() {
+		if name == "stub" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Names() = %v, missing stub", Names())
+	}
+
+	src, err := New("stub", core.DefaultConfig(), nil)
+	if err != nil {
+		t.Fatalf("New(\"stub\") error = %v", err)
+	}
+	got, err := src.Search(context.Background(), "example.com")
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, %v; want %v", got, err, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name should panic")
+		}
+	}()
+	Register("STUB", func(config *core.Config, keys *api.Manager) Source { return &stubSource{} })
+}
+
+func TestTimeout(t *testing.T) {
+	config := core.DefaultConfig()
+
+	config.Timeout = 5 * time.Second
+	if got := Timeout(config); got != 30*time.Second {
+		t.Errorf("Timeout() = %v, want 30s minimum", got)
+	}
+
+	config.Timeout = 45 * time.Second
+	if got := Timeout(config); got != 45*time.Second {
+		t.Errorf("Timeout() = %v, want 45s", got)
+	}
+}
+
+func TestDNSSource_Wordlist(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("*.example.com", "192.0.2.100")
+	zone.AddA("www.example.com", "192.0.2.1")
+	zone.AddA("api.example.com", "192.0.2.2")
+	zone.AddA("api-staging.example.com", "192.0.2.3")
+	zone.AddMX("example.com", "mail.example.com")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	defer resolver.SetDefault(resolver.Default())
+	resolver.SetDefault(r)
+
+	wordlist := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(wordlist, []byte("www\napi\nwildcarded\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var status bytes.Buffer
+	config := core.DefaultConfig()
+	config.Status = &status // Progress lines go here, not to stdout
+	config.Wordlist = wordlist
+	config.Permutations = true
+
+	src, err := New("dns", config, nil)
+	if err != nil {
+		t.Fatalf("New(dns) error: %v", err)
+	}
+	records, err := src.Search(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, record := range records {
+		got[record.IP] = record.Hostname + " " + record.Via
+	}
+	want := map[string]string{
+		"192.0.2.1":   "www.example.com DNS subdomain",
+		"192.0.2.2":   "api.example.com DNS subdomain",
+		"192.0.2.3":   "api-staging.example.com DNS subdomain", // Permutation of api
+		"192.0.2.100": "mail.example.com MX record",            // The wildcard IP only via MX
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+	if !strings.Contains(status.String(), "  → ") {
+		t.Errorf("progress output = %q, want the source's progress lines", status.String())
+	}
+
+	config.Wordlist = filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := src.Search(context.Background(), "example.com"); err == nil {
+		t.Error("Search() with a missing wordlist should fail")
+	}
+}
+
+func TestMailSource(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddTXT("example.com", "v=spf1 ip4:192.0.2.10 include:_spf.example.com include:_spf.vendor.net -all")
+	zone.AddTXT("_spf.example.com", "v=spf1 a:relay.example.com ip4:203.0.113.0/16")
+	zone.AddA("relay.example.com", "192.0.2.11")
+	zone.AddTXT("_spf.vendor.net", "v=spf1 ip4:198.51.100.1")
+	zone.AddTXT("_dmarc.example.com", "v=DMARC1; p=quarantine; rua=mailto:dmarc@reports.example.com,mailto:x@dmarc.vendor.net")
+	zone.AddMX("reports.example.com", "mx.reports.example.com")
+	zone.AddA("mx.reports.example.com", "192.0.2.12")
+	zone.AddTXT("selector1._domainkey.example.com", "v=DKIM1; k=rsa; p=MIGf")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	defer resolver.SetDefault(resolver.Default())
+	resolver.SetDefault(r)
+
+	message := filepath.Join(t.TempDir(), "order.eml")
+	headers := "Received: from web01.example.com (web01.example.com [192.0.2.13]) by mx.recipient.net with ESMTP\r\n" +
+		"Subject: Your order\r\n\r\nThanks\r\n"
+	if err := os.WriteFile(message, []byte(headers), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := core.DefaultConfig()
+	config.Quiet = true
+	config.EMLFile = message
+
+	src, err := New("mail", config, nil)
+	if err != nil {
+		t.Fatalf("New(mail) error: %v", err)
+	}
+	records, err := src.Search(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, record := range records {
+		got[record.IP] = record.Hostname + " " + record.Via
+	}
+	want := map[string]string{
+		"192.0.2.10": "example.com SPF ip4:192.0.2.10",
+		"192.0.2.11": "_spf.example.com SPF a:relay.example.com",
+		"192.0.2.12": "mx.reports.example.com DMARC report MX",
+		"192.0.2.13": "web01.example.com Received header",
+		"203.0.0.0":  "_spf.example.com SPF ip4:203.0.113.0/16",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+	// The /16 is too large to list address by address and keeps its prefix
+	for _, record := range records {
+		want := ""
+		if record.IP == "203.0.0.0" {
+			want = "203.0.0.0/16"
+		}
+		if record.Network != want {
+			t.Errorf("%s network = %q, want %q", record.IP, record.Network, want)
+		}
+	}
+
+	config.EMLFile = filepath.Join(t.TempDir(), "missing.eml")
+	if _, err := src.Search(context.Background(), "example.com"); err == nil {
+		t.Error("Search() with a missing .eml file should fail")
+	}
+}
@@ -0,0 +1,453 @@
+package passive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/api"
+	"github.com/jhaxce/origindive/v3/pkg/passive/censys"
+	"github.com/jhaxce/origindive/v3/pkg/passive/ct"
+	passivedns "github.com/jhaxce/origindive/v3/pkg/passive/dns"
+	"github.com/jhaxce/origindive/v3/pkg/passive/dnsdumpster"
+	"github.com/jhaxce/origindive/v3/pkg/passive/eml"
+	"github.com/jhaxce/origindive/v3/pkg/passive/securitytrails"
+	"github.com/jhaxce/origindive/v3/pkg/passive/shodan"
+	"github.com/jhaxce/origindive/v3/pkg/passive/subdomain"
+	"github.com/jhaxce/origindive/v3/pkg/passive/viewdns"
+	"github.com/jhaxce/origindive/v3/pkg/passive/virustotal"
+	"github.com/jhaxce/origindive/v3/pkg/passive/wayback"
+	"github.com/jhaxce/origindive/v3/pkg/passive/zoomeye"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+)
+
+// Built-in sources
+func init() {
+	Register("ct", func(config *core.Config, keys *api.Manager) Source {
+		// Certificate Transparency logs (free): crt.sh, or the logs themselves when it fails
+		return &ctSource{config: config}
+	})
+	Register("dns", func(config *core.Config, keys *api.Manager) Source {
+		// Subdomain enumeration and MX records (free via public resolvers)
+		return &dnsSource{config: config}
+	})
+	Register("mail", func(config *core.Config, keys *api.Manager) Source {
+		// SPF, DMARC and DKIM records and the headers of a supplied .eml file (free)
+		return &mailSource{config: config}
+	})
+	Register("shodan", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("shodan", config, keys, config.ShodanKeys, "Shodan API keys", "Shodan search failed",
+			withPivots(shodan.SearchWithKey, shodan.SearchPivotWithKey))
+	})
+	Register("censys", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("censys", config, keys, config.CensysTokens, "Censys PAT tokens", "Censys search failed",
+			withPivots(
+				func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+					ips, err := censys.SearchWithToken(ctx, domain, key, config.CensysOrgID, t)
+					if err != nil {
+						return nil, err
+					}
+					// Hosts presenting the domain's certificates; only a rate limit fails the attempt
+					certIPs, err := censys.SearchCertificatesWithToken(ctx, domain, key, config.CensysOrgID, t)
+					if api.IsRateLimitError(err) {
+						return nil, err
+					}
+					return append(ips, certIPs...), nil
+				},
+				func(ctx context.Context, pivot core.Pivot, key string, t time.Duration) ([]core.PassiveIP, error) {
+					return censys.SearchPivotWithToken(ctx, pivot, key, config.CensysOrgID, t)
+				}))
+	})
+	Register("securitytrails", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("securitytrails", config, keys, config.SecurityTrailsKeys, "SecurityTrails API keys", "SecurityTrails search failed",
+			func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+				return securitytrails.SearchWithKey(ctx, domain, key, t)
+			})
+	})
+	Register("zoomeye", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("zoomeye", config, keys, config.ZoomEyeKeys, "ZoomEye API keys", "ZoomEye search failed",
+			withPivots(zoomeye.SearchWithKey, zoomeye.SearchPivotWithKey))
+	})
+	Register("wayback", func(config *core.Config, keys *api.Manager) Source {
+		// Wayback Machine is free - no API key needed
+		return searchFunc("wayback", config, "Wayback Machine search failed", func(ctx context.Context, domain string, t time.Duration) ([]core.PassiveIP, error) {
+			return wayback.SearchSubdomains(ctx, domain, t)
+		})
+	})
+	Register("virustotal", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("virustotal", config, keys, config.VirusTotalKeys, "VirusTotal API keys", "VirusTotal search failed",
+			func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+				ips, err := virustotal.SearchWithKey(ctx, domain, key, t)
+				if err != nil {
+					return nil, err
+				}
+				// Dated resolutions of the domain itself; only a rate limit fails the attempt
+				resolved, err := virustotal.SearchResolutionsWithKey(ctx, domain, key, t)
+				if api.IsRateLimitError(err) {
+					return nil, err
+				}
+				return append(ips, resolved...), nil
+			})
+	})
+	Register("viewdns", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("viewdns", config, keys, config.ViewDNSKeys, "ViewDNS API keys", "ViewDNS search failed",
+			func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+				return viewdns.SearchReverseIPWithKey(ctx, domain, key, t)
+			})
+	})
+	Register("dnsdumpster", func(config *core.Config, keys *api.Manager) Source {
+		return keyedSearchFunc("dnsdumpster", config, keys, config.DNSDumpsterKeys, "DNSDumpster API keys", "DNSDumpster search failed",
+			func(ctx context.Context, domain, key string, t time.Duration) ([]core.PassiveIP, error) {
+				return dnsdumpster.SearchWithKey(ctx, domain, key, t)
+			})
+	})
+}
+
+// funcSource adapts a package-level search function to the Source interface
+type funcSource struct {
+	name   string
+	search func(ctx context.Context, domain string) ([]core.PassiveIP, error)
+}
+
+// Name returns the source identifier
+func (s *funcSource) Name() string { return s.name }
+
+// Search runs the wrapped search function
+func (s *funcSource) Search(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+	return s.search(ctx, domain)
+}
+
+// searchFunc wraps a search that takes a per-request timeout, bounding it by the passive timeout
+func searchFunc(name string, config *core.Config, failure string,
+	search func(ctx context.Context, domain string, timeout time.Duration) ([]core.PassiveIP, error)) Source {
+	return &funcSource{name: name, search: func(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+		t := Timeout(config)
+		ctx, cancel := context.WithTimeout(ctx, t)
+		defer cancel()
+
+		ips, err := search(ctx, domain, t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", failure, err)
+		}
+		return ips, nil
+	}}
+}
+
+// keyedSearchFunc is searchFunc for sources that need API keys
+// Each attempt gets one key from the manager, which rotates past rate-limited keys
+func keyedSearchFunc(name string, config *core.Config, manager *api.Manager, keys []string, keyKind, failure string,
+	search func(ctx context.Context, domain, key string, timeout time.Duration) ([]core.PassiveIP, error)) Source {
+	return &funcSource{name: name, search: func(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no %s configured", keyKind)
+		}
+		manager.SetKeys(api.Source(name), keys)
+
+		t := Timeout(config)
+		ctx, cancel := context.WithTimeout(ctx, t)
+		defer cancel()
+
+		var ips []core.PassiveIP
+		err := manager.Execute(ctx, api.Source(name), func(ctx context.Context, key string) error {
+			var err error
+			ips, err = search(ctx, domain, key, t)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", failure, err)
+		}
+		return ips, nil
+	}}
+}
+
+// ctSource searches crt.sh and falls back to reading CT logs directly when it fails
+type ctSource struct {
+	config *core.Config
+}
+
+// Name returns the source identifier
+func (s *ctSource) Name() string { return "ct" }
+
+// Search queries crt.sh, then the newest entries of each configured RFC 6962 log
+func (s *ctSource) Search(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+	t := Timeout(s.config)
+	crtCtx, cancel := context.WithTimeout(ctx, t)
+	ips, err := ct.SearchCrtSh(crtCtx, domain, t)
+	cancel()
+	if err == nil {
+		return ips, nil
+	}
+
+	logs := s.config.CTLogs
+	if len(logs) == 0 {
+		logs = ct.DefaultLogs
+	}
+	if !s.config.Quiet {
+		fmt.Fprintf(statusOutput(s.config), "  → crt.sh unavailable, reading %d CT logs directly...\n", len(logs))
+	}
+	logCtx, cancel := context.WithTimeout(ctx, t*4)
+	defer cancel()
+	window := s.config.CTWindow
+	if window == 0 {
+		window = ct.DefaultWindow
+	}
+	ips, logErr := ct.SearchLogs(logCtx, domain, logs, window, t)
+	if logErr != nil {
+		return nil, fmt.Errorf("CT search failed: %w (direct log fallback: %v)", err, logErr)
+	}
+	return ips, nil
+}
+
+// dnsSource brute forces subdomains (built-in list or wordlist, optionally permuted)
+// and resolves the MX records of the domain
+type dnsSource struct {
+	config *core.Config
+}
+
+// Name returns the source identifier
+func (s *dnsSource) Name() string { return "dns" }
+
+// Search resolves subdomains and MX hosts, one record per hostname and IP
+func (s *dnsSource) Search(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+	var records []core.PassiveIP
+	seen := make(map[string]bool)
+	add := func(ip, hostname, via string, metadata map[string]interface{}) {
+		if seen[hostname+"|"+ip] {
+			return
+		}
+		seen[hostname+"|"+ip] = true
+		now := time.Now()
+		records = append(records, core.PassiveIP{
+			IP:        ip,
+			Source:    "dns",
+			Hostname:  hostname,
+			Via:       via,
+			FirstSeen: now,
+			LastSeen:  now,
+			Metadata:  metadata,
+		})
+	}
+
+	// Phase 1: Subdomain enumeration (names resolving only to a wildcard record are dropped)
+	s.progress("Enumerating subdomains...")
+	t := Timeout(s.config)
+	subScanner := subdomain.NewScanner(domain, 20, t)
+	if wildcard := subScanner.DetectWildcard(ctx); len(wildcard) > 0 {
+		s.progress(fmt.Sprintf("Wildcard DNS detected (%s), ignoring names that only resolve to it", strings.Join(wildcard, ", ")))
+	}
+
+	var subResults map[string][]string
+	var err error
+	if s.config.Wordlist != "" {
+		// Wordlists can be large, so they are not bounded by the source timeout
+		s.progress("Brute forcing subdomains from " + s.config.Wordlist + "...")
+		subResults, err = subScanner.ScanWordlist(ctx, s.config.Wordlist)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		scanCtx, cancel := context.WithTimeout(ctx, t*4)
+		subResults, err = subScanner.Scan(scanCtx, subdomain.CommonSubdomains)
+		cancel()
+	}
+	if err == nil && s.config.Permutations && len(subResults) > 0 {
+		discovered := make([]string, 0, len(subResults))
+		for sub := range subResults {
+			discovered = append(discovered, sub)
+		}
+		sort.Strings(discovered)
+		permutations := subdomain.Permutations(discovered)
+		s.progress(fmt.Sprintf("Resolving %d permutations of %d discovered subdomains...", len(permutations), len(discovered)))
+		permCtx, cancel := context.WithTimeout(ctx, t*4)
+		subResults, err = subScanner.Scan(permCtx, permutations)
+		cancel()
+	}
+	if filtered := subScanner.Filtered(); filtered > 0 {
+		s.progress(fmt.Sprintf("Ignored %d names answered by the wildcard record", filtered))
+	}
+	if err == nil && len(subResults) > 0 {
+		subs := make([]string, 0, len(subResults))
+		for sub := range subResults {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+
+		for _, sub := range subs {
+			for _, ip := range subResults[sub] {
+				add(ip, sub+"."+domain, "DNS subdomain", nil)
+			}
+		}
+		s.progress(fmt.Sprintf("Found %d IPs from %d subdomains", len(subScanner.GetAllIPs()), len(subResults)))
+	}
+
+	// Phase 2: MX record analysis
+	s.progress("Analyzing MX records...")
+	mxCtx, cancel := context.WithTimeout(ctx, t*4)
+	defer cancel()
+	mxRecords, err := passivedns.LookupMX(mxCtx, domain, t)
+	if err == nil && len(mxRecords) > 0 {
+		for _, mx := range mxRecords {
+			for _, ip := range mx.IPs {
+				add(ip, strings.TrimSuffix(mx.Host, "."), "MX record", map[string]interface{}{"mx_priority": mx.Priority})
+			}
+		}
+		s.progress(fmt.Sprintf("Found %d IPs from %d MX records", len(passivedns.GetAllMXIPs(mxRecords)), len(mxRecords)))
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no IPs discovered from DNS enumeration")
+	}
+
+	return records, nil
+}
+
+// progress prints a sub-step of the DNS source unless running quietly
+func (s *dnsSource) progress(msg string) {
+	if !s.config.Quiet {
+		fmt.Fprintf(statusOutput(s.config), "  → %s\n", msg)
+	}
+}
+
+// mailSource analyzes the domain's mail infrastructure beyond MX: the servers its
+// SPF policy authorizes, DMARC report mailboxes, DKIM selectors and the relays in
+// the Received headers of a mail sent by the target
+type mailSource struct {
+	config *core.Config
+}
+
+// Name returns the source identifier
+func (s *mailSource) Name() string { return "mail" }
+
+// Search expands the SPF policy, checks DMARC and DKIM and reads the configured
+// .eml file. Only addresses the domain's own SPF records authorize are reported;
+// third-party includes only name the mail providers it uses.
+func (s *mailSource) Search(ctx context.Context, domain string) ([]core.PassiveIP, error) {
+	var records []core.PassiveIP
+	seen := make(map[string]bool)
+	add := func(ip, hostname, via, network string) {
+		if seen[hostname+"|"+ip] {
+			return
+		}
+		seen[hostname+"|"+ip] = true
+		now := time.Now()
+		records = append(records, core.PassiveIP{
+			IP:        ip,
+			Source:    "mail",
+			Hostname:  hostname,
+			Via:       via,
+			FirstSeen: now,
+			LastSeen:  now,
+			Network:   network,
+		})
+	}
+	t := Timeout(s.config)
+
+	// Phase 1: SPF, following include: and redirect= chains
+	s.progress("Expanding SPF record...")
+	if spf, err := passivedns.LookupSPF(ctx, domain, t); err != nil {
+		s.progress(err.Error())
+	} else {
+		for _, addr := range spf.Addresses {
+			if inDomain(addr.Domain, domain) {
+				add(addr.IP, addr.Domain, "SPF "+addr.Mechanism, "")
+			}
+		}
+		// Networks too large to list address by address are reported once, at their
+		// network address, with the prefix in Network for auto mode to scan
+		for _, network := range spf.Networks {
+			if prefix, err := netip.ParsePrefix(network.IP); err == nil && inDomain(network.Domain, domain) {
+				add(prefix.Addr().String(), network.Domain, "SPF "+network.Mechanism, network.IP)
+			}
+		}
+		var providers []string
+		for _, include := range spf.Includes {
+			if !inDomain(include, domain) {
+				providers = append(providers, include)
+			}
+		}
+		if len(providers) > 0 {
+			s.progress("SPF includes third-party senders: " + strings.Join(providers, ", "))
+		}
+		if spf.LimitReached {
+			s.progress("SPF DNS lookup limit reached, remaining terms skipped")
+		}
+		s.progress(fmt.Sprintf("Found %d IPs in the SPF policy (%d DNS lookups)", len(records), spf.Lookups))
+	}
+
+	// Phase 2: DMARC report mailboxes hosted under the domain
+	if dmarc, err := passivedns.LookupDMARC(ctx, domain, t); err != nil {
+		s.progress(err.Error())
+	} else {
+		s.progress("DMARC policy: p=" + dmarc.Policy)
+		for _, host := range dmarc.Reports {
+			if !inDomain(host, domain) {
+				continue
+			}
+			if mxRecords, err := passivedns.LookupMX(ctx, host, t); err == nil {
+				for _, mx := range mxRecords {
+					for _, ip := range mx.IPs {
+						add(ip, strings.TrimSuffix(mx.Host, "."), "DMARC report MX", "")
+					}
+				}
+			} else if ips, err := resolver.Default().LookupHost(ctx, host); err == nil {
+				for _, ip := range ips {
+					add(ip, host, "DMARC report host", "")
+				}
+			}
+		}
+	}
+
+	// Phase 3: DKIM selectors, which name the services sending as the domain
+	if keys := passivedns.LookupDKIM(ctx, domain, passivedns.DKIMSelectors, t); len(keys) > 0 {
+		selectors := make([]string, 0, len(keys))
+		for _, key := range keys {
+			selectors = append(selectors, key.Selector)
+		}
+		s.progress("DKIM selectors: " + strings.Join(selectors, ", "))
+	}
+
+	// Phase 4: relays in the headers of a mail sent by the target
+	if s.config.EMLFile != "" {
+		hops, err := eml.ParseFile(s.config.EMLFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, hop := range hops {
+			add(hop.IP, hop.Host, hop.Header+" header", "")
+		}
+		s.progress(fmt.Sprintf("Found %d IPs in the headers of %s", len(hops), s.config.EMLFile))
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no IPs discovered from mail records")
+	}
+
+	return records, nil
+}
+
+// progress prints a sub-step of the mail source unless running quietly
+func (s *mailSource) progress(msg string) {
+	if !s.config.Quiet {
+		fmt.Fprintf(statusOutput(s.config), "  → %s\n", msg)
+	}
+}
+
+// inDomain reports whether name is domain or a name under it
+func inDomain(name, domain string) bool {
+	name, domain = strings.ToLower(strings.TrimSuffix(name, ".")), strings.ToLower(domain)
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+// statusOutput returns where sources print their progress lines
+func statusOutput(config *core.Config) io.Writer {
+	if config.Status != nil {
+		return config.Status
+	}
+	return os.Stdout
+}
@@ -0,0 +1,66 @@
+package subdomain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PermutationWords are the environment and role words combined with discovered names
+var PermutationWords = []string{
+	"dev", "staging", "stage", "test", "qa", "uat", "prod",
+	"old", "new", "beta", "internal", "origin", "backend", "direct",
+}
+
+// maxPermutationNumber is the highest number appended to a discovered name (api1..api3)
+const maxPermutationNumber = 3
+
+// Permutations derives candidate names from discovered subdomains given relative to
+// the domain (e.g. "api" or "api.eu"). The first label is varied: numbered (api2),
+// hyphenated with a PermutationWord on either side (dev-api, api-staging) and as a
+// new third level (dev.api). Discovered names are not returned.
+func Permutations(discovered []string) []string {
+	known := make(map[string]bool, len(discovered))
+	for _, name := range discovered {
+		known[strings.ToLower(name)] = true
+	}
+
+	var candidates []string
+	add := func(name string) {
+		if !known[name] {
+			known[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, name := range discovered {
+		name = strings.ToLower(name)
+		label, rest := name, ""
+		if i := strings.Index(name, "."); i >= 0 {
+			label, rest = name[:i], name[i:]
+		}
+		if label == "" || label == "*" {
+			continue
+		}
+
+		// api2 -> api, api1, api3
+		base := strings.TrimRight(label, "0123456789")
+		if base == "" {
+			base = label
+		} else if base != label {
+			add(strings.TrimSuffix(base, "-") + rest)
+		}
+		for n := 1; n <= maxPermutationNumber; n++ {
+			add(base + strconv.Itoa(n) + rest)
+		}
+
+		for _, word := range PermutationWords {
+			if word == label {
+				continue
+			}
+			add(word + "-" + label + rest)
+			add(label + "-" + word + rest)
+			add(word + "." + name)
+		}
+	}
+	return candidates
+}
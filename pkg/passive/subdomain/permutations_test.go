@@ -0,0 +1,26 @@
+package subdomain
+
+import "testing"
+
+func TestPermutations(t *testing.T) {
+	candidates := Permutations([]string{"api", "API2.eu", "dev"})
+
+	got := make(map[string]bool)
+	for _, name := range candidates {
+		if got[name] {
+			t.Errorf("Permutations() returned %q twice", name)
+		}
+		got[name] = true
+	}
+
+	for _, want := range []string{"api1", "api3", "dev-api", "api-staging", "dev.api", "api.eu", "api1.eu", "qa-api2.eu", "api2-origin.eu", "old.api2.eu", "dev-staging"} {
+		if !got[want] {
+			t.Errorf("Permutations() missing %q", want)
+		}
+	}
+	for _, discovered := range []string{"api", "api2.eu", "dev", "dev-dev"} {
+		if got[discovered] {
+			t.Errorf("Permutations() returned %q", discovered)
+		}
+	}
+}
@@ -2,11 +2,19 @@
 package subdomain
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"net"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // CommonSubdomains is a list of frequently used subdomains
@@ -48,14 +56,19 @@ var CommonSubdomains = []string{
 	"www", "web", "app", "mobile", "m", "wap", "public", "internal",
 }
 
+// wildcardProbes is the number of random names resolved to detect a wildcard record
+const wildcardProbes = 3
+
 // Scanner performs subdomain enumeration
 type Scanner struct {
 	domain     string
 	workers    int
 	timeout    time.Duration
-	dnsServers []string
+	resolver   *resolver.Resolver
 	mu         sync.Mutex
-	discovered map[string][]string // subdomain -> IPs
+	discovered map[string][]string        // subdomain -> IPs
+	wildcards  map[string]map[string]bool // zone -> IPs its wildcard record answers with
+	filtered   int                        // names dropped as wildcard answers
 }
 
 // NewScanner creates a new subdomain scanner
@@ -64,11 +77,17 @@ func NewScanner(domain string, workers int, timeout time.Duration) *Scanner {
 		domain:     domain,
 		workers:    workers,
 		timeout:    timeout,
-		dnsServers: []string{"8.8.8.8:53", "1.1.1.1:53"}, // Google, Cloudflare DNS
+		resolver:   resolver.Default(),
 		discovered: make(map[string][]string),
+		wildcards:  make(map[string]map[string]bool),
 	}
 }
 
+// SetResolver makes the scanner resolve names through r instead of the default resolver
+func (s *Scanner) SetResolver(r *resolver.Resolver) {
+	s.resolver = r
+}
+
 // Result represents a subdomain scan result
 type Result struct {
 	Subdomain string
@@ -82,9 +101,55 @@ func (s *Scanner) Scan(ctx context.Context, subdomains []string) (map[string][]s
 		subdomains = CommonSubdomains
 	}
 
-	// Create work channel
-	jobs := make(chan string, len(subdomains))
-	results := make(chan Result, len(subdomains))
+	return s.run(ctx, func(jobs chan<- string) error {
+		for _, subdomain := range subdomains {
+			select {
+			case jobs <- subdomain:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// ScanWordlist resolves the subdomains listed in a wordlist file, one per line
+func (s *Scanner) ScanWordlist(ctx context.Context, path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer file.Close()
+
+	return s.ScanReader(ctx, file)
+}
+
+// ScanReader resolves subdomains read one per line from r as they are read, so
+// wordlists of any size are never held in memory. Blank lines and # comments are
+// skipped and names may be given relative to the domain or fully qualified.
+func (s *Scanner) ScanReader(ctx context.Context, r io.Reader) (map[string][]string, error) {
+	return s.run(ctx, func(jobs chan<- string) error {
+		lines := bufio.NewScanner(r)
+		for lines.Scan() {
+			subdomain := s.relative(lines.Text())
+			if subdomain == "" {
+				continue
+			}
+			select {
+			case jobs <- subdomain:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return lines.Err()
+	})
+}
+
+// run resolves the subdomains fed to jobs with the scanner's workers and collects
+// the names that resolve to something other than a wildcard record
+func (s *Scanner) run(ctx context.Context, feed func(jobs chan<- string) error) (map[string][]string, error) {
+	jobs := make(chan string, s.workers)
+	results := make(chan Result, s.workers)
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -97,7 +162,7 @@ func (s *Scanner) Scan(ctx context.Context, subdomains []string) (map[string][]s
 				case <-ctx.Done():
 					return
 				default:
-					ips, err := s.resolveSubdomain(subdomain)
+					ips, err := s.resolveSubdomain(ctx, subdomain)
 					results <- Result{
 						Subdomain: subdomain,
 						IPs:       ips,
@@ -109,10 +174,11 @@ func (s *Scanner) Scan(ctx context.Context, subdomains []string) (map[string][]s
 	}
 
 	// Send jobs
-	for _, subdomain := range subdomains {
-		jobs <- subdomain
-	}
-	close(jobs)
+	feedErr := make(chan error, 1)
+	go func() {
+		feedErr <- feed(jobs)
+		close(jobs)
+	}()
 
 	// Wait and close results
 	go func() {
@@ -122,43 +188,116 @@ func (s *Scanner) Scan(ctx context.Context, subdomains []string) (map[string][]s
 
 	// Collect results
 	for result := range results {
-		if result.Error == nil && len(result.IPs) > 0 {
+		if result.Error != nil || len(result.IPs) == 0 {
+			continue
+		}
+		if s.isWildcard(ctx, result) {
 			s.mu.Lock()
-			s.discovered[result.Subdomain] = result.IPs
+			s.filtered++
 			s.mu.Unlock()
+			continue
 		}
+		s.mu.Lock()
+		s.discovered[result.Subdomain] = result.IPs
+		s.mu.Unlock()
 	}
 
-	return s.discovered, nil
+	// Callers get a copy: the scanner keeps accumulating when it is reused
+	return s.GetResults(), <-feedErr
 }
 
 // resolveSubdomain resolves a subdomain to IP addresses
-func (s *Scanner) resolveSubdomain(subdomain string) ([]string, error) {
+func (s *Scanner) resolveSubdomain(ctx context.Context, subdomain string) ([]string, error) {
 	target := fmt.Sprintf("%s.%s", subdomain, s.domain)
 
-	// Create custom resolver with timeout
-	resolver := &net.Resolver{
-		PreferGo: true,
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Resolve A and AAAA records
+	return s.resolver.LookupHost(ctx, target)
+}
+
+// relative normalizes a wordlist entry to a name relative to the domain, or ""
+// for blank lines, comments and the domain itself
+func (s *Scanner) relative(word string) string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || strings.HasPrefix(word, "#") {
+		return ""
+	}
+	word = strings.TrimSuffix(word, ".")
+	word = strings.TrimSuffix(word, "."+strings.ToLower(s.domain))
+	if word == strings.ToLower(s.domain) {
+		return ""
 	}
+	return word
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
+// DetectWildcard returns the IPs the domain's wildcard record answers with, sorted,
+// or nil if it has none. Names resolving only to these are not reported.
+func (s *Scanner) DetectWildcard(ctx context.Context) []string {
+	var ips []string
+	for ip := range s.wildcardIPs(ctx, s.domain) {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
 
-	// Resolve A records
-	ips, err := resolver.LookupHost(ctx, target)
-	if err != nil {
-		return nil, err
+// Filtered returns the number of names dropped because they only resolved to a
+// wildcard record
+func (s *Scanner) Filtered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filtered
+}
+
+// isWildcard reports whether every IP of a result is an answer of the wildcard
+// record of the zone the name is in
+func (s *Scanner) isWildcard(ctx context.Context, result Result) bool {
+	zone := s.domain
+	if i := strings.Index(result.Subdomain, "."); i >= 0 {
+		zone = result.Subdomain[i+1:] + "." + s.domain
+	}
+	wildcard := s.wildcardIPs(ctx, zone)
+	if len(wildcard) == 0 {
+		return false
+	}
+	for _, ip := range result.IPs {
+		if !wildcard[ip] {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcardIPs resolves random names under zone, caching the union of their IPs
+func (s *Scanner) wildcardIPs(ctx context.Context, zone string) map[string]bool {
+	s.mu.Lock()
+	ips, ok := s.wildcards[zone]
+	s.mu.Unlock()
+	if ok {
+		return ips
 	}
 
-	// Filter out IPv6 for now (focus on IPv4)
-	var ipv4s []string
-	for _, ip := range ips {
-		if net.ParseIP(ip).To4() != nil {
-			ipv4s = append(ipv4s, ip)
+	ips = make(map[string]bool)
+	for i := 0; i < wildcardProbes; i++ {
+		label := make([]byte, 8)
+		rand.Read(label)
+		lookupCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		answers, err := s.resolver.LookupHost(lookupCtx, hex.EncodeToString(label)+"."+zone)
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, ip := range answers {
+			ips[ip] = true
 		}
 	}
 
-	return ipv4s, nil
+	s.mu.Lock()
+	s.wildcards[zone] = ips
+	s.mu.Unlock()
+	return ips
 }
 
 // GetAllIPs returns all unique IPs from discovered subdomains
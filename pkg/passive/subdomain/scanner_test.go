@@ -2,8 +2,15 @@ package subdomain
 
 import (
 	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
 )
 
 func TestNewScanner(t *testing.T) {
@@ -21,8 +28,8 @@ func TestNewScanner(t *testing.T) {
 	if scanner.timeout != 3*time.Second {
 		t.Errorf("timeout = %v, want 3s", scanner.timeout)
 	}
-	if len(scanner.dnsServers) != 2 {
-		t.Errorf("dnsServers count = %d, want 2", len(scanner.dnsServers))
+	if scanner.resolver != resolver.Default() {
+		t.Error("resolver should be the default resolver")
 	}
 	if scanner.discovered == nil {
 		t.Error("discovered map should be initialized")
@@ -212,7 +219,7 @@ func TestCommonSubdomains_NotEmpty(t *testing.T) {
 func TestScanner_ResolveSubdomain_ValidDomain(t *testing.T) {
 	scanner := NewScanner("google.com", 5, 5*time.Second)
 
-	ips, err := scanner.resolveSubdomain("www")
+	ips, err := scanner.resolveSubdomain(context.Background(), "www")
 	if err != nil {
 		t.Logf("Resolution failed (expected in test env): %v", err)
 		return
@@ -233,7 +240,7 @@ func TestScanner_ResolveSubdomain_ValidDomain(t *testing.T) {
 func TestScanner_ResolveSubdomain_InvalidDomain(t *testing.T) {
 	scanner := NewScanner("example.com", 5, 2*time.Second)
 
-	ips, err := scanner.resolveSubdomain("nonexistent99999")
+	ips, err := scanner.resolveSubdomain(context.Background(), "nonexistent99999")
 	if err == nil {
 		t.Log("Resolution succeeded unexpectedly")
 	}
@@ -246,7 +253,7 @@ func TestScanner_ResolveSubdomain_InvalidDomain(t *testing.T) {
 func TestScanner_ResolveSubdomain_Timeout(t *testing.T) {
 	scanner := NewScanner("example.com", 5, 1*time.Nanosecond)
 
-	_, err := scanner.resolveSubdomain("www")
+	_, err := scanner.resolveSubdomain(context.Background(), "www")
 	if err == nil {
 		t.Log("Resolution succeeded despite timeout (might be cached)")
 	}
@@ -317,3 +324,126 @@ func TestScanner_MultipleScanCalls(t *testing.T) {
 		t.Logf("Accumulated results: %d subdomains", len(results2))
 	}
 }
+
+// testZone serves example.com with a wildcard record and a wildcard under dev
+func testZone(t *testing.T) *Scanner {
+	t.Helper()
+	zone := resolvertest.NewServer()
+	t.Cleanup(zone.Close)
+	zone.AddA("*.example.com", "192.0.2.100")
+	zone.AddA("www.example.com", "192.0.2.1")
+	zone.AddA("api.example.com", "192.0.2.2", "192.0.2.100")
+	zone.AddA("*.dev.example.com", "192.0.2.200")
+	zone.AddA("origin.dev.example.com", "192.0.2.3")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	scanner := NewScanner("example.com", 4, time.Second)
+	scanner.SetResolver(r)
+	return scanner
+}
+
+func TestScanner_Wildcard(t *testing.T) {
+	scanner := testZone(t)
+
+	if got := scanner.DetectWildcard(context.Background()); !reflect.DeepEqual(got, []string{"192.0.2.100"}) {
+		t.Errorf("DetectWildcard() = %v, want [192.0.2.100]", got)
+	}
+
+	results, err := scanner.Scan(context.Background(), []string{"www", "api", "nothing-here", "x.dev", "origin.dev"})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	want := map[string][]string{
+		"www":        {"192.0.2.1"},
+		"api":        {"192.0.2.2", "192.0.2.100"}, // Not only the wildcard IP
+		"origin.dev": {"192.0.2.3"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Scan() = %v, want %v", results, want)
+	}
+	if scanner.Filtered() != 2 {
+		t.Errorf("Filtered() = %d, want 2 wildcard answers", scanner.Filtered())
+	}
+}
+
+func TestScanner_ScanReader(t *testing.T) {
+	scanner := testZone(t)
+
+	wordlist := "# comment\n\nWWW\norigin.dev.example.com.\nexample.com\n  missing  \n"
+	results, err := scanner.ScanReader(context.Background(), strings.NewReader(wordlist))
+	if err != nil {
+		t.Fatalf("ScanReader() error: %v", err)
+	}
+	want := map[string][]string{"www": {"192.0.2.1"}, "origin.dev": {"192.0.2.3"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("ScanReader() = %v, want %v", results, want)
+	}
+
+	if _, err := scanner.ScanWordlist(context.Background(), filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ScanWordlist() of a missing file should fail")
+	}
+}
+
+func TestScanner_Scan_ReturnsCopy(t *testing.T) {
+	scanner := testZone(t)
+
+	first, err := scanner.Scan(context.Background(), []string{"www"})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	second, err := scanner.Scan(context.Background(), []string{"origin.dev"})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	// A reused scanner accumulates, but earlier results are not modified
+	if want := map[string][]string{"www": {"192.0.2.1"}}; !reflect.DeepEqual(first, want) {
+		t.Errorf("first Scan() = %v after a second run, want %v", first, want)
+	}
+	if len(second) != 2 {
+		t.Errorf("second Scan() = %v, want both runs' names", second)
+	}
+
+	delete(second, "www")
+	if len(scanner.GetResults()) != 2 {
+		t.Error("Scan() should return a copy, not the scanner's map")
+	}
+}
+
+func TestScanner_Scan_IPv6(t *testing.T) {
+	zone := resolvertest.NewServer()
+	t.Cleanup(zone.Close)
+	zone.AddAAAA("*.example.com", "2001:db8::100")
+	zone.AddA("www.example.com", "192.0.2.1")
+	zone.AddAAAA("www.example.com", "2001:db8::1")
+	zone.AddAAAA("v6.example.com", "2001:db8::2")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	scanner := NewScanner("example.com", 4, time.Second)
+	scanner.SetResolver(r)
+	scanner.DetectWildcard(context.Background())
+
+	results, err := scanner.Scan(context.Background(), []string{"www", "v6", "nothing-here"})
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	for _, ips := range results {
+		sort.Strings(ips)
+	}
+	want := map[string][]string{
+		"www": {"192.0.2.1", "2001:db8::1"},
+		"v6":  {"2001:db8::2"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Scan() = %v, want %v", results, want)
+	}
+	if scanner.Filtered() != 1 {
+		t.Errorf("Filtered() = %d, want the AAAA wildcard answer dropped", scanner.Filtered())
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // ViewDNSResponse represents the ViewDNS API response for reverse IP lookup
@@ -41,34 +44,15 @@ type ViewDNSDomain struct {
 var apiBaseURL = "https://api.viewdns.info/reverseip/"
 
 // SearchReverseIP queries ViewDNS reverse IP lookup for domains on the same server
-func SearchReverseIP(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+// Each IP records the co-hosted domain that resolved to it
+func SearchReverseIP(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no ViewDNS API keys provided")
+		return nil, fmt.Errorf("no ViewDNS API keys provided")
 	}
 
-	// First resolve the domain to get its current IP
-	resolver := &net.Resolver{}
-	addrs, err := resolver.LookupHost(ctx, domain)
+	targetIP, err := resolveTargetIP(ctx, domain)
 	if err != nil {
-		return []string{}, fmt.Errorf("failed to resolve domain: %w", err)
-	}
-
-	if len(addrs) == 0 {
-		return []string{}, fmt.Errorf("no IP addresses found for domain")
-	}
-
-	// Use the first IPv4 address
-	var targetIP string
-	for _, addr := range addrs {
-		ip := net.ParseIP(addr)
-		if ip != nil && ip.To4() != nil {
-			targetIP = addr
-			break
-		}
-	}
-
-	if targetIP == "" {
-		return []string{}, fmt.Errorf("no IPv4 address found for domain")
+		return nil, err
 	}
 
 	// Try each API key until one works
@@ -91,18 +75,47 @@ func SearchReverseIP(ctx context.Context, domain string, apiKeys []string, timeo
 		}
 
 		// For other errors, return immediately
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
+}
+
+// SearchReverseIPWithKey performs the reverse IP lookup for domain with a single API key
+func SearchReverseIPWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	targetIP, err := resolveTargetIP(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	return reverseIPWithKey(ctx, targetIP, apiKey, timeout)
+}
+
+// resolveTargetIP returns the address the domain currently resolves to, preferring
+// IPv4 (where most co-hosting data is) over IPv6 for IPv6-only domains
+func resolveTargetIP(ctx context.Context, domain string) (string, error) {
+	addrs, err := resolver.Default().LookupHost(ctx, domain)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve domain: %w", err)
+	}
+
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no IP addresses found for domain")
+	}
+
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+			return addr, nil
+		}
+	}
+	return addrs[0], nil
 }
 
 // reverseIPWithKey performs reverse IP lookup with a single API key
-func reverseIPWithKey(ctx context.Context, ipAddr, apiKey string, timeout time.Duration) ([]string, error) {
+func reverseIPWithKey(ctx context.Context, ipAddr, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
 	apiURL := fmt.Sprintf("%s?host=%s&apikey=%s&output=json",
 		apiBaseURL, url.QueryEscape(ipAddr), url.QueryEscape(apiKey))
 
@@ -148,8 +161,8 @@ func reverseIPWithKey(ctx context.Context, ipAddr, apiKey string, timeout time.D
 	}
 
 	// Resolve discovered domains to IPs
-	ipSet := make(map[string]bool)
-	resolver := &net.Resolver{}
+	seen := make(map[string]bool)
+	records := make([]core.PassiveIP, 0)
 
 	for _, domainEntry := range vdnsResp.Response.Domains {
 		domainName := strings.TrimSpace(domainEntry.Name)
@@ -159,27 +172,33 @@ func reverseIPWithKey(ctx context.Context, ipAddr, apiKey string, timeout time.D
 
 		// Resolve with short timeout
 		resolveCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-		addrs, err := resolver.LookupHost(resolveCtx, domainName)
+		addrs, err := resolver.Default().LookupHost(resolveCtx, domainName)
 		cancel()
 
 		if err != nil {
 			continue // Skip failed resolutions
 		}
 
-		// Filter IPv4 only
+		now := time.Now()
 		for _, addr := range addrs {
-			ip := net.ParseIP(addr)
-			if ip != nil && ip.To4() != nil {
-				ipSet[addr] = true
+			if net.ParseIP(addr) == nil || seen[addr] {
+				continue
 			}
+			seen[addr] = true
+			records = append(records, core.PassiveIP{
+				IP:        addr,
+				Source:    "viewdns",
+				Hostname:  domainName,
+				Via:       "reverse IP neighbour",
+				FirstSeen: now,
+				LastSeen:  now,
+				Metadata: map[string]interface{}{
+					"reverse_ip_of": ipAddr,
+					"last_resolved": domainEntry.LastResolved,
+				},
+			})
 		}
 	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
-	}
-
-	return ips, nil
+	return records, nil
 }
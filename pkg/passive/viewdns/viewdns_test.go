@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
 )
 
 func TestSearchReverseIP_NoAPIKeys(t *testing.T) {
@@ -415,7 +420,15 @@ func TestReverseIPWithKey_DomainResolution(t *testing.T) {
 
 	// Should get at least localhost IP (127.0.0.1 or others)
 	if len(ips) == 0 {
-		t.Error("Expected at least 1 IP from localhost resolution")
+		t.Fatal("Expected at least 1 IP from localhost resolution")
+	}
+
+	// Each IP records the co-hosted domain that led to it
+	if ips[0].Hostname != "localhost" || ips[0].Source != "viewdns" {
+		t.Errorf("record = %+v, want viewdns record for localhost", ips[0])
+	}
+	if ips[0].Metadata["reverse_ip_of"] != "192.168.1.1" {
+		t.Errorf("reverse_ip_of = %v, want 192.168.1.1", ips[0].Metadata["reverse_ip_of"])
 	}
 }
 
@@ -439,15 +452,27 @@ func TestReverseIPWithKey_Timeout(t *testing.T) {
 	}
 }
 
-func TestReverseIPWithKey_IPv4FilteringOnly(t *testing.T) {
-	// Test that only IPv4 addresses are returned (IPv6 filtered out)
-	// This is tested via DomainResolution test with localhost (may return ::1)
+func TestReverseIPWithKey_IPv6(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("neighbour.example.com", "192.0.2.1")
+	zone.AddAAAA("neighbour.example.com", "2001:db8::1")
+	zone.AddAAAA("v6only.example.com", "2001:db8::2")
+
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("resolver.New() error: %v", err)
+	}
+	original := resolver.Default()
+	defer resolver.SetDefault(original)
+	resolver.SetDefault(r)
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := ViewDNSResponse{
-			Query: ViewDNSQuery{ToolType: 2, Host: "192.168.1.1"},
+			Query: ViewDNSQuery{ToolType: 2, Host: "192.0.2.1"},
 			Response: ViewDNSResults{
 				Domains: []ViewDNSDomain{
-					{Name: "localhost", LastResolved: "2024-01-01"},
+					{Name: "neighbour.example.com", LastResolved: "2024-01-01"},
 				},
 			},
 		}
@@ -461,17 +486,21 @@ func TestReverseIPWithKey_IPv4FilteringOnly(t *testing.T) {
 	apiBaseURL = server.URL + "/"
 	defer func() { apiBaseURL = oldURL }()
 
-	ctx := context.Background()
-	ips, err := reverseIPWithKey(ctx, "192.168.1.1", "test_key", 5*time.Second)
-
+	ips, err := reverseIPWithKey(context.Background(), "192.0.2.1", "test_key", 5*time.Second)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
+	var got []string
+	for _, ip := range ips {
+		got = append(got, ip.IP)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"192.0.2.1", "2001:db8::1"}) {
+		t.Errorf("reverseIPWithKey() IPs = %v, want both address families", got)
+	}
 
-	// Verify all IPs are IPv4
-	for _, ipStr := range ips {
-		if !strings.Contains(ipStr, ".") || strings.Contains(ipStr, ":") {
-			t.Errorf("Expected IPv4 address, got: %s", ipStr)
-		}
+	// IPv6-only domains are looked up by their IPv6 address
+	if target, err := resolveTargetIP(context.Background(), "v6only.example.com"); err != nil || target != "2001:db8::2" {
+		t.Errorf("resolveTargetIP() = %q, %v; want 2001:db8::2", target, err)
 	}
 }
@@ -10,6 +10,10 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/passive/history"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // VTSubdomainResponse represents the VirusTotal subdomains API response
@@ -28,7 +32,8 @@ type VTDomainData struct {
 
 // VTDomainAttributes contains domain metadata
 type VTDomainAttributes struct {
-	LastDNSRecords []VTDNSRecord `json:"last_dns_records"`
+	LastDNSRecords     []VTDNSRecord `json:"last_dns_records"`
+	LastDNSRecordsDate int64         `json:"last_dns_records_date"` // Unix time the records were observed
 }
 
 // VTDNSRecord represents a DNS record
@@ -37,6 +42,28 @@ type VTDNSRecord struct {
 	Value string `json:"value"`
 }
 
+// VTResolutionResponse represents the VirusTotal domain resolutions API response
+type VTResolutionResponse struct {
+	Data  []VTResolutionData `json:"data"`
+	Links VTLinks            `json:"links"`
+	Error VTError            `json:"error,omitempty"`
+}
+
+// VTResolutionData represents a single dated resolution of the domain
+type VTResolutionData struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Attributes VTResolutionAttributes `json:"attributes"`
+}
+
+// VTResolutionAttributes contains the resolved address and when it was seen
+type VTResolutionAttributes struct {
+	Date      int64  `json:"date"` // Unix time of the resolution
+	HostName  string `json:"host_name"`
+	IPAddress string `json:"ip_address"`
+	Resolver  string `json:"resolver"`
+}
+
 // VTLinks contains pagination links
 type VTLinks struct {
 	Self string `json:"self"`
@@ -50,9 +77,9 @@ type VTError struct {
 }
 
 // SearchSubdomains queries VirusTotal for subdomains and their DNS records
-func SearchSubdomains(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+func SearchSubdomains(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no VirusTotal API keys provided")
+		return nil, fmt.Errorf("no VirusTotal API keys provided")
 	}
 
 	// Try each API key until one works
@@ -63,7 +90,7 @@ func SearchSubdomains(ctx context.Context, domain string, apiKeys []string, time
 			continue
 		}
 
-		ips, err := searchWithKey(ctx, domain, apiKey, timeout)
+		ips, err := SearchWithKey(ctx, domain, apiKey, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -75,23 +102,162 @@ func SearchSubdomains(ctx context.Context, domain string, apiKeys []string, time
 		}
 
 		// For other errors, return immediately
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
 }
 
 // apiBaseURL is the VirusTotal API endpoint (can be overridden in tests)
 var apiBaseURL = "https://www.virustotal.com/api/v3/domains/"
 
-// searchWithKey performs the search with a single API key
-func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
-	url := fmt.Sprintf("%s%s/subdomains?limit=40", apiBaseURL, domain)
+// SearchWithKey performs the search with a single API key
+func SearchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	body, err := get(ctx, fmt.Sprintf("%s%s/subdomains?limit=40", apiBaseURL, domain), apiKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse successful response
+	var vtResp VTSubdomainResponse
+	if err := json.Unmarshal(body, &vtResp); err != nil {
+		return nil, fmt.Errorf("failed to parse VirusTotal response: %w", err)
+	}
+
+	// Check for API error in JSON (even with 200 OK)
+	if vtResp.Error.Message != "" {
+		return nil, fmt.Errorf("VirusTotal API error: %s", vtResp.Error.Message)
+	}
+
+	// Extract IPv4 addresses from DNS records, once per subdomain (first sighting wins)
+	seen := make(map[string]bool)
+	records := make([]core.PassiveIP, 0)
+	add := func(ip, hostname, via string, lastSeen time.Time) {
+		parsedIP := net.ParseIP(ip)
+		if parsedIP == nil || parsedIP.To4() == nil || seen[hostname+"|"+ip] {
+			return
+		}
+		seen[hostname+"|"+ip] = true
+		records = append(records, core.PassiveIP{
+			IP:        ip,
+			Source:    "virustotal",
+			Hostname:  hostname,
+			Via:       via,
+			FirstSeen: lastSeen,
+			LastSeen:  lastSeen,
+		})
+	}
+
+	for _, data := range vtResp.Data {
+		var observed time.Time
+		if data.Attributes.LastDNSRecordsDate > 0 {
+			observed = time.Unix(data.Attributes.LastDNSRecordsDate, 0)
+		}
+
+		for _, dnsRecord := range data.Attributes.LastDNSRecords {
+			// Only process A records (IPv4)
+			if strings.ToUpper(dnsRecord.Type) != "A" {
+				continue
+			}
+
+			ip := strings.TrimSpace(dnsRecord.Value)
+			if ip == "" {
+				continue
+			}
+			add(ip, data.ID, "VirusTotal DNS record", observed)
+		}
+
+		// Also resolve the subdomain itself
+		subdomain := data.ID
+		if subdomain != "" {
+			// Resolve with short timeout to avoid delays
+			resolveCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			addrs, err := resolver.Default().LookupHost(resolveCtx, subdomain)
+			cancel()
+
+			if err == nil {
+				now := time.Now()
+				for _, addr := range addrs {
+					add(addr, subdomain, "VirusTotal subdomain", now)
+				}
+			}
+		}
+	}
+
+	return records, nil
+}
+
+// SearchResolutionsWithKey returns the IPv4 addresses VirusTotal has seen domain
+// resolve to, with the dated sightings kept in Metadata[history.MetadataKey]
+func SearchResolutionsWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	body, err := get(ctx, fmt.Sprintf("%s%s/resolutions?limit=40", apiBaseURL, domain), apiKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var vtResp VTResolutionResponse
+	if err := json.Unmarshal(body, &vtResp); err != nil {
+		return nil, fmt.Errorf("failed to parse VirusTotal response: %w", err)
+	}
+	if vtResp.Error.Message != "" {
+		return nil, fmt.Errorf("VirusTotal API error: %s", vtResp.Error.Message)
+	}
+	return parseResolutions(domain, vtResp.Data), nil
+}
+
+// parseResolutions converts resolutions into one passive IP record per IPv4 address
+func parseResolutions(domain string, resolutions []VTResolutionData) []core.PassiveIP {
+	byIP := make(map[string]*core.PassiveIP)
+	var order []string
+
+	for _, resolution := range resolutions {
+		ip := strings.TrimSpace(resolution.Attributes.IPAddress)
+		if parsedIP := net.ParseIP(ip); parsedIP == nil || parsedIP.To4() == nil || resolution.Attributes.Date <= 0 {
+			continue
+		}
+		seen := time.Unix(resolution.Attributes.Date, 0).UTC()
+
+		record, ok := byIP[ip]
+		if !ok {
+			hostname := resolution.Attributes.HostName
+			if hostname == "" {
+				hostname = domain
+			}
+			record = &core.PassiveIP{
+				IP:        ip,
+				Source:    "virustotal",
+				Hostname:  hostname,
+				Via:       "VirusTotal resolution",
+				FirstSeen: seen,
+				LastSeen:  seen,
+				Metadata:  make(map[string]interface{}),
+			}
+			byIP[ip] = record
+			order = append(order, ip)
+		}
+		if seen.Before(record.FirstSeen) {
+			record.FirstSeen = seen
+		}
+		if seen.After(record.LastSeen) {
+			record.LastSeen = seen
+		}
+		periods, _ := record.Metadata[history.MetadataKey].([]history.Period)
+		record.Metadata[history.MetadataKey] = append(periods, history.Period{From: seen, To: seen})
+	}
 
+	records := make([]core.PassiveIP, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
+	}
+	return records
+}
+
+// get performs an authenticated API request and returns the body of a 200 response
+func get(ctx context.Context, url, apiKey string, timeout time.Duration) ([]byte, error) {
 	client := &http.Client{
 		Timeout: timeout,
 	}
@@ -136,63 +302,5 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		}
 		return nil, fmt.Errorf("VirusTotal returned status %d: %s", resp.StatusCode, strings.TrimSpace(bodyStr))
 	}
-
-	// Parse successful response
-	var vtResp VTSubdomainResponse
-	if err := json.Unmarshal(body, &vtResp); err != nil {
-		return nil, fmt.Errorf("failed to parse VirusTotal response: %w", err)
-	}
-
-	// Check for API error in JSON (even with 200 OK)
-	if vtResp.Error.Message != "" {
-		return nil, fmt.Errorf("VirusTotal API error: %s", vtResp.Error.Message)
-	}
-
-	// Extract unique IPv4 addresses from DNS records
-	ipSet := make(map[string]bool)
-	for _, data := range vtResp.Data {
-		for _, dnsRecord := range data.Attributes.LastDNSRecords {
-			// Only process A records (IPv4)
-			if strings.ToUpper(dnsRecord.Type) != "A" {
-				continue
-			}
-
-			ip := strings.TrimSpace(dnsRecord.Value)
-			if ip == "" {
-				continue
-			}
-
-			// Validate IPv4
-			parsedIP := net.ParseIP(ip)
-			if parsedIP != nil && parsedIP.To4() != nil {
-				ipSet[ip] = true
-			}
-		}
-
-		// Also resolve the subdomain itself
-		subdomain := data.ID
-		if subdomain != "" {
-			// Resolve with short timeout to avoid delays
-			resolveCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-			addrs, err := net.DefaultResolver.LookupHost(resolveCtx, subdomain)
-			cancel()
-
-			if err == nil {
-				for _, addr := range addrs {
-					parsedIP := net.ParseIP(addr)
-					if parsedIP != nil && parsedIP.To4() != nil {
-						ipSet[addr] = true
-					}
-				}
-			}
-		}
-	}
-
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
-	}
-
-	return ips, nil
+	return body, nil
 }
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/passive/history"
 )
 
 func TestSearchSubdomains_NoAPIKeys(t *testing.T) {
@@ -176,7 +178,7 @@ func TestSearchWithKey_HTTPError(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "invalid_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "invalid_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected error for invalid key")
@@ -197,7 +199,7 @@ func TestSearchWithKey_NoContent(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected rate limit error for 204")
@@ -221,7 +223,7 @@ func TestSearchWithKey_429RateLimit(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected rate limit error for 429")
@@ -243,7 +245,7 @@ func TestSearchWithKey_InvalidJSON(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected JSON parsing error")
@@ -259,7 +261,7 @@ func TestSearchWithKey_InvalidURL(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err == nil {
 		t.Fatal("Expected error for invalid URL")
@@ -289,7 +291,7 @@ func TestSearchWithKey_ReadBodyError(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err == nil {
 		t.Fatal("Expected error for failed body read")
@@ -388,7 +390,7 @@ func TestSearchWithKey_LongResponseBody(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 	if err == nil {
 		t.Log("Expected error for bad request")
 	} else if len(err.Error()) > 300 {
@@ -438,7 +440,7 @@ func TestSearchSubdomains_WhitespaceKeys(t *testing.T) {
 
 func TestSearchWithKey_EmptyDomain(t *testing.T) {
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Log("Expected error for empty domain")
@@ -473,7 +475,7 @@ func TestSearchWithKey_StatusCodes(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Test validates error handling without network calls
 			ctx := context.Background()
-			_, err := searchWithKey(ctx, "example.com", "test_key", 100*time.Millisecond)
+			_, err := SearchWithKey(ctx, "example.com", "test_key", 100*time.Millisecond)
 
 			if tt.wantErr && err == nil {
 				t.Log("Expected error for", tt.name)
@@ -492,6 +494,7 @@ func TestSearchWithKey_SuccessWithARecords(t *testing.T) {
 					ID:   "sub1.example.com",
 					Type: "domain",
 					Attributes: VTDomainAttributes{
+						LastDNSRecordsDate: 1704067200, // 2024-01-01
 						LastDNSRecords: []VTDNSRecord{
 							{Type: "A", Value: "192.168.1.1"},
 							{Type: "A", Value: "192.168.1.2"},
@@ -537,7 +540,7 @@ func TestSearchWithKey_SuccessWithARecords(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -552,7 +555,7 @@ func TestSearchWithKey_SuccessWithARecords(t *testing.T) {
 	// Verify specific IPs are present
 	ipMap := make(map[string]bool)
 	for _, ip := range ips {
-		ipMap[ip] = true
+		ipMap[ip.IP] = true
 	}
 
 	expectedIPs := []string{"192.168.1.1", "192.168.1.2", "192.168.1.3", "192.168.2.1", "192.168.2.2", "192.168.3.1"}
@@ -561,6 +564,14 @@ func TestSearchWithKey_SuccessWithARecords(t *testing.T) {
 			t.Errorf("Expected IP %s not found in results", expectedIP)
 		}
 	}
+
+	// The duplicate keeps the subdomain whose DNS record reported it first
+	if ips[0].IP != "192.168.1.1" || ips[0].Hostname != "sub1.example.com" || ips[0].Via != "VirusTotal DNS record" {
+		t.Errorf("first record provenance = %s %q, want 192.168.1.1 via sub1.example.com", ips[0].IP, ips[0].Provenance())
+	}
+	if ips[0].LastSeen.Unix() != 1704067200 {
+		t.Errorf("LastSeen = %v, want last_dns_records_date", ips[0].LastSeen)
+	}
 }
 
 func TestSearchWithKey_EmptyDNSValues(t *testing.T) {
@@ -594,7 +605,7 @@ func TestSearchWithKey_EmptyDNSValues(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -604,7 +615,7 @@ func TestSearchWithKey_EmptyDNSValues(t *testing.T) {
 	// Empty and whitespace-only values should be filtered out
 	validIPCount := 0
 	for _, ip := range ips {
-		if ip == "192.168.1.1" || ip == "192.168.1.2" {
+		if ip.IP == "192.168.1.1" || ip.IP == "192.168.1.2" {
 			validIPCount++
 		}
 	}
@@ -630,7 +641,7 @@ func TestSearchWithKey_ErrorInJSON(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected API error")
@@ -656,7 +667,7 @@ func TestSearchWithKey_EmptyResponse(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -685,7 +696,7 @@ func TestSearchWithKey_LongBodyTruncation(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected error for bad request")
@@ -715,7 +726,7 @@ func TestSearchWithKey_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err == nil {
 		t.Error("Expected context cancellation error")
@@ -735,7 +746,7 @@ func TestSearchWithKey_Timeout(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
 
 	if err == nil {
 		t.Error("Expected timeout error")
@@ -783,7 +794,7 @@ func TestSearchWithKey_NonOKStatusWithJSONError(t *testing.T) {
 			defer func() { apiBaseURL = oldURL }()
 
 			ctx := context.Background()
-			_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+			_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 			if err == nil {
 				t.Errorf("Expected error for status %d", tt.statusCode)
@@ -808,7 +819,7 @@ func TestSearchWithKey_NonOKStatusPlainText(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected error for bad request")
@@ -997,7 +1008,7 @@ func TestSearchWithKey_SubdomainResolution(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -1011,7 +1022,7 @@ func TestSearchWithKey_SubdomainResolution(t *testing.T) {
 	// Check if localhost resolution added IPs (should have 127.0.0.1 or similar)
 	hasLocalhost := false
 	for _, ip := range ips {
-		if strings.HasPrefix(ip, "127.") {
+		if strings.HasPrefix(ip.IP, "127.") {
 			hasLocalhost = true
 			break
 		}
@@ -1049,7 +1060,7 @@ func TestSearchWithKey_SubdomainResolutionIPv6Only(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -1062,8 +1073,8 @@ func TestSearchWithKey_SubdomainResolutionIPv6Only(t *testing.T) {
 
 	// Verify no IPv6 addresses
 	for _, ip := range ips {
-		if strings.Contains(ip, ":") {
-			t.Errorf("Found IPv6 address in results (should be filtered): %s", ip)
+		if strings.Contains(ip.IP, ":") {
+			t.Errorf("Found IPv6 address in results (should be filtered): %s", ip.IP)
 		}
 	}
 }
@@ -1095,7 +1106,7 @@ func TestSearchWithKey_SubdomainResolutionTimeout(t *testing.T) {
 	defer func() { apiBaseURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -1106,3 +1117,57 @@ func TestSearchWithKey_SubdomainResolutionTimeout(t *testing.T) {
 		t.Error("Expected at least 1 IP from A record")
 	}
 }
+
+func TestSearchResolutionsWithKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/resolutions" || r.Header.Get("x-apikey") != "test_key" {
+			http.NotFound(w, r)
+			return
+		}
+		resp := VTResolutionResponse{Data: []VTResolutionData{
+			{Type: "resolution", Attributes: VTResolutionAttributes{Date: 1704067200, HostName: "example.com", IPAddress: "192.0.2.1"}}, // 2024-01-01
+			{Type: "resolution", Attributes: VTResolutionAttributes{Date: 1577836800, HostName: "example.com", IPAddress: "192.0.2.1"}}, // 2020-01-01
+			{Type: "resolution", Attributes: VTResolutionAttributes{Date: 1609459200, IPAddress: "192.0.2.2"}},                          // 2021-01-01
+			{Type: "resolution", Attributes: VTResolutionAttributes{Date: 1609459200, IPAddress: "2001:db8::1"}},                        // IPv6, skip
+			{Type: "resolution", Attributes: VTResolutionAttributes{IPAddress: "192.0.2.3"}},                                            // Undated, skip
+		}}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	oldURL := apiBaseURL
+	apiBaseURL = server.URL + "/"
+	defer func() { apiBaseURL = oldURL }()
+
+	ips, err := SearchResolutionsWithKey(context.Background(), "example.com", "test_key", 5*time.Second)
+	if err != nil {
+		t.Fatalf("SearchResolutionsWithKey() error: %v", err)
+	}
+	if len(ips) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(ips), ips)
+	}
+
+	first := ips[0]
+	if first.IP != "192.0.2.1" || first.Via != "VirusTotal resolution" || first.Hostname != "example.com" {
+		t.Errorf("first record = %+v", first)
+	}
+	if first.FirstSeen.Unix() != 1577836800 || first.LastSeen.Unix() != 1704067200 {
+		t.Errorf("seen range = %v..%v, want 2020-01-01..2024-01-01", first.FirstSeen, first.LastSeen)
+	}
+	if periods, _ := first.Metadata[history.MetadataKey].([]history.Period); len(periods) != 2 {
+		t.Errorf("history = %v, want one period per resolution", first.Metadata[history.MetadataKey])
+	}
+	if ips[1].Hostname != "example.com" {
+		t.Errorf("Hostname = %q, want the domain when the resolution has none", ips[1].Hostname)
+	}
+
+	// Rate limits are reported like the subdomain search
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+	apiBaseURL = limited.URL + "/"
+	if _, err := SearchResolutionsWithKey(context.Background(), "example.com", "test_key", 5*time.Second); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("SearchResolutionsWithKey() error = %v, want a rate limit error", err)
+	}
+}
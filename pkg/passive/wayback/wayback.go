@@ -10,13 +10,17 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 )
 
 // CDXRecord represents a single record from the Wayback Machine CDX API
 type CDXRecord []string
 
 // SearchSubdomains queries the Wayback Machine CDX API for historical subdomains
-func SearchSubdomains(ctx context.Context, domain string, timeout time.Duration) ([]string, error) {
+// Each IP records the archived hostname that resolved to it
+func SearchSubdomains(ctx context.Context, domain string, timeout time.Duration) ([]core.PassiveIP, error) {
 	// Build URL: query for *.domain.com with JSON output and collapse by urlkey
 	url := fmt.Sprintf("http://web.archive.org/cdx/search/cdx?url=*.%s&output=json&collapse=urlkey&fl=original", domain)
 
@@ -62,8 +66,9 @@ func SearchSubdomains(ctx context.Context, domain string, timeout time.Duration)
 		return nil, fmt.Errorf("failed to parse Wayback response: %w", err)
 	}
 
-	// Extract unique subdomains from URLs
+	// Extract unique subdomains from URLs (in archive order)
 	subdomainSet := make(map[string]bool)
+	var subdomains []string
 	for i, record := range records {
 		// Skip header row
 		if i == 0 {
@@ -79,17 +84,12 @@ func SearchSubdomains(ctx context.Context, domain string, timeout time.Duration)
 
 		// Extract subdomain from URL
 		subdomain := extractSubdomain(urlStr, domain)
-		if subdomain != "" {
+		if subdomain != "" && !subdomainSet[subdomain] {
 			subdomainSet[subdomain] = true
+			subdomains = append(subdomains, subdomain)
 		}
 	}
 
-	// Convert to slice
-	subdomains := make([]string, 0, len(subdomainSet))
-	for subdomain := range subdomainSet {
-		subdomains = append(subdomains, subdomain)
-	}
-
 	// Resolve subdomains to IPs (limit to first 100 to avoid long delays)
 	return resolveSubdomainsToIPs(ctx, subdomains, domain, 100, 3*time.Second)
 }
@@ -127,10 +127,11 @@ func extractSubdomain(urlStr, baseDomain string) string {
 	return hostnameLower
 }
 
-// resolveSubdomainsToIPs resolves a list of subdomains to IPv4 addresses
-func resolveSubdomainsToIPs(ctx context.Context, subdomains []string, baseDomain string, maxResolve int, timeout time.Duration) ([]string, error) {
-	ipSet := make(map[string]bool)
-	resolver := &net.Resolver{}
+// resolveSubdomainsToIPs resolves a list of subdomains to IPv4 and IPv6 addresses
+// Each subdomain/IP pair is reported once, so names sharing an IP are all kept
+func resolveSubdomainsToIPs(ctx context.Context, subdomains []string, baseDomain string, maxResolve int, timeout time.Duration) ([]core.PassiveIP, error) {
+	seen := make(map[string]bool)
+	records := make([]core.PassiveIP, 0)
 
 	resolveCount := 0
 	for _, subdomain := range subdomains {
@@ -139,29 +140,31 @@ func resolveSubdomainsToIPs(ctx context.Context, subdomains []string, baseDomain
 		}
 
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
-		addrs, err := resolver.LookupHost(ctxWithTimeout, subdomain)
+		addrs, err := resolver.Default().LookupHost(ctxWithTimeout, subdomain)
 		cancel()
 
 		if err != nil {
 			continue // Skip failed resolutions
 		}
 
-		// Filter IPv4 only
+		now := time.Now()
 		for _, addr := range addrs {
-			ip := net.ParseIP(addr)
-			if ip != nil && ip.To4() != nil {
-				ipSet[addr] = true
+			if net.ParseIP(addr) == nil || seen[subdomain+"|"+addr] {
+				continue
 			}
+			seen[subdomain+"|"+addr] = true
+			records = append(records, core.PassiveIP{
+				IP:        addr,
+				Source:    "wayback",
+				Hostname:  subdomain,
+				Via:       "Wayback archived URL",
+				FirstSeen: now,
+				LastSeen:  now,
+			})
 		}
 
 		resolveCount++
 	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
-	}
-
-	return ips, nil
+	return records, nil
 }
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
 )
 
 func TestSearchSubdomains_ValidResponse(t *testing.T) {
@@ -212,19 +217,33 @@ func TestResolveSubdomainsToIPs_ValidDomain(t *testing.T) {
 	}
 }
 
-func TestResolveSubdomainsToIPs_IPv4Only(t *testing.T) {
-	ctx := context.Background()
+func TestResolveSubdomainsToIPs_IPv6(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("www.example.com", "192.0.2.1")
+	zone.AddAAAA("www.example.com", "2001:db8::1")
+	zone.AddAAAA("v6.example.com", "2001:db8::2")
 
-	ips, err := resolveSubdomainsToIPs(ctx, []string{"google.com"}, "google.com", 10, 5*time.Second)
+	r, err := resolver.New([]string{zone.Addr}, time.Second)
 	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
+		t.Fatalf("resolver.New() error: %v", err)
 	}
+	original := resolver.Default()
+	defer resolver.SetDefault(original)
+	resolver.SetDefault(r)
 
-	// All should be IPv4 format
-	for _, ip := range ips {
-		if len(ip) > 15 {
-			t.Errorf("Expected IPv4 only, got: %s", ip)
-		}
+	records, err := resolveSubdomainsToIPs(context.Background(), []string{"www.example.com", "v6.example.com"}, "example.com", 10, time.Second)
+	if err != nil {
+		t.Fatalf("resolveSubdomainsToIPs() error: %v", err)
+	}
+	var got []string
+	for _, record := range records {
+		got = append(got, record.Hostname+"|"+record.IP)
+	}
+	sort.Strings(got)
+	want := []string{"v6.example.com|2001:db8::2", "www.example.com|192.0.2.1", "www.example.com|2001:db8::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveSubdomainsToIPs() = %v, want %v", got, want)
 	}
 }
 
@@ -239,10 +258,25 @@ func TestResolveSubdomainsToIPs_Deduplication(t *testing.T) {
 
 	seen := make(map[string]bool)
 	for _, ip := range ips {
-		if seen[ip] {
-			t.Errorf("Duplicate IP: %s", ip)
+		if seen[ip.IP] {
+			t.Errorf("Duplicate IP: %s", ip.IP)
 		}
-		seen[ip] = true
+		seen[ip.IP] = true
+	}
+}
+
+func TestResolveSubdomainsToIPs_Provenance(t *testing.T) {
+	ctx := context.Background()
+
+	ips, err := resolveSubdomainsToIPs(ctx, []string{"localhost"}, "localhost", 10, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ips) == 0 {
+		t.Skip("localhost did not resolve to IPv4")
+	}
+	if ips[0].Source != "wayback" || ips[0].Hostname != "localhost" || ips[0].Via == "" {
+		t.Errorf("record = %+v, want wayback record for localhost", ips[0])
 	}
 }
 
@@ -10,8 +10,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 // ZoomEyeV2Request represents the request body for ZoomEye v2 POST API
@@ -41,13 +44,16 @@ type ZoomEyeV2Asset struct {
 	UpdateTime string `json:"update_time"`
 }
 
+// updateTimeLayouts are the formats seen in ZoomEyeV2Asset.UpdateTime
+var updateTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05"}
+
 // apiURL is the ZoomEye v2 API endpoint (can be overridden in tests)
 var apiURL = "https://api.zoomeye.ai/v2/search"
 
 // SearchHost queries ZoomEye for hosts matching the domain
-func SearchHost(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]string, error) {
+func SearchHost(ctx context.Context, domain string, apiKeys []string, timeout time.Duration) ([]core.PassiveIP, error) {
 	if len(apiKeys) == 0 {
-		return []string{}, fmt.Errorf("no ZoomEye API keys provided")
+		return nil, fmt.Errorf("no ZoomEye API keys provided")
 	}
 
 	// Try each API key until one works
@@ -58,7 +64,7 @@ func SearchHost(ctx context.Context, domain string, apiKeys []string, timeout ti
 			continue
 		}
 
-		ips, err := searchWithKey(ctx, domain, apiKey, timeout)
+		ips, err := SearchWithKey(ctx, domain, apiKey, timeout)
 		if err == nil {
 			return ips, nil
 		}
@@ -70,20 +76,57 @@ func SearchHost(ctx context.Context, domain string, apiKeys []string, timeout ti
 		}
 
 		// For other errors, return immediately
-		return []string{}, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
+		return nil, fmt.Errorf("key %d/%d failed: %w", i+1, len(apiKeys), err)
 	}
 
 	if lastErr != nil {
-		return []string{}, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
+		return nil, fmt.Errorf("all %d API keys exhausted: %w", len(apiKeys), lastErr)
 	}
 
-	return []string{}, fmt.Errorf("no valid API keys found")
+	return nil, fmt.Errorf("no valid API keys found")
 }
 
-// searchWithKey performs the search with a single API key
-func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]string, error) {
-	// Build query: ssl.cert.subject.cn="domain.com" and encode with base64
+// SearchWithKey performs the search with a single API key
+func SearchWithKey(ctx context.Context, domain, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	// Build query: ssl.cert.subject.cn="domain.com"
 	query := fmt.Sprintf(`ssl.cert.subject.cn="%s"`, domain)
+	return search(ctx, query, "ZoomEye SSL cert CN", apiKey, timeout)
+}
+
+// PivotQuery builds the ZoomEye query for a pivot and the description of its results
+func PivotQuery(pivot core.Pivot) (query, via string, err error) {
+	switch pivot.Kind {
+	case core.PivotFavicon:
+		return "iconhash=" + strconv.Quote(pivot.Value), "ZoomEye favicon hash", nil
+	case core.PivotTitle:
+		return "title=" + strconv.Quote(pivot.Value), "ZoomEye HTML title", nil
+	case core.PivotCertSHA256:
+		return "ssl.cert.fingerprint.sha256=" + strconv.Quote(pivot.Value), "ZoomEye cert SHA-256", nil
+	case core.PivotCertSubject:
+		return "ssl.cert.subject.cn=" + strconv.Quote(pivot.Value), "ZoomEye cert subject", nil
+	}
+	return "", "", fmt.Errorf("%w: %s", core.ErrUnsupportedPivot, pivot.Kind)
+}
+
+// SearchPivotWithKey finds hosts sharing an attribute of the live site
+func SearchPivotWithKey(ctx context.Context, pivot core.Pivot, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
+	query, via, err := PivotQuery(pivot)
+	if err != nil {
+		return nil, err
+	}
+	records, err := search(ctx, query, via, apiKey, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Pivot = pivot.Kind
+		records[i].Metadata["pivot_value"] = pivot.Value
+	}
+	return records, nil
+}
+
+// search runs a base64-encoded search query; via describes the query in the returned records
+func search(ctx context.Context, query, via, apiKey string, timeout time.Duration) ([]core.PassiveIP, error) {
 	encodedQuery := base64.StdEncoding.EncodeToString([]byte(query))
 
 	// Create POST request body for v2 API
@@ -152,9 +195,16 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 		return nil, fmt.Errorf("ZoomEye API error (code %d): %s", zoomeyeResp.Code, zoomeyeResp.Message)
 	}
 
-	// Extract unique IPv4 addresses
-	ipSet := make(map[string]bool)
-	for _, asset := range zoomeyeResp.Data {
+	return parseAssets(zoomeyeResp.Data, via), nil
+}
+
+// parseAssets converts search assets into passive IP records, one per IPv4 address.
+// The most recent update time across an IP's assets becomes its last seen time.
+func parseAssets(assets []ZoomEyeV2Asset, via string) []core.PassiveIP {
+	byIP := make(map[string]*core.PassiveIP)
+	var order []string
+
+	for _, asset := range assets {
 		ip := strings.TrimSpace(asset.IP)
 		if ip == "" {
 			continue
@@ -162,21 +212,38 @@ func searchWithKey(ctx context.Context, domain, apiKey string, timeout time.Dura
 
 		// Validate and filter IPv4 only
 		parsedIP := net.ParseIP(ip)
-		if parsedIP == nil {
+		if parsedIP == nil || parsedIP.To4() == nil {
 			continue
 		}
 
-		// Check if IPv4 (To4() returns nil for IPv6)
-		if parsedIP.To4() != nil {
-			ipSet[ip] = true
+		record, ok := byIP[ip]
+		if !ok {
+			record = &core.PassiveIP{IP: ip, Source: "zoomeye", Via: via, Metadata: make(map[string]interface{})}
+			byIP[ip] = record
+			order = append(order, ip)
+		}
+
+		if updated := parseUpdateTime(asset.UpdateTime); updated.After(record.LastSeen) {
+			record.LastSeen = updated
+		}
+		if record.Hostname == "" {
+			record.Hostname = strings.TrimSpace(asset.Hostname)
 		}
 	}
 
-	// Convert to slice
-	ips := make([]string, 0, len(ipSet))
-	for ip := range ipSet {
-		ips = append(ips, ip)
+	records := make([]core.PassiveIP, 0, len(order))
+	for _, ip := range order {
+		records = append(records, *byIP[ip])
 	}
+	return records
+}
 
-	return ips, nil
+// parseUpdateTime parses an asset update time, returning the zero time if unknown
+func parseUpdateTime(value string) time.Time {
+	for _, layout := range updateTimeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
 }
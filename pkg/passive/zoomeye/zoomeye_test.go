@@ -2,13 +2,17 @@ package zoomeye
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
 )
 
 func TestSearchHost_NoAPIKeys(t *testing.T) {
@@ -130,7 +134,7 @@ func TestSearchWithKey_StatusCodes(t *testing.T) {
 			defer func() { apiURL = oldURL }()
 
 			ctx := context.Background()
-			_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+			_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 			if err == nil {
 				t.Errorf("Expected error for status %d", tt.statusCode)
@@ -154,7 +158,7 @@ func TestSearchWithKey_InvalidJSON(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected JSON parsing error")
@@ -180,7 +184,7 @@ func TestSearchWithKey_APIErrorCode(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected API error for non-60000 code")
@@ -196,13 +200,13 @@ func TestSearchWithKey_SuccessfulResponse(t *testing.T) {
 			Message: "Success",
 			Total:   3,
 			Data: []ZoomEyeV2Asset{
-				{IP: "192.168.1.1", Port: 443},
+				{IP: "192.168.1.1", Port: 443, UpdateTime: "2024-02-01T09:00:00"},
 				{IP: "192.168.1.2", Port: 80},
-				{IP: "  192.168.1.3  ", Port: 443}, // With whitespace
-				{IP: "192.168.1.1", Port: 8080},    // Duplicate IP (different port)
-				{IP: "", Port: 443},                // Empty IP (skip)
-				{IP: "invalid-ip", Port: 443},      // Invalid IP (skip)
-				{IP: "2001:db8::1", Port: 443},     // IPv6 (skip - v4 only)
+				{IP: "  192.168.1.3  ", Port: 443},                                 // With whitespace
+				{IP: "192.168.1.1", Port: 8080, UpdateTime: "2024-04-01 09:00:00"}, // Duplicate IP (different port)
+				{IP: "", Port: 443},                                                // Empty IP (skip)
+				{IP: "invalid-ip", Port: 443},                                      // Invalid IP (skip)
+				{IP: "2001:db8::1", Port: 443},                                     // IPv6 (skip - v4 only)
 			},
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -216,7 +220,7 @@ func TestSearchWithKey_SuccessfulResponse(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -230,7 +234,15 @@ func TestSearchWithKey_SuccessfulResponse(t *testing.T) {
 	// Verify specific IPs are present
 	ipMap := make(map[string]bool)
 	for _, ip := range ips {
-		ipMap[ip] = true
+		ipMap[ip.IP] = true
+		if ip.Source != "zoomeye" {
+			t.Errorf("Source = %q, want zoomeye", ip.Source)
+		}
+	}
+
+	// The latest update time of a duplicated IP wins
+	if got := ips[0].LastSeen.Format("2006-01-02"); ips[0].IP != "192.168.1.1" || got != "2024-04-01" {
+		t.Errorf("LastSeen for %s = %s, want 2024-04-01", ips[0].IP, got)
 	}
 
 	expectedIPs := []string{"192.168.1.1", "192.168.1.2", "192.168.1.3"}
@@ -260,7 +272,7 @@ func TestSearchWithKey_EmptyResponse(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	ips, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	ips, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -286,7 +298,7 @@ func TestSearchWithKey_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := searchWithKey(ctx, "example.com", "test_key", 5*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 5*time.Second)
 
 	if err == nil {
 		t.Error("Expected context cancellation error")
@@ -306,7 +318,7 @@ func TestSearchWithKey_Timeout(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Millisecond)
 
 	if err == nil {
 		t.Error("Expected timeout error")
@@ -331,7 +343,7 @@ func TestSearchWithKey_LongBodyTruncation(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "example.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "example.com", "test_key", 1*time.Second)
 
 	if err == nil {
 		t.Error("Expected error for bad request")
@@ -380,9 +392,54 @@ func TestSearchWithKey_Base64Query(t *testing.T) {
 	defer func() { apiURL = oldURL }()
 
 	ctx := context.Background()
-	_, err := searchWithKey(ctx, "test-domain.com", "test_key", 1*time.Second)
+	_, err := SearchWithKey(ctx, "test-domain.com", "test_key", 1*time.Second)
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 }
+
+func TestPivotQuery(t *testing.T) {
+	tests := []struct {
+		pivot core.Pivot
+		query string
+	}{
+		{core.Pivot{Kind: core.PivotFavicon, Value: "-2059846915"}, `iconhash="-2059846915"`},
+		{core.Pivot{Kind: core.PivotTitle, Value: "Shop"}, `title="Shop"`},
+		{core.Pivot{Kind: core.PivotCertSHA256, Value: "ab12"}, `ssl.cert.fingerprint.sha256="ab12"`},
+		{core.Pivot{Kind: core.PivotCertSubject, Value: "*.example.com"}, `ssl.cert.subject.cn="*.example.com"`},
+	}
+	for _, tt := range tests {
+		query, via, err := PivotQuery(tt.pivot)
+		if err != nil || query != tt.query || via == "" {
+			t.Errorf("PivotQuery(%v) = %q, %q, %v, want %q", tt.pivot, query, via, err, tt.query)
+		}
+	}
+	if _, _, err := PivotQuery(core.Pivot{Kind: "nope"}); !errors.Is(err, core.ErrUnsupportedPivot) {
+		t.Errorf("PivotQuery(nope) error = %v, want ErrUnsupportedPivot", err)
+	}
+}
+
+func TestSearchPivotWithKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody ZoomEyeV2Request
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if query, _ := base64.StdEncoding.DecodeString(reqBody.QBase64); string(query) != `title="Shop"` {
+			t.Errorf("query = %q, want title=\"Shop\"", query)
+		}
+		json.NewEncoder(w).Encode(ZoomEyeV2Response{Code: 60000, Data: []ZoomEyeV2Asset{{IP: "192.0.2.10", Port: 443}}})
+	}))
+	defer server.Close()
+
+	oldURL := apiURL
+	apiURL = server.URL
+	defer func() { apiURL = oldURL }()
+
+	records, err := SearchPivotWithKey(context.Background(), core.Pivot{Kind: core.PivotTitle, Value: "Shop"}, "test_key", time.Second)
+	if err != nil {
+		t.Fatalf("SearchPivotWithKey() error: %v", err)
+	}
+	if len(records) != 1 || records[0].IP != "192.0.2.10" || records[0].Via != "ZoomEye HTML title" || records[0].Pivot != core.PivotTitle {
+		t.Errorf("SearchPivotWithKey() = %+v, want 192.0.2.10 via the HTML title", records)
+	}
+}
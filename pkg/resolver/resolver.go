@@ -0,0 +1,293 @@
+// Package resolver provides the DNS resolver shared by every lookup in origindive.
+// It queries custom UDP/TCP resolvers or DNS-over-HTTPS endpoints round-robin with
+// a per-query timeout, or the system resolver when none are configured.
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// DefaultTimeout is the per-query timeout used when none is given
+const DefaultTimeout = 3 * time.Second
+
+// maxMessageSize is the largest DNS message read from a DoH response
+const maxMessageSize = 65535
+
+// defaultResolver is the resolver returned by Default
+var defaultResolver atomic.Pointer[Resolver]
+
+func init() {
+	defaultResolver.Store(System(DefaultTimeout))
+}
+
+// Default returns the process-wide resolver (the system resolver until SetDefault)
+func Default() *Resolver {
+	return defaultResolver.Load()
+}
+
+// SetDefault replaces the process-wide resolver
+func SetDefault(r *Resolver) {
+	defaultResolver.Store(r)
+}
+
+// Server is one upstream DNS resolver
+type Server struct {
+	Network string // "udp", "tcp" or "https"
+	Address string // host:port, or the DoH URL for https
+}
+
+// String returns the server in the form accepted by ParseServer
+func (s Server) String() string {
+	if s.Network == "https" {
+		return s.Address
+	}
+	return s.Network + "://" + s.Address
+}
+
+// ParseServer parses a resolver given as IP[:port], udp://IP[:port], tcp://IP[:port]
+// or an https:// DNS-over-HTTPS URL. The port defaults to 53.
+func ParseServer(s string) (Server, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "https://") {
+		return Server{Network: "https", Address: s}, nil
+	}
+
+	network := "udp"
+	for _, prefix := range []string{"udp", "tcp"} {
+		if strings.HasPrefix(s, prefix+"://") {
+			network = prefix
+			s = strings.TrimPrefix(s, prefix+"://")
+			break
+		}
+	}
+
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		// No port: a bare IPv4 or IPv6 address
+		host, port = strings.Trim(s, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil || port == "" {
+		return Server{}, fmt.Errorf("%w: %q", core.ErrInvalidResolver, s)
+	}
+	return Server{Network: network, Address: net.JoinHostPort(host, port)}, nil
+}
+
+// Resolver resolves names through its servers, rotating between them on every query
+type Resolver struct {
+	servers  []Server
+	timeout  time.Duration
+	next     atomic.Uint64
+	client   *http.Client // DNS-over-HTTPS transport
+	resolver *net.Resolver
+}
+
+// New returns a resolver for the given servers (see ParseServer) with a per-query
+// timeout. Without servers it uses the system resolver.
+func New(servers []string, timeout time.Duration) (*Resolver, error) {
+	var parsed []Server
+	for _, s := range servers {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		server, err := ParseServer(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, server)
+	}
+	if len(parsed) == 0 {
+		return System(timeout), nil
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	r := &Resolver{
+		servers: parsed,
+		timeout: timeout,
+		client:  &http.Client{Timeout: timeout},
+	}
+	r.resolver = &net.Resolver{PreferGo: true, Dial: r.dial}
+	return r, nil
+}
+
+// System returns a resolver that uses the operating system's configuration
+func System(timeout time.Duration) *Resolver {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Resolver{timeout: timeout, resolver: &net.Resolver{}}
+}
+
+// Servers returns the configured servers (empty for the system resolver)
+func (r *Resolver) Servers() []Server {
+	return append([]Server(nil), r.servers...)
+}
+
+// Timeout returns the per-query timeout
+func (r *Resolver) Timeout() time.Duration {
+	return r.timeout
+}
+
+// Net returns the underlying net.Resolver, for use as a net.Dialer's Resolver
+func (r *Resolver) Net() *net.Resolver {
+	return r.resolver
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of host
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.resolver.LookupHost(ctx, r.absolute(host))
+}
+
+// LookupIPv4 returns the IPv4 addresses of host
+func (r *Resolver) LookupIPv4(ctx context.Context, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	ips, err := r.resolver.LookupIP(ctx, "ip4", r.absolute(host))
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			addrs = append(addrs, ip4.String())
+		}
+	}
+	return addrs, nil
+}
+
+// LookupMX returns the MX records of domain, sorted by preference
+func (r *Resolver) LookupMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.resolver.LookupMX(ctx, r.absolute(domain))
+}
+
+// LookupTXT returns the TXT records of name, each record's strings joined
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.resolver.LookupTXT(ctx, r.absolute(name))
+}
+
+// LookupAddr returns the PTR names of an IP address
+func (r *Resolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.resolver.LookupAddr(ctx, addr)
+}
+
+// absolute roots names sent to custom servers so resolv.conf search domains
+// (often internal ones) are never appended
+func (r *Resolver) absolute(name string) string {
+	if len(r.servers) == 0 || strings.HasSuffix(name, ".") || net.ParseIP(name) != nil {
+		return name
+	}
+	return name + "."
+}
+
+// dial connects the pure Go resolver to the next server in rotation, ignoring the
+// system nameserver it asked for
+func (r *Resolver) dial(ctx context.Context, network, _ string) (net.Conn, error) {
+	server := r.servers[(r.next.Add(1)-1)%uint64(len(r.servers))]
+	switch server.Network {
+	case "https":
+		return &dohConn{ctx: ctx, url: server.Address, client: r.client}, nil
+	case "tcp":
+		network = "tcp"
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, network, server.Address)
+}
+
+// dohConn carries the length-prefixed DNS messages the pure Go resolver writes to a
+// stream connection over DNS-over-HTTPS (RFC 8484), one POST per message
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	client   *http.Client
+	deadline time.Time
+	wbuf     bytes.Buffer
+	rbuf     bytes.Buffer
+}
+
+// Write buffers the query and exchanges every complete message
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.wbuf.Write(b)
+	for c.wbuf.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.wbuf.Bytes()))
+		if c.wbuf.Len() < 2+size {
+			break
+		}
+		c.wbuf.Next(2)
+		answer, err := c.exchange(c.wbuf.Next(size))
+		if err != nil {
+			return 0, err
+		}
+		c.rbuf.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+		c.rbuf.Write(answer)
+	}
+	return len(b), nil
+}
+
+// Read returns the buffered answers
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.rbuf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.rbuf.Read(b)
+}
+
+// exchange POSTs one DNS message and returns the answer message
+func (c *dohConn) exchange(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server returned HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr(c.url) }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr(c.url) }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+// dohAddr is the net.Addr of a DoH endpoint
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
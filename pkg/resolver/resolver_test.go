@@ -0,0 +1,280 @@
+package resolver
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/resolver/resolvertest"
+)
+
+// serveTCP answers length-prefixed DNS queries on a local TCP port from a test zone
+func serveTCP(t *testing.T, zone *resolvertest.Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on TCP: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var size [2]byte
+				for {
+					if _, err := io.ReadFull(conn, size[:]); err != nil {
+						return
+					}
+					query := make([]byte, binary.BigEndian.Uint16(size[:]))
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+					reply, err := zone.Answer(query)
+					if err != nil {
+						return
+					}
+					conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(reply))), reply...))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestParseServer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Server
+	}{
+		{"8.8.8.8", Server{"udp", "8.8.8.8:53"}},
+		{"1.1.1.1:5353", Server{"udp", "1.1.1.1:5353"}},
+		{"tcp://9.9.9.9", Server{"tcp", "9.9.9.9:53"}},
+		{"udp://[2001:4860:4860::8888]:53", Server{"udp", "[2001:4860:4860::8888]:53"}},
+		{"2606:4700:4700::1111", Server{"udp", "[2606:4700:4700::1111]:53"}},
+		{" https://dns.example/dns-query ", Server{"https", "https://dns.example/dns-query"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseServer(tt.input)
+		if err != nil {
+			t.Errorf("ParseServer(%q) error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseServer(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "dns.google", "http://dns.example/dns-query", "tcp://", "8.8.8.8:"} {
+		if _, err := ParseServer(invalid); !errors.Is(err, core.ErrInvalidResolver) {
+			t.Errorf("ParseServer(%q) error = %v, want ErrInvalidResolver", invalid, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	r, err := New(nil, 0)
+	if err != nil {
+		t.Fatalf("New(nil) error: %v", err)
+	}
+	if len(r.Servers()) != 0 || r.Timeout() != DefaultTimeout {
+		t.Errorf("New(nil) = %v servers, %v timeout; want the system resolver", r.Servers(), r.Timeout())
+	}
+
+	if _, err := New([]string{"8.8.8.8", "resolver.invalid"}, time.Second); !errors.Is(err, core.ErrInvalidResolver) {
+		t.Errorf("New() with an invalid server error = %v, want ErrInvalidResolver", err)
+	}
+}
+
+func TestLookupRoundRobin(t *testing.T) {
+	first, second := resolvertest.NewServer(), resolvertest.NewServer()
+	defer first.Close()
+	defer second.Close()
+	for _, zone := range []*resolvertest.Server{first, second} {
+		zone.AddA("origin.example.com", "192.0.2.1")
+		zone.AddMX("example.com", "mail.example.com")
+	}
+
+	r, err := New([]string{first.Addr, "udp://" + second.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		addrs, err := r.LookupIPv4(context.Background(), "origin.example.com")
+		if err != nil {
+			t.Fatalf("LookupIPv4() error: %v", err)
+		}
+		if !reflect.DeepEqual(addrs, []string{"192.0.2.1"}) {
+			t.Errorf("LookupIPv4() = %v, want [192.0.2.1]", addrs)
+		}
+	}
+	if first.Queries() == 0 || second.Queries() == 0 {
+		t.Errorf("queries = %d/%d, want both servers used", first.Queries(), second.Queries())
+	}
+
+	mx, err := r.LookupMX(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("LookupMX() error: %v", err)
+	}
+	if len(mx) != 1 || mx[0].Host != "mail.example.com." || mx[0].Pref != 10 {
+		t.Errorf("LookupMX() = %+v", mx)
+	}
+}
+
+func TestLookupTXT(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	long := "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 300)
+	zone.AddTXT("example.com", "v=spf1 ip4:192.0.2.0/28 -all", "google-site-verification=abc")
+	zone.AddTXT("selector1._domainkey.example.com", long)
+
+	r, err := New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	txt, err := r.LookupTXT(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("LookupTXT() error: %v", err)
+	}
+	if !reflect.DeepEqual(txt, []string{"v=spf1 ip4:192.0.2.0/28 -all", "google-site-verification=abc"}) {
+		t.Errorf("LookupTXT() = %q", txt)
+	}
+
+	// Values longer than one character string are split and joined back
+	txt, err = r.LookupTXT(context.Background(), "selector1._domainkey.example.com")
+	if err != nil || len(txt) != 1 || txt[0] != long {
+		t.Errorf("LookupTXT() = %q, %v; want the %d-byte record", txt, err, len(long))
+	}
+}
+
+func TestLookupHost_IPv6(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("origin.example.com", "192.0.2.1")
+	zone.AddAAAA("origin.example.com", "2001:db8::1")
+	zone.AddAAAA("v6.example.com", "2001:db8::2")
+
+	r, err := New([]string{zone.Addr}, time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	addrs, err := r.LookupHost(context.Background(), "origin.example.com")
+	if err != nil {
+		t.Fatalf("LookupHost() error: %v", err)
+	}
+	sort.Strings(addrs)
+	if !reflect.DeepEqual(addrs, []string{"192.0.2.1", "2001:db8::1"}) {
+		t.Errorf("LookupHost() = %v, want both address families", addrs)
+	}
+
+	// A name with only AAAA records exists and has no IPv4 addresses
+	if addrs, err := r.LookupHost(context.Background(), "v6.example.com"); err != nil || !reflect.DeepEqual(addrs, []string{"2001:db8::2"}) {
+		t.Errorf("LookupHost() = %v, %v; want [2001:db8::2]", addrs, err)
+	}
+	if addrs, err := r.LookupIPv4(context.Background(), "v6.example.com"); len(addrs) != 0 {
+		t.Errorf("LookupIPv4() = %v, %v; want no addresses", addrs, err)
+	}
+}
+
+func TestLookupTCP(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("origin.example.com", "192.0.2.2")
+
+	r, err := New([]string{"tcp://" + serveTCP(t, zone)}, time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	addrs, err := r.LookupHost(context.Background(), "origin.example.com")
+	if err != nil {
+		t.Fatalf("LookupHost() error: %v", err)
+	}
+	if !reflect.DeepEqual(addrs, []string{"192.0.2.2"}) {
+		t.Errorf("LookupHost() = %v, want [192.0.2.2]", addrs)
+	}
+}
+
+func TestLookupDoH(t *testing.T) {
+	zone := resolvertest.NewServer()
+	defer zone.Close()
+	zone.AddA("origin.example.com", "192.0.2.3")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/dns-message" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		reply, err := zone.Answer(query)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(reply)
+	}))
+	defer server.Close()
+
+	// ParseServer only accepts https:// URLs; the test server speaks plain HTTP
+	r, err := New([]string{"https://dns.example/dns-query"}, time.Second)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	r.servers[0].Address = server.URL + "/dns-query"
+
+	addrs, err := r.LookupIPv4(context.Background(), "origin.example.com")
+	if err != nil {
+		t.Fatalf("LookupIPv4() error: %v", err)
+	}
+	if !reflect.DeepEqual(addrs, []string{"192.0.2.3"}) {
+		t.Errorf("LookupIPv4() = %v, want [192.0.2.3]", addrs)
+	}
+}
+
+func TestLookupTimeout(t *testing.T) {
+	// A server that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	r, err := New([]string{conn.LocalAddr().String()}, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	start := time.Now()
+	if _, err := r.LookupHost(context.Background(), "origin.example.com"); err == nil {
+		t.Fatal("LookupHost() against a silent server should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("LookupHost() took %v, want the 200ms query timeout", elapsed)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	original := Default()
+	defer SetDefault(original)
+
+	r := System(time.Second)
+	SetDefault(r)
+	if Default() != r {
+		t.Error("Default() should return the resolver set by SetDefault")
+	}
+}
@@ -0,0 +1,181 @@
+// Package resolvertest provides an in-memory DNS server for tests
+package resolvertest
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Server is a UDP DNS server answering A, AAAA, MX and TXT queries from records added to it.
+// Names without records get NXDOMAIN; "*.zone" records answer every name under zone.
+type Server struct {
+	// Addr is the host:port the server listens on, usable as a resolver server
+	Addr string
+
+	conn    net.PacketConn
+	queries atomic.Int64
+
+	mu   sync.Mutex
+	a    map[string][][4]byte
+	aaaa map[string][][16]byte
+	mx   map[string][]string
+	txt  map[string][]string
+}
+
+// NewServer starts an empty server on a loopback UDP port; the caller must Close it
+func NewServer() *Server {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic("resolvertest: failed to listen on a port: " + err.Error())
+	}
+	s := &Server{
+		Addr: conn.LocalAddr().String(),
+		conn: conn,
+		a:    make(map[string][][4]byte),
+		aaaa: make(map[string][][16]byte),
+		mx:   make(map[string][]string),
+		txt:  make(map[string][]string),
+	}
+	go s.serve()
+	return s
+}
+
+// AddA adds IPv4 A records for name (which may be a "*.zone" wildcard)
+func (s *Server) AddA(name string, ips ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ip := range ips {
+		var a [4]byte
+		copy(a[:], net.ParseIP(ip).To4())
+		s.a[canonical(name)] = append(s.a[canonical(name)], a)
+	}
+}
+
+// AddAAAA adds IPv6 AAAA records for name (which may be a "*.zone" wildcard)
+func (s *Server) AddAAAA(name string, ips ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ip := range ips {
+		var aaaa [16]byte
+		copy(aaaa[:], net.ParseIP(ip).To16())
+		s.aaaa[canonical(name)] = append(s.aaaa[canonical(name)], aaaa)
+	}
+}
+
+// AddMX adds MX records for name, preferences 10, 20, ... in order
+func (s *Server) AddMX(name string, hosts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mx[canonical(name)] = append(s.mx[canonical(name)], hosts...)
+}
+
+// AddTXT adds TXT records for name, one per value
+func (s *Server) AddTXT(name string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txt[canonical(name)] = append(s.txt[canonical(name)], values...)
+}
+
+// Queries returns the number of queries received
+func (s *Server) Queries() int {
+	return int(s.queries.Load())
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.conn.Close()
+}
+
+// serve answers queries until the server is closed
+func (s *Server) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.queries.Add(1)
+		if reply, err := s.Answer(buf[:n]); err == nil {
+			s.conn.WriteTo(reply, addr)
+		}
+	}
+}
+
+// Answer builds the response to a DNS query message, for serving it over other transports
+func (s *Server) Answer(query []byte) ([]byte, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		return nil, err
+	}
+	msg.Header.Response = true
+	msg.Header.Authoritative = true
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range msg.Questions {
+		name := canonical(q.Name.String())
+		header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class, TTL: 60}
+		a, knownA := lookup(s.a, name)
+		aaaa, knownAAAA := lookup(s.aaaa, name)
+		switch q.Type {
+		case dnsmessage.TypeA:
+			for _, ip := range a {
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: ip}})
+			}
+		case dnsmessage.TypeAAAA:
+			for _, ip := range aaaa {
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AAAAResource{AAAA: ip}})
+			}
+		case dnsmessage.TypeMX:
+			for i, host := range s.mx[name] {
+				mx, err := dnsmessage.NewName(host + ".")
+				if err != nil {
+					return nil, err
+				}
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.MXResource{Pref: uint16(10 * (i + 1)), MX: mx}})
+			}
+		case dnsmessage.TypeTXT:
+			for _, value := range s.txt[name] {
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.TXTResource{TXT: splitTXT(value)}})
+			}
+		}
+		if !knownA && !knownAAAA && len(s.mx[name]) == 0 && len(s.txt[name]) == 0 {
+			msg.Header.RCode = dnsmessage.RCodeNameError
+		}
+	}
+	return msg.Pack()
+}
+
+// lookup returns the address records of name, falling back to the closest
+// wildcard, and whether the name has any
+func lookup[T any](records map[string][]T, name string) ([]T, bool) {
+	if addrs, ok := records[name]; ok {
+		return addrs, true
+	}
+	for parent := name; strings.Contains(parent, "."); {
+		parent = parent[strings.Index(parent, ".")+1:]
+		if addrs, ok := records["*."+parent]; ok {
+			return addrs, true
+		}
+	}
+	return nil, false
+}
+
+// splitTXT splits a TXT value into the 255-byte character strings DNS carries
+func splitTXT(value string) []string {
+	var parts []string
+	for len(value) > 255 {
+		parts = append(parts, value[:255])
+		value = value[255:]
+	}
+	return append(parts, value)
+}
+
+// canonical lowercases a name and strips the root dot
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,284 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/favicon"
+)
+
+// Baseline attributes a candidate response can match
+const (
+	MatchStatus  = "status"
+	MatchTitle   = "title"
+	MatchBody    = "body"
+	MatchFavicon = "favicon"
+	MatchHeaders = "headers"
+	MatchCookies = "cookies"
+)
+
+// identifyingHeaders are response headers set by the application rather than the CDN
+var identifyingHeaders = []string{"Server", "X-Powered-By", "X-Generator", "X-AspNet-Version", "X-AspNetMvc-Version"}
+
+// cdnServers are Server header values that identify the CDN rather than the origin
+var cdnServers = []string{"akamai", "bunnycdn", "cloudflare", "cloudfront", "ddos-guard", "fastly", "imperva", "incapsula", "stackpath", "sucuri", "varnish"}
+
+// Reference is the live site's profile candidates are matched against: the
+// similarity Baseline plus the attributes compared exactly
+type Reference struct {
+	*Baseline
+	BodyHash    string            // Body fingerprint, comparable with IPResult.BodyHash
+	Headers     map[string]string // Application headers (X-Powered-By, non-CDN Server, ...)
+	Cookies     []string          // Names of the cookies the site sets
+	FaviconHash int32             // Shodan-style favicon hash (0 if the site has none)
+}
+
+// FetchReference fetches the baseline, then records the body fingerprint,
+// application headers, cookie names and favicon hash of its final URL
+func (s *Scanner) FetchReference(ctx context.Context) (*Reference, error) {
+	b, err := s.FetchBaseline(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: sniTransport(s.client.Transport, "", false), // SNI follows each redirect's host
+		Timeout:   s.config.Timeout,
+	}
+	resp, body, err := s.fetchReference(ctx, client, b.URL, bodyReadLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reference profile for %s: %w", s.config.Domain, err)
+	}
+
+	r := &Reference{
+		Baseline: b,
+		BodyHash: bodyHash(body),
+		Headers:  applicationHeaders(resp.Header),
+		Cookies:  cookieNames(resp),
+	}
+
+	// A missing favicon is not an error: the attribute is simply not compared
+	if icon, err := resp.Request.URL.Parse(favicon.Href(body)); err == nil {
+		if iconResp, data, err := s.fetchReference(ctx, client, icon.String(), favicon.ReadLimit); err == nil && iconResp.StatusCode == http.StatusOK && len(data) > 0 {
+			r.FaviconHash = favicon.Hash(data)
+		}
+	}
+	return r, nil
+}
+
+// SetReference enables similarity scoring and attribute matching of every response against r
+func (s *Scanner) SetReference(r *Reference) {
+	s.reference = r
+	s.SetBaseline(r.Baseline)
+}
+
+// Matches returns the reference attributes a response shares, in a stable order
+func (r *Reference) Matches(result *core.IPResult, resp *http.Response) []string {
+	var matches []string
+	if result.HTTPCode == r.StatusCode {
+		matches = append(matches, MatchStatus)
+	}
+	if r.Title != "" && strings.EqualFold(strings.TrimSpace(result.Title), r.Title) {
+		matches = append(matches, MatchTitle)
+	}
+	if r.BodyHash != "" && result.BodyHash == r.BodyHash {
+		matches = append(matches, MatchBody)
+	}
+	if r.FaviconHash != 0 && result.FaviconHash == r.FaviconHash {
+		matches = append(matches, MatchFavicon)
+	}
+	if len(r.Headers) > 0 && mapsEqual(r.Headers, applicationHeaders(resp.Header)) {
+		matches = append(matches, MatchHeaders)
+	}
+	if len(r.Cookies) > 0 && containsAll(cookieNames(resp), r.Cookies) {
+		matches = append(matches, MatchCookies)
+	}
+	return matches
+}
+
+// fetchReference GETs a URL with the scan's User-Agent and custom headers
+func (s *Scanner) fetchReference(ctx context.Context, client *http.Client, url string, limit int64) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.config.NoUserAgent {
+		if ua := s.getUserAgent(); ua != "" {
+			req.Header.Set("User-Agent", ua)
+		}
+	}
+	s.setHeaders(req)
+
+	resp, err := s.throttledDo(ctx, client, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// inspectBody reads a response body to fingerprint it and compare it with the baseline
+// Bodies are read for 200 responses with --verify, and for every response once a
+// baseline is set. The favicon of 200 responses is hashed with --verify or when the
+// reference has one.
+func (s *Scanner) inspectBody(ctx context.Context, client *http.Client, result *core.IPResult, resp *http.Response) {
+	verify := s.config.VerifyContent && resp.StatusCode == http.StatusOK
+	if !verify && s.baseline == nil {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, bodyReadLimit))
+	if err != nil {
+		return
+	}
+	result.BodyHash = bodyHash(body)
+
+	// Extract HTML title if Content-Type is HTML
+	if strings.Contains(strings.ToLower(result.ContentType), "html") {
+		result.Title = extractTitle(string(body))
+	}
+
+	if resp.StatusCode == http.StatusOK && (verify || (s.reference != nil && s.reference.FaviconHash != 0)) {
+		result.FaviconHash = s.candidateFavicon(ctx, client, resp, body)
+	}
+
+	if s.baseline != nil {
+		result.Similarity = s.baseline.Similarity(resp.Header, body)
+	}
+	if s.reference != nil {
+		result.BaselineMatches = s.reference.Matches(result, resp)
+	}
+}
+
+// candidateFavicon hashes the favicon a candidate serves for the target (0 if none).
+// The icon is requested from the same IP and port as the page, with the page's Host
+// header; icons linked from other sites are ignored in favor of /favicon.ico.
+func (s *Scanner) candidateFavicon(ctx context.Context, client *http.Client, resp *http.Response, body []byte) int32 {
+	page := resp.Request.URL
+	host := resp.Request.Host
+	if host == "" {
+		host = s.config.Domain
+	}
+
+	icon, err := page.Parse(favicon.Href(body))
+	if err != nil || (icon.Host != page.Host && !strings.EqualFold(icon.Hostname(), (&url.URL{Host: host}).Hostname())) {
+		icon = &url.URL{Path: favicon.DefaultPath}
+	}
+	iconURL := page.ResolveReference(&url.URL{Path: icon.Path, RawPath: icon.RawPath, RawQuery: icon.RawQuery})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL.String(), nil)
+	if err != nil {
+		return 0
+	}
+	req.Host = host
+	if !s.config.NoUserAgent {
+		if ua := s.getUserAgent(); ua != "" {
+			req.Header.Set("User-Agent", ua)
+		}
+	}
+	s.setHeaders(req)
+
+	iconClient := &http.Client{
+		Transport: client.Transport,
+		Timeout:   client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse // A redirect would leave the candidate IP
+		},
+	}
+	iconResp, err := s.throttledDo(ctx, iconClient, req)
+	if err != nil {
+		return 0
+	}
+	defer iconResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(iconResp.Body, favicon.ReadLimit))
+	if err != nil || iconResp.StatusCode != http.StatusOK || len(data) == 0 {
+		return 0
+	}
+	return favicon.Hash(data)
+}
+
+// bodyHash returns the short SHA-256 fingerprint used for response bodies
+func bodyHash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])[:16] // First 16 chars
+}
+
+// applicationHeaders returns the identifying headers that were not set by a CDN
+func applicationHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range identifyingHeaders {
+		value := header.Get(name)
+		if value == "" || (name == "Server" && isCDNServer(value)) {
+			continue
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// isCDNServer reports whether a Server header value names a CDN or cache
+func isCDNServer(value string) bool {
+	value = strings.ToLower(value)
+	for _, cdn := range cdnServers {
+		if strings.Contains(value, cdn) {
+			return true
+		}
+	}
+	return false
+}
+
+// cookieNames returns the sorted names of the cookies a response sets
+func cookieNames(resp *http.Response) []string {
+	var names []string
+	for _, cookie := range resp.Cookies() {
+		if !containsAll(names, []string{cookie.Name}) {
+			names = append(names, cookie.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsAll reports whether every value of want is in have
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// mapsEqual reports whether two string maps hold the same entries
+func mapsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
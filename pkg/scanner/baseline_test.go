@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/favicon"
+)
+
+func TestScanner_FetchReference(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/static/icon.png" {
+			w.Write([]byte("icon-bytes"))
+			return
+		}
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("X-Powered-By", "PHP/8.2")
+		w.Header().Set("Server", "cloudflare")
+		http.SetCookie(w, &http.Cookie{Name: "laravel_session", Value: strconv.Itoa(requests)})
+		http.SetCookie(w, &http.Cookie{Name: "XSRF-TOKEN", Value: "x"})
+		page := fmt.Sprintf(similarityPage, strconv.Itoa(requests), "token-"+strconv.Itoa(requests), time.Now().Format(time.StampNano))
+		w.Write([]byte(`<link rel="shortcut icon" href="/static/icon.png">` + page))
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// The test server stands in for both the CDN (via the domain) and the origin (via the IP)
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     server.Listener.Addr().String(),
+		HTTPMethod: "GET",
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	reference, err := s.FetchReference(context.Background())
+	if err != nil {
+		t.Fatalf("FetchReference() error: %v", err)
+	}
+	if reference.StatusCode != 200 || reference.Title != "Shop" || reference.URL != server.URL+"/" || reference.BodyHash == "" {
+		t.Errorf("FetchReference() = %+v, want the HTTP homepage", reference)
+	}
+	if want := map[string]string{"X-Powered-By": "PHP/8.2"}; !reflect.DeepEqual(reference.Headers, want) {
+		t.Errorf("Headers = %v, want %v (CDN Server header excluded)", reference.Headers, want)
+	}
+	if want := []string{"XSRF-TOKEN", "laravel_session"}; !reflect.DeepEqual(reference.Cookies, want) {
+		t.Errorf("Cookies = %v, want %v", reference.Cookies, want)
+	}
+	if reference.FaviconHash != favicon.Hash([]byte("icon-bytes")) {
+		t.Errorf("FaviconHash = %d, want the hash of the linked icon", reference.FaviconHash)
+	}
+	s.SetReference(reference)
+
+	// Same page with new tokens: everything but the exact body matches
+	result := s.scanIP(context.Background(), net.ParseIP("127.0.0.1"), port)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+	if result.FaviconHash != reference.FaviconHash {
+		t.Errorf("candidate FaviconHash = %d, want %d", result.FaviconHash, reference.FaviconHash)
+	}
+	if want := []string{MatchStatus, MatchTitle, MatchFavicon, MatchHeaders, MatchCookies}; !reflect.DeepEqual(result.BaselineMatches, want) {
+		t.Errorf("BaselineMatches = %v, want %v", result.BaselineMatches, want)
+	}
+	if result.Similarity < 0.9 {
+		t.Errorf("Similarity = %v, want >= 0.9 for the same page with new tokens", result.Similarity)
+	}
+
+	unreachable, _ := New(&core.Config{Timeout: time.Second, Workers: 1, Domain: "127.0.0.1:1"})
+	if _, err := unreachable.FetchReference(context.Background()); err == nil {
+		t.Error("FetchReference() on a closed port should fail")
+	}
+}
+
+func TestReference_Matches(t *testing.T) {
+	r := &Reference{Baseline: &Baseline{StatusCode: 200, Title: "Shop"}, BodyHash: "abc", FaviconHash: -42, Headers: map[string]string{"X-Powered-By": "Express"}}
+
+	resp := &http.Response{Header: http.Header{"X-Powered-By": {"Express"}, "Server": {"cloudflare"}}}
+	got := r.Matches(&core.IPResult{HTTPCode: 200, Title: " shop ", BodyHash: "abc", FaviconHash: -42}, resp)
+	if want := []string{MatchStatus, MatchTitle, MatchBody, MatchFavicon, MatchHeaders}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Matches() = %v, want %v", got, want)
+	}
+
+	// A default vhost shares nothing; attributes the reference lacks are never matched
+	got = (&Reference{Baseline: &Baseline{StatusCode: 200}}).Matches(&core.IPResult{HTTPCode: 403, Title: "Forbidden"}, &http.Response{Header: http.Header{}})
+	if len(got) != 0 {
+		t.Errorf("Matches() = %v, want none", got)
+	}
+}
@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// recordCert captures the leaf certificate of an HTTPS response into the result.
+// An IP whose certificate SANs cover the target domain is marked as a possible origin
+// regardless of the HTTP status, since a default vhost or 403 still presents the cert.
+// Known CDN/WAF edges serve the domain's certificate by design and are never promoted.
+func (s *Scanner) recordCert(result *core.IPResult, resp *http.Response) {
+	leaf := leafCertificate(resp.TLS)
+	if leaf == nil {
+		return
+	}
+
+	result.Cert = certInfo(leaf)
+	if provider, ok := s.edgeProvider(result.IP); ok {
+		result.Provider = provider
+		return
+	}
+	if certCoversDomain(leaf, s.config.Domain) {
+		result.CertMatch = true
+		result.PossibleOrigin = true
+		result.PossibleOriginDest = s.config.Domain
+	}
+}
+
+// edgeProvider returns the CDN/WAF provider whose ranges contain ip, if any
+func (s *Scanner) edgeProvider(ip string) (string, bool) {
+	if s.edges == nil {
+		return "", false
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", false
+	}
+	return s.edges.FindProvider(addr)
+}
+
+// leafCertificate returns the first peer certificate of a TLS connection, if any
+func leafCertificate(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
+
+// certInfo converts an x509 certificate into the result representation
+func certInfo(cert *x509.Certificate) *core.CertInfo {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	issuer := cert.Issuer.CommonName
+	if issuer == "" {
+		issuer = cert.Issuer.String()
+	}
+
+	return &core.CertInfo{
+		Subject:     cert.Subject.CommonName,
+		SANs:        sans,
+		Issuer:      issuer,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+	}
+}
+
+// certCoversDomain reports whether the certificate's SANs (including wildcards) cover domain
+func certCoversDomain(cert *x509.Certificate, domain string) bool {
+	if domain == "" {
+		return false
+	}
+	return cert.VerifyHostname(domain) == nil
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+	"github.com/jhaxce/origindive/v3/pkg/waf"
+)
+
+func TestScanner_RecordCert(t *testing.T) {
+	// httptest's certificate carries example.com and 127.0.0.1 as SANs
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		domain    string
+		wantMatch bool
+	}{
+		{"SAN covers domain", "example.com", true},
+		{"SAN does not cover domain", "other.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &core.Config{
+				Timeout:    2 * time.Second,
+				Workers:    1,
+				Domain:     tt.domain,
+				HTTPMethod: "GET",
+				Scheme:     core.SchemeHTTPS,
+			}
+			s, err := New(config)
+			if err != nil {
+				t.Fatalf("New() error: %v", err)
+			}
+			redirectDial(t, s, server.Listener.Addr().String())
+
+			result := s.scanIP(context.Background(), net.ParseIP("192.0.2.10"), 0)
+			if result.Status != "4xx" {
+				t.Fatalf("Status = %q, want 4xx (error: %s)", result.Status, result.Error)
+			}
+			if result.Cert == nil {
+				t.Fatal("Cert = nil, want leaf certificate")
+			}
+			if len(result.Cert.Fingerprint) != 64 {
+				t.Errorf("Fingerprint = %q, want 64 hex chars", result.Cert.Fingerprint)
+			}
+			if len(result.Cert.SANs) == 0 {
+				t.Error("SANs empty, want certificate SANs")
+			}
+			if result.CertMatch != tt.wantMatch {
+				t.Errorf("CertMatch = %v, want %v", result.CertMatch, tt.wantMatch)
+			}
+			if result.PossibleOrigin != tt.wantMatch {
+				t.Errorf("PossibleOrigin = %v, want %v", result.PossibleOrigin, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestScanner_RecordCert_EdgeIP(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	// A CDN edge presents the domain's certificate without being the origin
+	dbPath := filepath.Join(t.TempDir(), "waf_ranges.json")
+	db := &waf.WAFDatabase{Providers: []waf.Provider{{ID: "cloudflare", Name: "Cloudflare", Ranges: []string{"192.0.2.0/24"}}}}
+	if err := waf.SaveWAFDatabase(dbPath, db); err != nil {
+		t.Fatalf("SaveWAFDatabase() error: %v", err)
+	}
+
+	config := &core.Config{
+		Timeout:         2 * time.Second,
+		Workers:         1,
+		Domain:          "example.com",
+		HTTPMethod:      "GET",
+		Scheme:          core.SchemeHTTPS,
+		WAFDatabasePath: dbPath,
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	redirectDial(t, s, server.Listener.Addr().String())
+
+	edge := s.scanIP(context.Background(), net.ParseIP("192.0.2.10"), 0)
+	if edge.Cert == nil {
+		t.Fatal("Cert = nil, want the edge certificate recorded")
+	}
+	if edge.CertMatch || edge.PossibleOrigin {
+		t.Errorf("edge CertMatch = %v, PossibleOrigin = %v, want both false", edge.CertMatch, edge.PossibleOrigin)
+	}
+	if edge.Provider != "cloudflare" {
+		t.Errorf("edge Provider = %q, want cloudflare", edge.Provider)
+	}
+
+	origin := s.scanIP(context.Background(), net.ParseIP("198.51.100.10"), 0)
+	if !origin.CertMatch || !origin.PossibleOrigin {
+		t.Errorf("origin CertMatch = %v, PossibleOrigin = %v, want both true", origin.CertMatch, origin.PossibleOrigin)
+	}
+}
+
+func TestScanner_RecordCert_PlainHTTP(t *testing.T) {
+	s := &Scanner{config: &core.Config{Domain: "example.com"}}
+	result := &core.IPResult{}
+	s.recordCert(result, &http.Response{})
+	if result.Cert != nil || result.CertMatch {
+		t.Errorf("recordCert() on plain HTTP set Cert=%v CertMatch=%v", result.Cert, result.CertMatch)
+	}
+}
+
+func TestScanner_Scan_CertMatchIsPossibleOrigin(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Scheme:     core.SchemeHTTPS,
+		IPRanges:   [][2]netip.Addr{{netip.MustParseAddr("192.0.2.10"), netip.MustParseAddr("192.0.2.10")}},
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	redirectDial(t, s, server.Listener.Addr().String())
+
+	result, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(result.Other) != 1 || !result.Other[0].CertMatch {
+		t.Fatalf("Other = %v, want the cert-matching 403", result.Other)
+	}
+	if len(result.Summary.PossibleOriginRelatedIPs) != 1 || result.Summary.PossibleOriginRelatedIPs[0] != "192.0.2.10" {
+		t.Errorf("PossibleOriginRelatedIPs = %v, want [192.0.2.10]", result.Summary.PossibleOriginRelatedIPs)
+	}
+}
@@ -0,0 +1,201 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+// DefaultCheckpointInterval is how often a running scan saves its checkpoint
+const DefaultCheckpointInterval = 30 * time.Second
+
+// checkpointVersion is bumped when the checkpoint format changes incompatibly
+const checkpointVersion = 1
+
+// Checkpoint is the saved state of an active scan
+// Completed counts IPs, from the start of the configured ranges, whose probes
+// have all finished; a resumed scan continues with the next IP.
+type Checkpoint struct {
+	Version     int              `json:"version"`
+	SavedAt     time.Time        `json:"saved_at"`
+	Config      *core.Config     `json:"config"`                 // Scan settings, without API keys or header values
+	HeaderNames []string         `json:"header_names,omitempty"` // Custom headers (-H) that must be given again on resume
+	IPRanges    [][2]netip.Addr  `json:"ip_ranges"`              // Ranges being scanned (not serialized with Config)
+	Completed   uint64           `json:"completed"`
+	Scanned     uint64           `json:"scanned"`
+	Skipped     uint64           `json:"skipped"`
+	Results     []*core.IPResult `json:"results"`             // Results of the completed IPs
+	Passive     []core.PassiveIP `json:"passive,omitempty"`   // Passive results an auto-mode scan was built from
+	Hostnames   []core.Hostname  `json:"hostnames,omitempty"` // Hostnames found by passive recon in auto mode
+}
+
+// LoadCheckpoint reads a checkpoint written by a previous scan
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", cp.Version)
+	}
+	if cp.Config == nil || len(cp.IPRanges) == 0 {
+		return nil, fmt.Errorf("checkpoint has no IP ranges")
+	}
+	cp.Config.IPRanges = cp.IPRanges
+
+	return &cp, nil
+}
+
+// Save writes the checkpoint atomically (temp file + rename)
+func (cp *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// checkpointConfig returns a copy of config that is safe to store in a checkpoint
+func checkpointConfig(config *core.Config) *core.Config {
+	saved := *config
+	saved.ShodanKeys = nil
+	saved.CensysTokens = nil
+	saved.SecurityTrailsKeys = nil
+	saved.ZoomEyeKeys = nil
+	saved.DNSDumpsterKeys = nil
+	saved.VirusTotalKeys = nil
+	saved.ViewDNSKeys = nil
+	saved.HunterKeys = nil
+	saved.WebshareAPIKey = ""
+	saved.Headers = nil // may carry credentials (Authorization, Cookie)
+	saved.ResumeFile = ""
+	return &saved
+}
+
+// headerNames returns the names of the custom headers in config
+func headerNames(config *core.Config) []string {
+	var names []string
+	for _, line := range config.Headers {
+		name, _, _ := strings.Cut(line, ":")
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+// pendingIP collects the outcome of an IP whose probes have not all finished
+type pendingIP struct {
+	probes  int
+	scanned uint64
+	skipped uint64
+	results []*core.IPResult
+}
+
+// checkpointTracker keeps the low-water mark of fully probed IPs
+// Workers finish jobs out of order, so an IP only counts as completed once it
+// and every IP before it are done.
+type checkpointTracker struct {
+	mu        sync.Mutex
+	ports     int
+	completed uint64
+	scanned   uint64
+	skipped   uint64
+	results   []*core.IPResult
+	pending   map[uint64]*pendingIP
+}
+
+// newCheckpointTracker creates a tracker, optionally continuing from a checkpoint
+func newCheckpointTracker(ports int, resume *Checkpoint) *checkpointTracker {
+	t := &checkpointTracker{
+		ports:   ports,
+		pending: make(map[uint64]*pendingIP),
+	}
+	if resume != nil {
+		t.completed = resume.Completed
+		t.scanned = resume.Scanned
+		t.skipped = resume.Skipped
+		t.results = append(t.results, resume.Results...)
+	}
+	return t
+}
+
+// done records one finished probe of the IP at position seq
+// result is nil when the probe produced nothing worth reporting
+func (t *checkpointTracker) done(seq uint64, skipped bool, result *core.IPResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.pending[seq]
+	if p == nil {
+		p = &pendingIP{}
+		t.pending[seq] = p
+	}
+	p.probes++
+	if skipped {
+		p.skipped++
+	} else {
+		p.scanned++
+	}
+	if result != nil {
+		p.results = append(p.results, result)
+	}
+
+	// Advance the mark over every contiguous fully probed IP
+	for {
+		next := t.pending[t.completed]
+		if next == nil || next.probes < t.ports {
+			return
+		}
+		t.scanned += next.scanned
+		t.skipped += next.skipped
+		t.results = append(t.results, next.results...)
+		delete(t.pending, t.completed)
+		t.completed++
+	}
+}
+
+// snapshot returns a checkpoint of the completed IPs
+func (t *checkpointTracker) snapshot(config *core.Config) *Checkpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &Checkpoint{
+		Version:     checkpointVersion,
+		SavedAt:     time.Now(),
+		Config:      checkpointConfig(config),
+		HeaderNames: headerNames(config),
+		IPRanges:    config.IPRanges,
+		Completed:   t.completed,
+		Scanned:     t.scanned,
+		Skipped:     t.skipped,
+		Results:     append([]*core.IPResult(nil), t.results...),
+	}
+}
@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+func TestCheckpointTracker_OutOfOrder(t *testing.T) {
+	tr := newCheckpointTracker(2, nil)
+
+	// IP 1 finishes before IP 0: nothing is completed yet
+	tr.done(1, false, &core.IPResult{IP: "192.0.2.2"})
+	tr.done(1, true, nil)
+	tr.done(0, false, &core.IPResult{IP: "192.0.2.1"})
+	if cp := tr.snapshot(&core.Config{}); cp.Completed != 0 || len(cp.Results) != 0 {
+		t.Fatalf("snapshot = %d completed, %d results; want 0, 0", cp.Completed, len(cp.Results))
+	}
+
+	// The second probe of IP 0 completes both
+	tr.done(0, false, nil)
+	cp := tr.snapshot(&core.Config{})
+	if cp.Completed != 2 || cp.Scanned != 3 || cp.Skipped != 1 {
+		t.Errorf("snapshot = %d completed, %d scanned, %d skipped; want 2, 3, 1", cp.Completed, cp.Scanned, cp.Skipped)
+	}
+	if len(cp.Results) != 2 || cp.Results[0].IP != "192.0.2.1" || cp.Results[1].IP != "192.0.2.2" {
+		t.Errorf("snapshot results = %v, want 192.0.2.1 then 192.0.2.2", cp.Results)
+	}
+
+	// Partial progress on IP 2 is not saved
+	tr.done(2, false, &core.IPResult{IP: "192.0.2.3"})
+	if cp := tr.snapshot(&core.Config{}); cp.Completed != 2 || len(cp.Results) != 2 {
+		t.Errorf("snapshot after partial IP = %d completed, %d results; want 2, 2", cp.Completed, len(cp.Results))
+	}
+}
+
+func TestCheckpoint_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.checkpoint.json")
+	addr := netip.MustParseAddr("192.0.2.1")
+
+	config := &core.Config{
+		Domain:     "example.com",
+		IPRanges:   [][2]netip.Addr{{addr, addr}},
+		Ports:      []int{443},
+		ShodanKeys: []string{"secret-key"},
+		Headers:    []string{"Authorization: Bearer secret-token"},
+		ResumeFile: path,
+	}
+	tr := newCheckpointTracker(1, nil)
+	tr.done(0, false, &core.IPResult{IP: "192.0.2.1", Status: "200"})
+
+	if err := tr.snapshot(config).Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "secret-key") {
+		t.Error("checkpoint contains an API key")
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "secret-token") {
+		t.Error("checkpoint contains a header value")
+	}
+	if len(config.ShodanKeys) != 1 || len(config.Headers) != 1 {
+		t.Error("Save() modified the live config")
+	}
+
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error: %v", err)
+	}
+	if cp.Completed != 1 || cp.Config.Domain != "example.com" || cp.Config.IPRanges[0][0] != addr || len(cp.Results) != 1 {
+		t.Errorf("LoadCheckpoint() = %+v, want the saved state", cp)
+	}
+	if !reflect.DeepEqual(cp.HeaderNames, []string{"Authorization"}) || cp.Config.Headers != nil {
+		t.Errorf("LoadCheckpoint() headers = %v / %v, want only the header name", cp.HeaderNames, cp.Config.Headers)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid JSON", "{"},
+		{"wrong version", `{"version": 99, "config": {}, "ip_ranges": [["192.0.2.1", "192.0.2.1"]]}`},
+		{"no ranges", `{"version": 1, "config": {"domain": "example.com"}}`},
+	}
+	for _, tt := range tests {
+		bad := filepath.Join(t.TempDir(), "bad.json")
+		os.WriteFile(bad, []byte(tt.content), 0644)
+		if _, err := LoadCheckpoint(bad); err == nil {
+			t.Errorf("LoadCheckpoint(%s) should fail", tt.name)
+		}
+	}
+	if _, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadCheckpoint(missing) should fail")
+	}
+}
+
+func TestScanner_Scan_InterruptAndResume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	path := filepath.Join(t.TempDir(), "scan.checkpoint.json")
+
+	// 127.0.0.1 answers; the other loopback addresses refuse the server's port
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Ports:      []int{port},
+		IPRanges:   [][2]netip.Addr{{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("127.0.0.3")}},
+	}
+
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	s.SetCheckpoint(path, time.Hour)
+	passive := []core.PassiveIP{{IP: "127.0.0.1", Source: "ct", Hostname: "www.example.com", Sources: []string{"ct"}}}
+	hostnames := []core.Hostname{{Name: "www.example.com", IPs: []string{"127.0.0.1"}, Sources: []string{"ct"}}}
+	s.SetPassive(passive, hostnames)
+
+	// Interrupt while the second IP is being probed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.SetProgressCallback(func(scanned, _ uint64) {
+		if scanned == 2 {
+			cancel()
+		}
+	})
+
+	_, err = s.Scan(ctx)
+	if !errors.Is(err, core.ErrScanInterrupted) {
+		t.Fatalf("Scan() error = %v, want ErrScanInterrupted", err)
+	}
+
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint() error: %v", err)
+	}
+	if cp.Completed != 1 || cp.Scanned != 1 || len(cp.Results) != 1 || cp.Results[0].IP != "127.0.0.1" {
+		t.Fatalf("checkpoint = %d completed, %d scanned, results %v; want 127.0.0.1 only", cp.Completed, cp.Scanned, cp.Results)
+	}
+	if !reflect.DeepEqual(cp.Passive, passive) || !reflect.DeepEqual(cp.Hostnames, hostnames) {
+		t.Errorf("checkpoint passive = %+v, %+v; want the auto-mode passive results", cp.Passive, cp.Hostnames)
+	}
+
+	// Resume with a fresh scanner built from the saved configuration
+	resumed, err := New(cp.Config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	resumed.SetCheckpoint(path, time.Hour)
+	resumed.Resume(cp)
+	if !reflect.DeepEqual(resumed.passive, passive) {
+		t.Errorf("resumed passive = %+v, want it kept for later checkpoints", resumed.passive)
+	}
+
+	var probed []uint64
+	resumed.SetProgressCallback(func(scanned, _ uint64) { probed = append(probed, scanned) })
+
+	result, err := resumed.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("resumed Scan() error: %v", err)
+	}
+	if len(probed) != 2 || probed[0] != 2 {
+		t.Errorf("progress after resume = %v, want [2 3]", probed)
+	}
+	if result.Summary.ScannedIPs != 3 {
+		t.Errorf("ScannedIPs = %d, want 3", result.Summary.ScannedIPs)
+	}
+	if len(result.Success) != 1 || result.Success[0].IP != "127.0.0.1" {
+		t.Errorf("Success = %v, want the checkpointed 127.0.0.1", result.Success)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("checkpoint should be removed after the scan completes")
+	}
+}
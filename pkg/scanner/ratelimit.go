@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultAdaptiveRate is the ceiling used by adaptive rate limiting when no
+// explicit rate limit is configured (requests per second)
+const DefaultAdaptiveRate = 100.0
+
+const (
+	adaptiveWindow    = 20   // Probe outcomes evaluated per adjustment
+	adaptiveSlowDown  = 0.25 // Failure ratio that halves the rate
+	adaptiveSpeedUp   = 0.05 // Failure ratio at or below which the rate recovers
+	adaptiveMinFactor = 32   // The rate never drops below ceiling/adaptiveMinFactor
+)
+
+// rateLimiter is a token bucket shared by every worker
+// The bucket holds a single token so the rate is never exceeded, even in
+// bursts. In adaptive mode the rate is halved when timeouts and errors spike
+// and raised back towards the ceiling once they clear.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // Current requests per second
+	ceiling  float64 // Configured maximum requests per second
+	adaptive bool
+	tokens   float64
+	last     time.Time
+	observed int // Outcomes in the current adaptive window
+	failed   int // Failures in the current adaptive window
+}
+
+// newRateLimiter creates a limiter allowing rps requests per second
+func newRateLimiter(rps float64, adaptive bool) *rateLimiter {
+	return &rateLimiter{
+		rate:     rps,
+		ceiling:  rps,
+		adaptive: adaptive,
+		tokens:   1,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a request may be sent or ctx is done
+// Each caller reserves its slot up front, so waiting workers are served in order;
+// a caller giving up hands its slot back.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > 1 {
+		l.tokens = 1
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		if l.tokens > 1 {
+			l.tokens = 1
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe records the outcome of a probe and adjusts the rate in adaptive mode
+func (l *rateLimiter) observe(failed bool) {
+	if !l.adaptive {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.observed++
+	if failed {
+		l.failed++
+	}
+	if l.observed < adaptiveWindow {
+		return
+	}
+
+	ratio := float64(l.failed) / float64(l.observed)
+	switch {
+	case ratio >= adaptiveSlowDown:
+		l.rate /= 2
+		if floor := l.ceiling / adaptiveMinFactor; l.rate < floor {
+			l.rate = floor
+		}
+	case ratio <= adaptiveSpeedUp:
+		l.rate += l.ceiling / 10
+		if l.rate > l.ceiling {
+			l.rate = l.ceiling
+		}
+	}
+	l.observed, l.failed = 0, 0
+}
+
+// current returns the rate currently enforced
+func (l *rateLimiter) current() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := newRateLimiter(50, false)
+
+	// 10 requests at 50 req/s take at least 9 intervals of 20ms, even when concurrent
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.wait(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 170*time.Millisecond {
+		t.Errorf("10 requests took %v, want at least 180ms at 50 req/s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.wait(context.Background())
+	if err := l.wait(ctx); err == nil {
+		t.Error("wait() should return the context error when cancelled")
+	}
+}
+
+func TestRateLimiter_WaitCancelReturnsSlot(t *testing.T) {
+	l := newRateLimiter(5, false)
+	l.wait(context.Background()) // Use the initial token
+
+	// Callers giving up on their 200ms slots must not delay the next caller
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if err := l.wait(ctx); err == nil {
+			t.Error("wait() should fail when the context expires first")
+		}
+		cancel()
+	}
+
+	start := time.Now()
+	l.wait(context.Background())
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("wait() after cancelled callers took %v, want one 200ms interval at most", elapsed)
+	}
+}
+
+func TestRateLimiter_Adaptive(t *testing.T) {
+	l := newRateLimiter(100, true)
+
+	window := func(failures int) {
+		for i := 0; i < adaptiveWindow; i++ {
+			l.observe(i < failures)
+		}
+	}
+
+	window(10)
+	if got := l.current(); got != 50 {
+		t.Errorf("rate after failure spike = %v, want 50", got)
+	}
+
+	// Moderate failures hold the rate
+	window(2)
+	if got := l.current(); got != 50 {
+		t.Errorf("rate after moderate failures = %v, want 50", got)
+	}
+
+	window(0)
+	if got := l.current(); got != 60 {
+		t.Errorf("rate after clean window = %v, want 60", got)
+	}
+
+	// The rate is bounded by the floor and the ceiling
+	for i := 0; i < 10; i++ {
+		window(adaptiveWindow)
+	}
+	if got := l.current(); got != 100.0/adaptiveMinFactor {
+		t.Errorf("rate after sustained failures = %v, want floor %v", got, 100.0/adaptiveMinFactor)
+	}
+	for i := 0; i < 20; i++ {
+		window(0)
+	}
+	if got := l.current(); got != 100 {
+		t.Errorf("rate after recovery = %v, want ceiling 100", got)
+	}
+
+	// Without adaptive mode the rate never changes
+	fixed := newRateLimiter(10, false)
+	for i := 0; i < adaptiveWindow; i++ {
+		fixed.observe(true)
+	}
+	if got := fixed.current(); got != 10 {
+		t.Errorf("fixed rate = %v, want 10", got)
+	}
+}
+
+func TestScanner_RateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var hits []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	config := &core.Config{
+		Timeout:      2 * time.Second,
+		Workers:      5,
+		Domain:       "example.com",
+		HTTPMethod:   "GET",
+		RateLimit:    40,
+		AdaptiveRate: true,
+	}
+
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// Every probe is throttled and a run of 429s halves the rate
+	for i := 0; i < adaptiveWindow; i++ {
+		s.observe(s.scanIP(context.Background(), net.ParseIP("127.0.0.1"), port))
+	}
+	if len(hits) != adaptiveWindow {
+		t.Fatalf("server received %d requests, want %d", len(hits), adaptiveWindow)
+	}
+	if elapsed := hits[len(hits)-1].Sub(hits[0]); elapsed < 450*time.Millisecond {
+		t.Errorf("%d requests took %v, want at least 475ms at 40 req/s", len(hits), elapsed)
+	}
+	if got := s.Rate(); got != 20 {
+		t.Errorf("Rate() after 429s = %v, want 20", got)
+	}
+
+	unlimited, _ := New(&core.Config{Workers: 1})
+	if unlimited.Rate() != 0 {
+		t.Errorf("Rate() without a limit = %v, want 0", unlimited.Rate())
+	}
+	adaptive, _ := New(&core.Config{Workers: 1, AdaptiveRate: true})
+	if adaptive.Rate() != DefaultAdaptiveRate {
+		t.Errorf("Rate() with adaptive only = %v, want %v", adaptive.Rate(), DefaultAdaptiveRate)
+	}
+}
@@ -11,8 +11,10 @@ import (
 	"math/rand"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
-	"regexp"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,15 +25,33 @@ import (
 	"github.com/jhaxce/origindive/v3/pkg/core"
 	"github.com/jhaxce/origindive/v3/pkg/ip"
 	"github.com/jhaxce/origindive/v3/pkg/output"
+	"github.com/jhaxce/origindive/v3/pkg/page"
 	"github.com/jhaxce/origindive/v3/pkg/proxy"
+	"github.com/jhaxce/origindive/v3/pkg/resolver"
 	"github.com/jhaxce/origindive/v3/pkg/waf"
 )
 
+// scanJob is a single (IP, port) probe; port 0 means the scheme's default port
+// seq is the IP's position in the scanned ranges, used for checkpointing
+type scanJob struct {
+	ip   netip.Addr
+	port int
+	seq  uint64
+}
+
+// httpsPorts and httpPorts map well-known ports (including Cloudflare's proxied
+// alternates) to the scheme they are served on
+var (
+	httpsPorts = map[int]bool{443: true, 2053: true, 2083: true, 2087: true, 2096: true, 8443: true}
+	httpPorts  = map[int]bool{80: true, 2052: true, 2082: true, 2086: true, 2095: true, 8080: true, 8880: true}
+)
+
 // Scanner performs HTTP-based origin IP discovery
 type Scanner struct {
 	config           *core.Config
 	client           *http.Client
 	wafFilter        *waf.Filter
+	edges            *waf.RangeSet  // Known CDN/WAF ranges, never origins by certificate alone
 	proxyList        []*proxy.Proxy // List of proxies for rotation
 	proxyIndex       uint64         // Atomic counter for proxy rotation
 	mu               sync.Mutex
@@ -39,6 +59,18 @@ type Scanner struct {
 	progressCallback func(scanned, total uint64) // Progress update callback
 	resultCallback   func(result *core.IPResult) // Real-time result callback
 	progressStopper  func()                      // Function to stop progress display
+
+	checkpointPath     string             // Checkpoint file ("" = checkpointing disabled)
+	checkpointInterval time.Duration      // How often the checkpoint is saved
+	resume             *Checkpoint        // Checkpoint to continue from
+	tracker            *checkpointTracker // Completed-IP tracking while checkpointing
+	passive            []core.PassiveIP   // Passive results saved with checkpoints (auto mode)
+	hostnames          []core.Hostname    // Passive hostnames saved with checkpoints (auto mode)
+
+	limiter   *rateLimiter // Request rate limit (nil = unlimited)
+	headers   http.Header  // Custom headers sent with every request
+	baseline  *Baseline    // Live site response for similarity scoring (nil = disabled)
+	reference *Reference   // Live site profile for attribute matching (nil = disabled)
 }
 
 // New creates a new scanner with the given configuration
@@ -47,6 +79,11 @@ func New(config *core.Config) (*Scanner, error) {
 		return nil, core.ErrInvalidConfig
 	}
 
+	headers, err := core.ParseHeaders(config.Headers)
+	if err != nil {
+		return nil, err
+	}
+
 	var proxyClient *http.Client
 	var proxyList []*proxy.Proxy
 
@@ -158,16 +195,19 @@ func New(config *core.Config) (*Scanner, error) {
 	var client *http.Client
 	if proxyClient != nil {
 		client = proxyClient
+		client.Transport = sniTransport(client.Transport, config.Domain, false)
 	} else {
 		// Standard client without proxy
 		client = &http.Client{
 			Timeout: config.Timeout,
 			Transport: &http.Transport{
 				DialContext: (&net.Dialer{
-					Timeout: config.ConnectTimeout,
+					Timeout:  config.ConnectTimeout,
+					Resolver: resolver.Default().Net(), // Used by the baseline fetch of the domain
 				}).DialContext,
 				TLSClientConfig: &tls.Config{
 					InsecureSkipVerify: true, // Required for testing origin servers
+					ServerName:         config.Domain,
 				},
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: config.Workers,
@@ -183,25 +223,42 @@ func New(config *core.Config) (*Scanner, error) {
 		config:    config,
 		client:    client,
 		proxyList: proxyList,
+		headers:   headers,
 	}
 
-	// Load WAF filter if enabled and database path is set
-	if config.SkipWAF {
-		wafPath := config.WAFDatabasePath
-		if wafPath == "" {
-			wafPath = "data/waf_ranges.json" // Default path
+	// Cap the request rate; adaptive mode without a limit starts from a default ceiling
+	if config.RateLimit > 0 || config.AdaptiveRate {
+		rps := config.RateLimit
+		if rps <= 0 {
+			rps = DefaultAdaptiveRate
 		}
+		s.limiter = newRateLimiter(rps, config.AdaptiveRate)
+	}
 
-		// Only load if we can access the file (handles test case with empty path)
-		db, err := waf.LoadWAFDatabase(wafPath)
+	// Load the WAF database: its ranges filter targets when SkipWAF is set and
+	// keep CDN edges presenting the domain's certificate from counting as origins
+	wafPath := config.WAFDatabasePath
+	if wafPath == "" {
+		wafPath = "data/waf_ranges.json" // Default path
+	}
+
+	// Only load if we can access the file (handles test case with empty path)
+	db, err := waf.LoadWAFDatabase(wafPath)
+	if err != nil {
+		// If using default path and it doesn't exist, silently skip
+		// If using custom path, this is an error
+		if config.SkipWAF && config.WAFDatabasePath != "" {
+			return nil, fmt.Errorf("failed to load WAF database from %s: %w", wafPath, err)
+		}
+		// Database not found - continue without WAF filtering
+	} else {
+		edges, err := waf.LoadFromDatabase(db, nil)
 		if err != nil {
-			// If using default path and it doesn't exist, silently skip
-			// If using custom path, this is an error
-			if config.WAFDatabasePath != "" {
-				return nil, fmt.Errorf("failed to load WAF database from %s: %w", wafPath, err)
-			}
-			// Default path not found - continue without WAF filtering
-		} else {
+			return nil, fmt.Errorf("failed to load WAF ranges: %w", err)
+		}
+		s.edges = edges
+
+		if config.SkipWAF {
 			filter, err := waf.NewFilterFromDatabase(db, config.SkipProviders, config.ShowSkipped)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create WAF filter: %w", err)
@@ -227,28 +284,43 @@ func (s *Scanner) Scan(ctx context.Context) (*core.ScanResult, error) {
 	}
 
 	// Calculate total IPs
-	// Convert [][2]uint32 from config to []ip.IPRange
-	ranges := make([]ip.IPRange, len(s.config.IPRanges))
-	for i, r := range s.config.IPRanges {
-		ranges[i] = ip.IPRange{Start: r[0], End: r[1]}
-	}
-
-	iterator := ip.NewIterator(ranges)
+	iterator := ip.NewAddrIterator(ip.RangesFromPairs(s.config.IPRanges))
 	totalIPs := iterator.TotalIPs()
 	if totalIPs == 0 {
 		return nil, fmt.Errorf("no IP ranges to scan")
 	}
+	ports := s.ports()
 
 	// Create channels
-	jobs := make(chan uint32, s.config.Workers*2)
+	jobs := make(chan scanJob, s.config.Workers*2)
 	results := make(chan *core.IPResult, s.config.Workers*2)
 
 	// Atomic counters
 	var (
 		scanned uint64
 		skipped uint64
+		seq     uint64
 	)
 
+	// Continue after the IPs a previous run completed
+	if s.resume != nil {
+		iterator.Skip(s.resume.Completed)
+		seq = s.resume.Completed
+		scanned = s.resume.Scanned
+		skipped = s.resume.Skipped
+		for _, r := range s.resume.Results {
+			result.AddResult(r)
+			if s.config.Mode == core.ModeAuto {
+				result.Probes = append(result.Probes, r)
+			}
+		}
+	}
+	stopCheckpoints := func() {}
+	if s.checkpointPath != "" {
+		s.tracker = newCheckpointTracker(len(ports), s.resume)
+		stopCheckpoints = s.saveCheckpoints(ctx)
+	}
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < s.config.Workers; i++ {
@@ -256,29 +328,37 @@ func (s *Scanner) Scan(ctx context.Context) (*core.ScanResult, error) {
 		go s.worker(ctx, &wg, jobs, results, &scanned, &skipped)
 	}
 
-	// Result collector
+	// Result collector: auto mode also receives the probes that are not displayed,
+	// so every passive IP can be judged on its own results
 	var collectorWg sync.WaitGroup
 	collectorWg.Add(1)
 	go func() {
 		defer collectorWg.Done()
 		for ipResult := range results {
-			result.AddResult(ipResult)
+			if s.config.Mode == core.ModeAuto {
+				result.Probes = append(result.Probes, ipResult)
+			}
+			if ipResult.Status == "skipped" || s.reported(ipResult) {
+				result.AddResult(ipResult)
+			}
 		}
 	}()
 
-	// Feed jobs
+	// Feed jobs: every IP is expanded into one job per configured port
 	go func() {
 		defer close(jobs)
-		for {
-			ipInt, ok := iterator.NextUint32()
+		for ; ; seq++ {
+			addr, ok := iterator.Next()
 			if !ok {
 				break
 			}
 
-			select {
-			case jobs <- ipInt:
-			case <-ctx.Done():
-				return
+			for _, port := range ports {
+				select {
+				case jobs <- scanJob{ip: addr, port: port, seq: seq}:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}
 	}()
@@ -290,6 +370,18 @@ func (s *Scanner) Scan(ctx context.Context) (*core.ScanResult, error) {
 	// Wait for collector
 	collectorWg.Wait()
 
+	// An interrupted checkpointed scan keeps its progress for --resume
+	stopCheckpoints()
+	if s.tracker != nil {
+		if ctx.Err() != nil {
+			if err := s.checkpoint().Save(s.checkpointPath); err != nil {
+				return nil, fmt.Errorf("%w: %v", core.ErrScanInterrupted, err)
+			}
+			return nil, fmt.Errorf("%w: progress saved to %s", core.ErrScanInterrupted, s.checkpointPath)
+		}
+		os.Remove(s.checkpointPath)
+	}
+
 	// Validate successful IPs if both --verify and --follow-redirect are enabled
 	// This checks if IPs behave the same without Host header (detects shared hosting)
 	if s.config.VerifyContent && s.config.MaxRedirects > 0 && len(result.Success) > 0 {
@@ -318,54 +410,80 @@ func (s *Scanner) Scan(ctx context.Context) (*core.ScanResult, error) {
 			result.Summary.FalsePositiveIPs = falsePositiveIPs
 		}
 
-		// Collect possible origin IPs from success results and classify as related vs other
-		related := make([]string, 0)
-		other := make([]string, 0)
-		for _, r := range result.Success {
-			if r.PossibleOrigin {
-				// Classify as related if the recorded destination contains the configured domain
-				if strings.Contains(strings.ToLower(r.PossibleOriginDest), strings.ToLower(s.config.Domain)) {
-					related = append(related, r.IP)
-				} else {
-					other = append(other, r.IP)
-				}
+	}
+
+	// Collect possible origin IPs and classify as related vs other
+	// Non-200 results only carry PossibleOrigin when their TLS certificate covers the domain
+	// An IP probed on several ports is listed once, as related if any of its ports is
+	related := make([]string, 0)
+	other := make([]string, 0)
+	candidates := make([]*core.IPResult, 0, len(result.Success))
+	candidates = append(candidates, result.Success...)
+	candidates = append(candidates, result.Redirects...)
+	candidates = append(candidates, result.Other...)
+	relatedSeen := make(map[string]bool)
+	otherSeen := make(map[string]bool)
+	for _, r := range candidates {
+		if r.PossibleOrigin {
+			// Classify as related if the recorded destination contains the configured domain
+			if strings.Contains(strings.ToLower(r.PossibleOriginDest), strings.ToLower(s.config.Domain)) {
+				relatedSeen[r.IP] = true
 			} else {
-				// Fallback: check redirect chain notes for legacy "Possible origin IP" note
-				for _, note := range r.RedirectChain {
-					if strings.HasPrefix(note, "Possible origin IP:") {
-						// Treat as other by default
-						other = append(other, r.IP)
-						break
-					}
+				otherSeen[r.IP] = true
+			}
+		} else {
+			// Fallback: check redirect chain notes for legacy "Possible origin IP" note
+			for _, note := range r.RedirectChain {
+				if strings.HasPrefix(note, "Possible origin IP:") {
+					// Treat as other by default
+					otherSeen[r.IP] = true
+					break
 				}
 			}
 		}
-
-		total := len(related) + len(other)
-		if total > 0 {
-			result.Summary.PossibleOriginCount = uint64(total)
-			// Combine related then other for predictable ordering
-			combined := make([]string, 0, total)
-			combined = append(combined, related...)
-			combined = append(combined, other...)
-			result.Summary.PossibleOriginIPs = combined
-			result.Summary.PossibleOriginRelatedCount = uint64(len(related))
-			result.Summary.PossibleOriginRelatedIPs = related
+	}
+	listed := make(map[string]bool)
+	for _, r := range candidates {
+		if listed[r.IP] {
+			continue
 		}
+		if relatedSeen[r.IP] {
+			related = append(related, r.IP)
+			listed[r.IP] = true
+		} else if otherSeen[r.IP] {
+			other = append(other, r.IP)
+			listed[r.IP] = true
+		}
+	}
+
+	total := len(related) + len(other)
+	if total > 0 {
+		result.Summary.PossibleOriginCount = uint64(total)
+		// Combine related then other for predictable ordering
+		combined := make([]string, 0, total)
+		combined = append(combined, related...)
+		combined = append(combined, other...)
+		result.Summary.PossibleOriginIPs = combined
+		result.Summary.PossibleOriginRelatedCount = uint64(len(related))
+		result.Summary.PossibleOriginRelatedIPs = related
 	}
 
 	// Finalize result
 	result.EndTime = time.Now()
-	result.Summary.TotalIPs = totalIPs
+	result.Summary.TotalIPs = ip.MulSaturating(totalIPs, uint64(len(ports)))
 	result.Summary.ScannedIPs = scanned
 	result.Summary.SkippedIPs = skipped
 	result.Summary.SuccessCount = uint64(len(result.Success))
 	result.Summary.Duration = result.EndTime.Sub(result.StartTime)
 
-	// Extract success IPs for summary display
+	// Extract success IPs for summary display (once per IP, not per port)
 	result.Summary.SuccessIPs = make([]string, 0, len(result.Success))
+	successSeen := make(map[string]bool, len(result.Success))
 	for _, ipResult := range result.Success {
-		result.Summary.SuccessIPs = append(result.Summary.SuccessIPs, ipResult.IP)
+		if !successSeen[ipResult.IP] {
+			successSeen[ipResult.IP] = true
+			result.Summary.SuccessIPs = append(result.Summary.SuccessIPs, ipResult.IP)
+		}
 	}
 
 	// Add WAF stats if filter was used
@@ -377,21 +495,21 @@ func (s *Scanner) Scan(ctx context.Context) (*core.ScanResult, error) {
 	return result, nil
 }
 
-// worker processes IP addresses from the jobs channel
-func (s *Scanner) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan uint32, results chan<- *core.IPResult, scanned, skipped *uint64) {
+// worker processes (IP, port) jobs from the jobs channel
+func (s *Scanner) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan scanJob, results chan<- *core.IPResult, scanned, skipped *uint64) {
 	defer wg.Done()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case ipInt, ok := <-jobs:
+		case job, ok := <-jobs:
 			if !ok {
 				return
 			}
 
 			// Convert to net.IP
-			ipAddr := ip.FromUint32(ipInt)
+			ipAddr := net.IP(job.ip.AsSlice())
 
 			// Check WAF filter
 			if s.wafFilter != nil {
@@ -404,19 +522,24 @@ func (s *Scanner) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan ui
 						s.progressCallback(atomic.LoadUint64(scanned)+newSkipped, 0)
 					}
 
+					var skippedResult *core.IPResult
 					if s.config.ShowSkipped {
-						results <- &core.IPResult{
+						skippedResult = &core.IPResult{
 							IP:       ipAddr.String(),
+							Port:     job.port,
 							Status:   "skipped",
 							Provider: provider,
 						}
+						results <- skippedResult
 					}
+					s.jobDone(job, true, skippedResult)
 					continue
 				}
 			}
 
 			// Scan the IP
-			result := s.scanIP(ctx, ipAddr)
+			result := s.scanIP(ctx, ipAddr, job.port)
+			s.observe(result)
 			newScanned := atomic.AddUint64(scanned, 1)
 
 			// Update progress
@@ -425,25 +548,105 @@ func (s *Scanner) worker(ctx context.Context, wg *sync.WaitGroup, jobs <-chan ui
 			}
 
 			// Send result
-			if s.config.ShowAll || result.Status == "200" {
+			reported := s.reported(result)
+			if reported && s.resultCallback != nil {
 				// Call result callback for real-time display
-				if s.resultCallback != nil {
-					s.resultCallback(result)
-				}
+				s.resultCallback(result)
+			}
+			if reported || s.config.Mode == core.ModeAuto {
 				results <- result
 			}
+
+			// A probe cut short by cancellation is redone on resume
+			if ctx.Err() == nil {
+				if !reported {
+					result = nil
+				}
+				s.jobDone(job, false, result)
+			}
 		}
 	}
 }
 
-// scanIP performs HTTP request to a single IP
-func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
+// reported reports whether a probe result is displayed and kept in the scan result
+func (s *Scanner) reported(result *core.IPResult) bool {
+	return s.config.ShowAll || result.Status == "200" || result.CertMatch
+}
+
+// scanIP probes a single IP and port using the configured scheme(s)
+// In "both" mode HTTPS is tried first and HTTP is only used when HTTPS fails to answer
+func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP, port int) *core.IPResult {
+	var result *core.IPResult
+	for _, scheme := range s.probeSchemes(port) {
+		result = s.probe(ctx, ipAddr, port, scheme)
+		if result.Status != "error" && result.Status != "timeout" {
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return result
+}
+
+// ports returns the ports probed for each IP (0 = scheme default port)
+func (s *Scanner) ports() []int {
+	if len(s.config.Ports) == 0 {
+		return []int{0}
+	}
+	return s.config.Ports
+}
+
+// probeSchemes returns the schemes to try, in order, for an IP and port.
+// Well-known ports use their own scheme unless "both" was requested explicitly.
+func (s *Scanner) probeSchemes(port int) []string {
+	if s.config.Scheme == core.SchemeBoth {
+		return []string{"https", "http"}
+	}
+	switch {
+	case httpsPorts[port]:
+		return []string{"https"}
+	case httpPorts[port]:
+		return []string{"http"}
+	}
+	if s.config.Scheme == core.SchemeHTTPS {
+		return []string{"https"}
+	}
+	return []string{"http"}
+}
+
+// probeURL builds the request URL for a host and scheme
+func probeURL(scheme, host string) string {
+	if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// probeHost returns the URL host for an IP, adding the port unless it is the scheme default
+// IPv6 addresses are always bracketed so they can be used in a URL
+func probeHost(ipStr string, port int, scheme string) string {
+	if port == 0 || (scheme == "https" && port == 443) || (scheme != "https" && port == 80) {
+		if strings.Contains(ipStr, ":") {
+			return "[" + ipStr + "]"
+		}
+		return ipStr
+	}
+	return net.JoinHostPort(ipStr, strconv.Itoa(port))
+}
+
+// probe performs a single HTTP(S) request to an IP with the target Host header.
+// HTTPS requests dial the IP but present the target domain as TLS SNI.
+func (s *Scanner) probe(ctx context.Context, ipAddr net.IP, port int, scheme string) *core.IPResult {
 	result := &core.IPResult{
-		IP: ipAddr.String(),
+		IP:     ipAddr.String(),
+		Scheme: scheme,
+		Port:   port,
 	}
 
 	// Construct URL
-	url := fmt.Sprintf("http://%s", ipAddr.String())
+	originalHost := probeHost(ipAddr.String(), port, scheme)
+	url := probeURL(scheme, originalHost)
 
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, s.config.HTTPMethod, url, nil)
@@ -464,11 +667,14 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 		}
 	}
 
-	// Set custom header if specified
-	if s.config.CustomHeader != "" {
-		// Parse header (format: "Name: Value")
-		// Simple implementation - just set as-is
-		req.Header.Set("X-Custom", s.config.CustomHeader)
+	// Custom headers go last so they can override the User-Agent
+	s.setHeaders(req)
+
+	// Wait for the rate limiter before sending anything
+	if err := s.throttle(ctx); err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
 	}
 
 	// Perform request
@@ -480,14 +686,20 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 	var naturalRedirect string
 	if s.config.MaxRedirects > 0 {
 		testReq, _ := http.NewRequestWithContext(ctx, s.config.HTTPMethod, url, nil)
+		s.setHeaders(testReq)
+		testTransport := client.Transport
+		if scheme == "https" {
+			// Send no SNI so the natural probe looks like a bare request to the IP
+			testTransport = sniTransport(client.Transport, "", true)
+		}
 		testClient := &http.Client{
-			Transport: client.Transport,
+			Transport: testTransport,
 			Timeout:   client.Timeout,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse // Don't follow, just capture first redirect
 			},
 		}
-		testResp, err := testClient.Do(testReq)
+		testResp, err := s.throttledDo(ctx, testClient, testReq)
 		if err == nil {
 			defer testResp.Body.Close()
 			if testResp.StatusCode >= 300 && testResp.StatusCode < 400 {
@@ -565,11 +777,18 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 
 					// Rewrite redirect URL to keep testing the same IP
 					// Instead of following redirect to new domain, rewrite URL to use original IP
+					// The probed port is kept only while the redirect stays on the same scheme
 					redirectedDomain := req.URL.Host
-					req.URL.Host = originalIP
+					if req.URL.Scheme == scheme {
+						req.URL.Host = originalHost
+					} else {
+						req.URL.Host = probeHost(originalIP, 0, req.URL.Scheme)
+					}
 					req.Host = redirectedDomain // Keep Host header as redirected domain
 					// Remove Referer to avoid leaking the original IP in redirected requests
 					req.Header.Del("Referer")
+					// Restore custom headers dropped on cross-host redirects (Authorization, Cookie)
+					s.setHeaders(req)
 				}
 
 				// Check if we've exceeded the max redirects
@@ -577,7 +796,8 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 					return fmt.Errorf("stopped after %d redirects", s.config.MaxRedirects)
 				}
 
-				return nil
+				// Each followed hop is a request and counts against the rate limit
+				return s.throttle(req.Context())
 			},
 		}
 		resp, err := customClient.Do(req)
@@ -599,6 +819,7 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 		result.HTTPCode = resp.StatusCode
 		result.Server = resp.Header.Get("Server")
 		result.ContentType = resp.Header.Get("Content-Type")
+		s.recordCert(result, resp)
 
 		// If final URL differs from initial URL, add a redirect note
 		finalURL := resp.Request.URL.String()
@@ -608,21 +829,8 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 		}
 		result.RedirectChain = redirectChain
 
-		// Extract content if enabled and status is 200
-		if s.config.VerifyContent && resp.StatusCode == 200 {
-			// Read response body (limit to 64KB for safety)
-			body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
-			if err == nil {
-				// Calculate SHA256 hash
-				hash := sha256.Sum256(body)
-				result.BodyHash = hex.EncodeToString(hash[:])[:16] // First 16 chars
-
-				// Extract HTML title if Content-Type is HTML
-				if strings.Contains(strings.ToLower(result.ContentType), "html") {
-					result.Title = extractTitle(string(body))
-				}
-			}
-		}
+		// Fingerprint the body (--verify) and compare it with the baseline
+		s.inspectBody(ctx, client, result, resp)
 
 		switch {
 		case resp.StatusCode == 200:
@@ -660,22 +868,10 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 	result.HTTPCode = resp.StatusCode
 	result.Server = resp.Header.Get("Server")
 	result.ContentType = resp.Header.Get("Content-Type")
+	s.recordCert(result, resp)
 
-	// Extract content if enabled and status is 200
-	if s.config.VerifyContent && resp.StatusCode == 200 {
-		// Read response body (limit to 64KB for safety)
-		body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
-		if err == nil {
-			// Calculate SHA256 hash
-			hash := sha256.Sum256(body)
-			result.BodyHash = hex.EncodeToString(hash[:])[:16] // First 16 chars
-
-			// Extract HTML title if Content-Type is HTML
-			if strings.Contains(strings.ToLower(result.ContentType), "html") {
-				result.Title = extractTitle(string(body))
-			}
-		}
-	}
+	// Fingerprint the body (--verify) and compare it with the baseline
+	s.inspectBody(ctx, client, result, resp)
 
 	switch {
 	case resp.StatusCode == 200:
@@ -695,18 +891,12 @@ func (s *Scanner) scanIP(ctx context.Context, ipAddr net.IP) *core.IPResult {
 
 // extractTitle extracts the <title> tag content from HTML
 func extractTitle(html string) string {
-	// Simple regex to extract title (not perfect but good enough)
-	re := regexp.MustCompile(`(?i)<title[^>]*>([^<]+)</title>`)
-	matches := re.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		title := strings.TrimSpace(matches[1])
-		// Limit title length
-		if len(title) > 100 {
-			title = title[:97] + "..."
-		}
-		return title
+	title := page.Title([]byte(html))
+	// Limit title length
+	if len(title) > 100 {
+		title = title[:97] + "..."
 	}
-	return ""
+	return title
 }
 
 // Stop cancels the ongoing scan
@@ -734,6 +924,119 @@ func (s *Scanner) SetResultCallback(callback func(result *core.IPResult)) {
 	s.resultCallback = callback
 }
 
+// SetCheckpoint saves scan progress to path every interval and when the scan is
+// interrupted; the file is removed once the scan completes
+func (s *Scanner) SetCheckpoint(path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	s.checkpointPath = path
+	s.checkpointInterval = interval
+}
+
+// Resume continues a scan from a checkpoint: completed IPs are skipped and their
+// results are included in the final result
+// The scanner must be created with the checkpoint's configuration.
+func (s *Scanner) Resume(cp *Checkpoint) {
+	s.resume = cp
+	s.passive = cp.Passive
+	s.hostnames = cp.Hostnames
+}
+
+// SetPassive stores the passive results an auto-mode scan was built from in its
+// checkpoints, so a resumed scan reports them without repeating passive recon
+func (s *Scanner) SetPassive(ips []core.PassiveIP, hostnames []core.Hostname) {
+	s.passive = ips
+	s.hostnames = hostnames
+}
+
+// checkpoint returns the scan's current checkpoint
+func (s *Scanner) checkpoint() *Checkpoint {
+	cp := s.tracker.snapshot(s.config)
+	cp.Passive = s.passive
+	cp.Hostnames = s.hostnames
+	return cp
+}
+
+// Rate returns the request rate currently enforced in requests per second
+// (0 = unlimited); in adaptive mode it reflects the latest adjustment
+func (s *Scanner) Rate() float64 {
+	if s.limiter == nil {
+		return 0
+	}
+	return s.limiter.current()
+}
+
+// setHeaders applies the configured custom headers to req, replacing existing values
+func (s *Scanner) setHeaders(req *http.Request) {
+	for name, values := range s.headers {
+		req.Header[name] = append([]string(nil), values...)
+	}
+}
+
+// throttle waits until the rate limit allows another request
+func (s *Scanner) throttle(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	return s.limiter.wait(ctx)
+}
+
+// throttledDo sends req once the rate limit allows it
+func (s *Scanner) throttledDo(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := s.throttle(ctx); err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// observe feeds a probe outcome to the adaptive rate limiter
+// Timeouts, connection errors and 429/503 responses count as failures.
+func (s *Scanner) observe(result *core.IPResult) {
+	if s.limiter == nil {
+		return
+	}
+	failed := result.Status == "timeout" || result.Status == "error" ||
+		result.HTTPCode == http.StatusTooManyRequests || result.HTTPCode == http.StatusServiceUnavailable
+	s.limiter.observe(failed)
+}
+
+// jobDone records a finished probe for checkpointing
+func (s *Scanner) jobDone(job scanJob, skipped bool, result *core.IPResult) {
+	if s.tracker != nil {
+		s.tracker.done(job.seq, skipped, result)
+	}
+}
+
+// saveCheckpoints periodically writes the checkpoint until the returned stop function is called
+func (s *Scanner) saveCheckpoints(ctx context.Context) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(s.checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.checkpoint().Save(s.checkpointPath); err != nil && s.config.Verbose {
+					fmt.Fprintf(os.Stderr, "[!] %s\n", err)
+				}
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
 // validateSuccessfulIPs checks if successful IPs behave the same without Host header
 // This helps detect shared hosting where the Host header influences the response
 // Returns list of IPs flagged as potential false positives
@@ -769,8 +1072,9 @@ func (s *Scanner) validateSuccessfulIPs(ctx context.Context, successIPs []*core.
 			// DON'T set req.Host - that's the key difference from main scan
 			// Remove Referer to avoid leaking the previous destination/IP
 			req.Header.Del("Referer")
+			s.setHeaders(req)
 
-			return nil
+			return s.throttle(req.Context())
 		},
 	}
 
@@ -792,10 +1096,11 @@ func (s *Scanner) validateSuccessfulIPs(ctx context.Context, successIPs []*core.
 		if len(ipResult.RedirectChain) == 0 {
 			if ipResult.Status == "200" && ipResult.BodyHash != "" {
 				// Test without Host header and compare body hash
-				testURL := fmt.Sprintf("http://%s", ipResult.IP)
+				testURL := probeURL(ipResult.Scheme, probeHost(ipResult.IP, ipResult.Port, ipResult.Scheme))
 				testReq, err := http.NewRequestWithContext(ctx, "GET", testURL, nil)
 				if err == nil {
-					resp2, err2 := client.Do(testReq)
+					s.setHeaders(testReq)
+					resp2, err2 := s.throttledDo(ctx, client, testReq)
 					if err2 == nil {
 						// Read body and compute hash (limit to 64KB as in scanIP)
 						body, errRead := io.ReadAll(io.LimitReader(resp2.Body, 64*1024))
@@ -807,7 +1112,9 @@ func (s *Scanner) validateSuccessfulIPs(ctx context.Context, successIPs []*core.
 								originNote := fmt.Sprintf("Possible origin IP (Unrelated): %s (content match)", ipResult.IP)
 								ipResult.RedirectChain = append(ipResult.RedirectChain, originNote)
 								ipResult.PossibleOrigin = true
-								ipResult.PossibleOriginDest = ""
+								if !ipResult.CertMatch {
+									ipResult.PossibleOriginDest = ""
+								}
 							} else {
 								note := fmt.Sprintf("Note: Without Host header: content differs from Host-based response (but still possible)")
 								ipResult.RedirectChain = append(ipResult.RedirectChain, note)
@@ -827,14 +1134,15 @@ func (s *Scanner) validateSuccessfulIPs(ctx context.Context, successIPs []*core.
 		naturalChain = []string{}
 
 		// Test without Host header
-		url := fmt.Sprintf("http://%s", ipResult.IP)
+		url := probeURL(ipResult.Scheme, probeHost(ipResult.IP, ipResult.Port, ipResult.Scheme))
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			continue
 		}
+		s.setHeaders(req)
 
 		// Don't set Host header - let it default to the IP
-		resp, err := client.Do(req)
+		resp, err := s.throttledDo(ctx, client, req)
 		if err != nil {
 			continue
 		}
@@ -943,7 +1251,7 @@ func (s *Scanner) validatePTRs(ctx context.Context, successIPs []*core.IPResult)
 	for _, ipResult := range successIPs {
 		// perform lookup with per-lookup timeout
 		lookupCtx, cancel := context.WithTimeout(ctx, s.config.Timeout)
-		names, err := net.DefaultResolver.LookupAddr(lookupCtx, ipResult.IP)
+		names, err := resolver.Default().LookupAddr(lookupCtx, ipResult.IP)
 		cancel()
 		if err != nil || len(names) == 0 {
 			// No PTR found; record empty PTR
@@ -1052,6 +1360,25 @@ func (s *Scanner) getClient() *http.Client {
 		// Fallback to default client on error
 		return s.client
 	}
+	client.Transport = sniTransport(client.Transport, s.config.Domain, false)
 
 	return client
 }
+
+// sniTransport returns a copy of rt whose TLS handshakes skip certificate
+// verification and present serverName as SNI. An empty serverName sends no SNI
+// (the IP literal is never sent), mirroring a bare request to the IP.
+// Non-*http.Transport round trippers are returned unchanged.
+func sniTransport(rt http.RoundTripper, serverName string, disableKeepAlives bool) http.RoundTripper {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return rt
+	}
+	clone := t.Clone()
+	clone.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true, // Required for testing origin servers
+		ServerName:         serverName,
+	}
+	clone.DisableKeepAlives = disableKeepAlives
+	return clone
+}
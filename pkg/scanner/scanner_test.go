@@ -2,9 +2,14 @@ package scanner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -1037,32 +1042,53 @@ func TestScanner_TLSHandling(t *testing.T) {
 	t.Logf("Scanner created for TLS testing with server at %s", server.URL)
 }
 
-// TestScanner_CustomHeaders tests custom header handling
+// TestScanner_CustomHeaders tests that custom headers reach every probe
 func TestScanner_CustomHeaders(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string][]string) // request path -> Authorization + X-Origin-Secret
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		customHeader := r.Header.Get("X-Custom")
-		if customHeader != "" {
-			w.Header().Set("X-Response", "Custom header received")
+		mu.Lock()
+		seen[r.Host+r.URL.Path] = []string{r.Header.Get("Authorization"), r.Header.Get("X-Origin-Secret"), r.UserAgent()}
+		mu.Unlock()
+		if r.URL.Path == "/" && r.Host == "example.com" {
+			http.Redirect(w, r, "http://www.example.com/home", http.StatusFound)
+			return
 		}
 		w.WriteHeader(http.StatusOK)
 	}))
 	defer server.Close()
 
-	config := &core.Config{
-		Timeout:         5 * time.Second,
-		Workers:         1,
-		Domain:          "example.com",
-		CustomHeader:    "Test: Value",
-		WAFDatabasePath: "",
-	}
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
 
-	scanner, err := New(config)
+	config := &core.Config{
+		Timeout:      2 * time.Second,
+		Workers:      1,
+		Domain:       "example.com",
+		HTTPMethod:   "GET",
+		MaxRedirects: 3,
+		Headers:      []string{"Authorization: Bearer token", "X-Origin-Secret: s3cret", "User-Agent: origin-test"},
+	}
+	s, err := New(config)
 	if err != nil {
 		t.Fatalf("New() error: %v", err)
 	}
 
-	if scanner.config.CustomHeader != "Test: Value" {
-		t.Errorf("CustomHeader = %s, want 'Test: Value'", scanner.config.CustomHeader)
+	result := s.scanIP(context.Background(), net.ParseIP("127.0.0.1"), port)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+
+	// Natural-redirect probe (no Host), main probe and the followed redirect hop
+	want := []string{"Bearer token", "s3cret", "origin-test"}
+	for _, key := range []string{"127.0.0.1:" + portStr + "/", "example.com/", "www.example.com/home"} {
+		if got := seen[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("request %s headers = %v, want %v", key, got, want)
+		}
+	}
+
+	if _, err := New(&core.Config{Workers: 1, Headers: []string{"no colon"}}); !errors.Is(err, core.ErrInvalidHeader) {
+		t.Errorf("New() with an invalid header error = %v, want ErrInvalidHeader", err)
 	}
 }
 
@@ -1247,7 +1273,7 @@ func TestScanner_Worker(t *testing.T) {
 	defer server.Close()
 
 	ctx := context.Background()
-	jobs := make(chan uint32, 10)
+	jobs := make(chan scanJob, 10)
 	results := make(chan *core.IPResult, 10)
 	var scanned, skipped uint64
 	var wg sync.WaitGroup
@@ -1257,8 +1283,8 @@ func TestScanner_Worker(t *testing.T) {
 	go scanner.worker(ctx, &wg, jobs, results, &scanned, &skipped)
 
 	// Send test IP (127.0.0.1)
-	testIP := uint32(127<<24 | 0<<16 | 0<<8 | 1)
-	jobs <- testIP
+	testIP := netip.MustParseAddr("127.0.0.1")
+	jobs <- scanJob{ip: testIP}
 	close(jobs)
 
 	// Wait for worker
@@ -1298,7 +1324,7 @@ func TestScanner_Worker_Cancellation(t *testing.T) {
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	jobs := make(chan uint32, 10)
+	jobs := make(chan scanJob, 10)
 	results := make(chan *core.IPResult, 10)
 	var scanned, skipped uint64
 	var wg sync.WaitGroup
@@ -1311,8 +1337,8 @@ func TestScanner_Worker_Cancellation(t *testing.T) {
 	cancel()
 
 	// Send job (should be ignored)
-	testIP := uint32(127<<24 | 0<<16 | 0<<8 | 1)
-	jobs <- testIP
+	testIP := netip.MustParseAddr("127.0.0.1")
+	jobs <- scanJob{ip: testIP}
 	close(jobs)
 
 	// Wait for worker
@@ -1330,13 +1356,39 @@ func TestScanner_Worker_Cancellation(t *testing.T) {
 	}
 }
 
-// TestScanner_ScanIP tests the scanIP function directly
+// TestScanner_ScanIP tests the scanIP function directly against a non-default port
 func TestScanner_ScanIP(t *testing.T) {
-	t.Skip("scanIP requires real HTTP server on port 80 - tested via integration tests")
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
 
-	// NOTE: scanIP is hardcoded to use http://IP:80, so it cannot easily test
-	// with httptest.Server which uses random ports. The function is tested
-	// via integration tests and the worker function tests.
+	result := s.scanIP(context.Background(), net.ParseIP("127.0.0.1"), port)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+	if result.Port != port {
+		t.Errorf("Port = %d, want %d", result.Port, port)
+	}
+	if host != "example.com" {
+		t.Errorf("Host = %q, want example.com", host)
+	}
 }
 
 // TestScanner_Scan_Integration tests the full Scan workflow
@@ -1358,10 +1410,10 @@ func TestScanner_Scan_Integration(t *testing.T) {
 		WAFDatabasePath: "",
 		Mode:            "active",
 		ShowAll:         true,
-		IPRanges: [][2]uint32{
+		IPRanges: [][2]netip.Addr{
 			{
-				uint32(127<<24 | 0<<16 | 0<<8 | 1),
-				uint32(127<<24 | 0<<16 | 0<<8 | 2),
+				netip.MustParseAddr("127.0.0.1"),
+				netip.MustParseAddr("127.0.0.2"),
 			},
 		},
 	}
@@ -1397,3 +1449,334 @@ func TestScanner_Scan_Integration(t *testing.T) {
 		t.Log("Warning: No results captured (could be WAF filtering or network issues)")
 	}
 }
+
+// redirectDial points every connection of the scanner's transport at addr so
+// probes to arbitrary IPs land on a local test server.
+func redirectDial(t *testing.T, s *Scanner, addr string) {
+	t.Helper()
+	transport, ok := s.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatal("scanner client transport is not *http.Transport")
+	}
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+func TestScanner_ScanIP_HTTPSWithSNI(t *testing.T) {
+	var sni, host string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sni = r.TLS.ServerName
+		host = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Scheme:     core.SchemeHTTPS,
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	redirectDial(t, s, server.Listener.Addr().String())
+
+	result := s.scanIP(context.Background(), net.ParseIP("192.0.2.10"), 0)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+	if result.Scheme != "https" {
+		t.Errorf("Scheme = %q, want https", result.Scheme)
+	}
+	if sni != "example.com" {
+		t.Errorf("SNI = %q, want example.com", sni)
+	}
+	if host != "example.com" {
+		t.Errorf("Host = %q, want example.com", host)
+	}
+}
+
+func TestScanner_ScanIP_BothFallsBackToHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Scheme:     core.SchemeBoth,
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	redirectDial(t, s, server.Listener.Addr().String())
+
+	result := s.scanIP(context.Background(), net.ParseIP("192.0.2.10"), 0)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+	if result.Scheme != "http" {
+		t.Errorf("Scheme = %q, want http after HTTPS handshake failure", result.Scheme)
+	}
+}
+
+func TestScanner_ProbeSchemes(t *testing.T) {
+	tests := []struct {
+		scheme core.Scheme
+		want   []string
+	}{
+		{"", []string{"http"}},
+		{core.SchemeHTTP, []string{"http"}},
+		{core.SchemeHTTPS, []string{"https"}},
+		{core.SchemeBoth, []string{"https", "http"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scheme), func(t *testing.T) {
+			s := &Scanner{config: &core.Config{Scheme: tt.scheme}}
+			got := s.probeSchemes(0)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("probeSchemes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanner_ProbeSchemes_Ports(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme core.Scheme
+		port   int
+		want   string
+	}{
+		{"443 uses https", core.SchemeHTTP, 443, "https"},
+		{"8443 uses https", core.SchemeHTTP, 8443, "https"},
+		{"2083 uses https", core.SchemeHTTP, 2083, "https"},
+		{"8080 uses http", core.SchemeHTTPS, 8080, "http"},
+		{"2052 uses http", core.SchemeHTTPS, 2052, "http"},
+		{"unknown port follows scheme", core.SchemeHTTPS, 9000, "https"},
+		{"both overrides well-known port", core.SchemeBoth, 80, "https,http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{config: &core.Config{Scheme: tt.scheme}}
+			if got := strings.Join(s.probeSchemes(tt.port), ","); got != tt.want {
+				t.Errorf("probeSchemes(%d) = %s, want %s", tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeHost(t *testing.T) {
+	tests := []struct {
+		ip     string
+		port   int
+		scheme string
+		want   string
+	}{
+		{"192.0.2.1", 0, "http", "192.0.2.1"},
+		{"192.0.2.1", 80, "http", "192.0.2.1"},
+		{"192.0.2.1", 443, "https", "192.0.2.1"},
+		{"192.0.2.1", 443, "http", "192.0.2.1:443"},
+		{"192.0.2.1", 8443, "https", "192.0.2.1:8443"},
+		{"2001:db8::1", 0, "http", "[2001:db8::1]"},
+		{"2001:db8::1", 443, "https", "[2001:db8::1]"},
+		{"2001:db8::1", 8080, "http", "[2001:db8::1]:8080"},
+	}
+
+	for _, tt := range tests {
+		if got := probeHost(tt.ip, tt.port, tt.scheme); got != tt.want {
+			t.Errorf("probeHost(%s, %d, %s) = %q, want %q", tt.ip, tt.port, tt.scheme, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_Scan_MultiplePorts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// Pick a second port that nothing listens on
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	_, closedStr, _ := net.SplitHostPort(closed.Addr().String())
+	closedPort, _ := strconv.Atoi(closedStr)
+	closed.Close()
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    2,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Ports:      []int{port, closedPort},
+		ShowAll:    true,
+		IPRanges:   [][2]netip.Addr{{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("127.0.0.1")}},
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	result, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if result.Summary.TotalIPs != 2 || result.Summary.ScannedIPs != 2 {
+		t.Errorf("TotalIPs/ScannedIPs = %d/%d, want 2/2", result.Summary.TotalIPs, result.Summary.ScannedIPs)
+	}
+	if len(result.Success) != 1 || result.Success[0].Port != port {
+		t.Fatalf("Success = %v, want one result on port %d", result.Success, port)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Port != closedPort {
+		t.Errorf("Errors = %v, want one result on port %d", result.Errors, closedPort)
+	}
+}
+
+func TestScanner_Scan_SummaryListsIPOnce(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	var ports []int
+	for _, server := range []*httptest.Server{first, second} {
+		_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+		port, _ := strconv.Atoi(portStr)
+		ports = append(ports, port)
+	}
+
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    2,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Ports:      ports,
+		IPRanges:   [][2]netip.Addr{{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("127.0.0.1")}},
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	result, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(result.Success) != 2 {
+		t.Fatalf("Success = %v, want one result per port", result.Success)
+	}
+	if !reflect.DeepEqual(result.Summary.SuccessIPs, []string{"127.0.0.1"}) {
+		t.Errorf("SuccessIPs = %v, want [127.0.0.1]", result.Summary.SuccessIPs)
+	}
+	if len(result.Summary.PossibleOriginIPs) > 1 || result.Summary.PossibleOriginCount > 1 {
+		t.Errorf("PossibleOriginIPs = %v (count %d), want the IP at most once", result.Summary.PossibleOriginIPs, result.Summary.PossibleOriginCount)
+	}
+}
+
+func TestScanner_Scan_AutoRecordsAllProbes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	for _, mode := range []core.ScanMode{core.ModeAuto, core.ModeActive} {
+		config := &core.Config{
+			Timeout:    2 * time.Second,
+			Workers:    1,
+			Domain:     "example.com",
+			HTTPMethod: "GET",
+			Mode:       mode,
+			Ports:      []int{port},
+			IPRanges:   [][2]netip.Addr{{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("127.0.0.1")}},
+		}
+		s, err := New(config)
+		if err != nil {
+			t.Fatalf("New() error: %v", err)
+		}
+		result, err := s.Scan(context.Background())
+		if err != nil {
+			t.Fatalf("Scan() error: %v", err)
+		}
+
+		// The 403 is not displayed without --show-all, but auto mode keeps it for its candidates
+		if len(result.Other) != 0 {
+			t.Errorf("%s: Other = %v, want the 403 filtered from display", mode, result.Other)
+		}
+		wantProbes := 0
+		if mode == core.ModeAuto {
+			wantProbes = 1
+		}
+		if len(result.Probes) != wantProbes {
+			t.Errorf("%s: Probes = %v, want %d", mode, result.Probes, wantProbes)
+		}
+	}
+}
+
+func TestScanner_Scan_IPv6(t *testing.T) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	loopback := netip.MustParseAddr("::1")
+	config := &core.Config{
+		Timeout:    2 * time.Second,
+		Workers:    1,
+		Domain:     "example.com",
+		HTTPMethod: "GET",
+		Ports:      []int{port},
+		IPRanges:   [][2]netip.Addr{{loopback, loopback}},
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	result, err := s.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	if len(result.Success) != 1 || result.Success[0].IP != "::1" {
+		t.Fatalf("Success = %v, want ::1", result.Success)
+	}
+}
+
+func TestProbeURL(t *testing.T) {
+	if got := probeURL("", "192.0.2.1"); got != "http://192.0.2.1" {
+		t.Errorf("probeURL(\"\") = %q", got)
+	}
+	if got := probeURL("https", "192.0.2.1"); got != "https://192.0.2.1" {
+		t.Errorf("probeURL(https) = %q", got)
+	}
+}
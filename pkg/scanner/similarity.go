@@ -0,0 +1,227 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// bodyReadLimit is how much of a response body is hashed and compared
+const bodyReadLimit = 64 * 1024
+
+// Shingle sizes and component weights for similarity scoring
+const (
+	structureShingle = 4
+	textShingle      = 3
+	structureWeight  = 0.4
+	textWeight       = 0.4
+	headerWeight     = 0.2
+)
+
+// cdnHeaderPrefixes are response headers added by CDNs and caches
+// They are ignored so an origin is not penalized for lacking them.
+var cdnHeaderPrefixes = []string{
+	"age", "alt-svc", "cdn-", "cf-", "nel", "report-to", "server-timing",
+	"via", "x-akamai", "x-amz-cf-", "x-azure-", "x-cache", "x-cdn", "x-fastly",
+	"x-iinfo", "x-served-by", "x-sucuri", "x-timer",
+}
+
+// Baseline is the live site's response, fetched the way a visitor reaches it
+type Baseline struct {
+	URL        string // Final URL after redirects
+	StatusCode int
+	Title      string
+	profile    *pageProfile
+}
+
+// pageProfile is the normalized form of a response used for fuzzy comparison
+type pageProfile struct {
+	structure map[uint64]struct{} // Shingles of the HTML tag sequence
+	text      map[uint64]struct{} // Shingles of the visible words
+	headers   map[string]struct{} // Response header names, CDN headers excluded
+}
+
+// FetchBaseline fetches the target domain's homepage through normal DNS resolution
+// (and therefore through its CDN/WAF). HTTPS is tried first, then HTTP.
+func (s *Scanner) FetchBaseline(ctx context.Context) (*Baseline, error) {
+	client := &http.Client{
+		Transport: sniTransport(s.client.Transport, "", false), // SNI follows each redirect's host
+		Timeout:   s.config.Timeout,
+	}
+
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL(scheme, s.config.Domain)+"/", nil)
+		if err != nil {
+			return nil, err
+		}
+		if !s.config.NoUserAgent {
+			if ua := s.getUserAgent(); ua != "" {
+				req.Header.Set("User-Agent", ua)
+			}
+		}
+		s.setHeaders(req)
+
+		resp, err := s.throttledDo(ctx, client, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, bodyReadLimit))
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return &Baseline{
+			URL:        resp.Request.URL.String(),
+			StatusCode: resp.StatusCode,
+			Title:      extractTitle(string(body)),
+			profile:    newPageProfile(resp.Header, body),
+		}, nil
+	}
+	return nil, fmt.Errorf("failed to fetch baseline for %s: %w", s.config.Domain, lastErr)
+}
+
+// SetBaseline enables similarity scoring of 200 responses against b
+func (s *Scanner) SetBaseline(b *Baseline) {
+	s.baseline = b
+}
+
+// Similarity scores a response against the baseline from 0 (unrelated) to 1 (same page)
+// It combines the Jaccard similarity of HTML structure shingles, visible text
+// shingles and the response header set, so dynamic tokens (CSRF, timestamps)
+// only lower the score slightly instead of breaking an exact hash match.
+func (b *Baseline) Similarity(header http.Header, body []byte) float64 {
+	return b.profile.similarity(newPageProfile(header, body))
+}
+
+// similarity returns the weighted similarity of two profiles
+// Components that are empty on both sides are left out of the weighting.
+func (p *pageProfile) similarity(other *pageProfile) float64 {
+	var score, weight float64
+	add := func(sim, w float64) {
+		if sim >= 0 {
+			score += sim * w
+			weight += w
+		}
+	}
+	add(jaccard(p.structure, other.structure), structureWeight)
+	add(jaccard(p.text, other.text), textWeight)
+	add(jaccard(p.headers, other.headers), headerWeight)
+
+	if weight == 0 {
+		return 0
+	}
+	return math.Round(score/weight*100) / 100
+}
+
+// newPageProfile normalizes a response for comparison
+func newPageProfile(header http.Header, body []byte) *pageProfile {
+	var tags, words []string
+	skip := ""
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			tags = append(tags, tag)
+			if tt == html.StartTagToken && (tag == "script" || tag == "style") {
+				skip = tag
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == skip {
+				skip = ""
+			}
+		case html.TextToken:
+			if skip == "" {
+				words = append(words, normalizeWords(string(z.Text()))...)
+			}
+		}
+	}
+
+	headers := make(map[string]struct{}, len(header))
+	for name := range header {
+		name = strings.ToLower(name)
+		if !isCDNHeader(name) {
+			headers[name] = struct{}{}
+		}
+	}
+
+	return &pageProfile{
+		structure: shingles(tags, structureShingle),
+		text:      shingles(words, textShingle),
+		headers:   headers,
+	}
+}
+
+// normalizeWords splits text into lowercase words
+// Words containing digits and very long tokens are collapsed to "#" so
+// timestamps, IDs and nonces do not count as differences.
+func normalizeWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range fields {
+		if len(word) > 32 || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			fields[i] = "#"
+		}
+	}
+	return fields
+}
+
+// shingles returns the hashed k-grams of a sequence
+// A sequence shorter than k yields a single shingle of the whole sequence.
+func shingles(seq []string, k int) map[uint64]struct{} {
+	set := make(map[uint64]struct{})
+	if len(seq) == 0 {
+		return set
+	}
+	if len(seq) < k {
+		k = len(seq)
+	}
+	for i := 0; i+k <= len(seq); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(seq[i:i+k], "\x00")))
+		set[h.Sum64()] = struct{}{}
+	}
+	return set
+}
+
+// jaccard returns |a ∩ b| / |a ∪ b|, or -1 when both sets are empty
+func jaccard[K comparable](a, b map[K]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return -1
+	}
+	shared := 0
+	for key := range a {
+		if _, ok := b[key]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// isCDNHeader reports whether a lowercase header name is added by a CDN or cache
+func isCDNHeader(name string) bool {
+	for _, prefix := range cdnHeaderPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
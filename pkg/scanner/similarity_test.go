@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jhaxce/origindive/v3/pkg/core"
+)
+
+const similarityPage = `<html><head><title>Shop</title><script>var t = "%s";</script></head>
+<body><nav><a href="/">Home</a><a href="/cart">Cart</a></nav>
+<form><input type="hidden" name="csrf" value="%s"><button>Sign in</button></form>
+<p>Welcome back to the shop. Today's deals end at %s.</p>
+<footer>Copyright example corp, all rights reserved</footer></body></html>`
+
+func TestPageProfile_Similarity(t *testing.T) {
+	header := http.Header{"Content-Type": {"text/html"}, "Set-Cookie": {"a=1"}, "Cf-Ray": {"abc"}, "Server": {"cloudflare"}}
+	base := newPageProfile(header, []byte(fmt.Sprintf(similarityPage, "111", "token-aaaa", "10:00")))
+
+	// Same page from the origin: new tokens and no CDN headers
+	originHeader := http.Header{"Content-Type": {"text/html"}, "Set-Cookie": {"a=2"}, "Server": {"nginx"}}
+	origin := newPageProfile(originHeader, []byte(fmt.Sprintf(similarityPage, "222", "token-bbbb", "11:30")))
+	if got := base.similarity(origin); got < 0.9 {
+		t.Errorf("similarity of the same page with dynamic tokens = %v, want >= 0.9", got)
+	}
+
+	other := newPageProfile(http.Header{"Content-Type": {"text/html"}}, []byte(
+		`<html><head><title>Welcome to nginx!</title></head><body><h1>Welcome to nginx!</h1>
+<p>If you see this page, the nginx web server is successfully installed.</p></body></html>`))
+	if got := base.similarity(other); got > 0.3 {
+		t.Errorf("similarity of an unrelated page = %v, want <= 0.3", got)
+	}
+
+	if got := base.similarity(base); got != 1 {
+		t.Errorf("self similarity = %v, want 1", got)
+	}
+	if got := newPageProfile(nil, nil).similarity(newPageProfile(nil, nil)); got != 0 {
+		t.Errorf("similarity of empty responses = %v, want 0", got)
+	}
+}
+
+func TestNormalizeWords(t *testing.T) {
+	got := normalizeWords("Order #12345 shipped, ETA 3pm; Thanks!")
+	want := []string{"order", "#", "shipped", "eta", "#", "thanks"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("normalizeWords() = %v, want %v", got, want)
+	}
+}
+
+func TestScanner_BaselineSimilarity(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, similarityPage, strconv.Itoa(requests), "token-"+strconv.Itoa(requests), time.Now().Format(time.StampNano))
+	}))
+	defer server.Close()
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// The test server stands in for both the CDN (via the domain) and the origin (via the IP)
+	config := &core.Config{
+		Timeout:       2 * time.Second,
+		Workers:       1,
+		Domain:        server.Listener.Addr().String(),
+		HTTPMethod:    "GET",
+		VerifyContent: true,
+	}
+	s, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	baseline, err := s.FetchBaseline(context.Background())
+	if err != nil {
+		t.Fatalf("FetchBaseline() error: %v", err)
+	}
+	if baseline.StatusCode != 200 || baseline.Title != "Shop" || baseline.URL != server.URL+"/" {
+		t.Errorf("FetchBaseline() = %+v, want the HTTP homepage", baseline)
+	}
+	s.SetBaseline(baseline)
+
+	result := s.scanIP(context.Background(), net.ParseIP("127.0.0.1"), port)
+	if result.Status != "200" {
+		t.Fatalf("Status = %q, want 200 (error: %s)", result.Status, result.Error)
+	}
+	if result.Similarity < 0.9 {
+		t.Errorf("Similarity = %v, want >= 0.9 for the same page with new tokens", result.Similarity)
+	}
+
+	unreachable, _ := New(&core.Config{Timeout: time.Second, Workers: 1, Domain: "127.0.0.1:1"})
+	if _, err := unreachable.FetchBaseline(context.Background()); err == nil {
+		t.Error("FetchBaseline() on a closed port should fail")
+	}
+}
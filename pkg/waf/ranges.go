@@ -71,8 +71,12 @@ func (rs *RangeSet) Contains(ip net.IP) bool {
 }
 
 // FindProvider returns the provider ID if the IP is in a WAF range
+// Works for both IPv4 and IPv6; IPv4-mapped IPv6 addresses match IPv4 ranges
 // Returns (providerID, found)
 func (rs *RangeSet) FindProvider(ip net.IP) (string, bool) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
 	for _, r := range rs.ranges {
 		if r.Network.Contains(ip) {
 			return r.Provider, true
@@ -230,6 +230,10 @@ func (u *Updater) parseAWSRanges(data []byte) ([]string, error) {
 			IPPrefix string `json:"ip_prefix"`
 			Service  string `json:"service"`
 		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Service    string `json:"service"`
+		} `json:"ipv6_prefixes"`
 	}
 
 	if err := json.Unmarshal(data, &awsData); err != nil {
@@ -242,6 +246,11 @@ func (u *Updater) parseAWSRanges(data []byte) ([]string, error) {
 			ranges = append(ranges, prefix.IPPrefix)
 		}
 	}
+	for _, prefix := range awsData.IPv6Prefixes {
+		if prefix.Service == "CLOUDFRONT" {
+			ranges = append(ranges, prefix.IPv6Prefix)
+		}
+	}
 
 	return ranges, nil
 }
@@ -249,14 +258,15 @@ func (u *Updater) parseAWSRanges(data []byte) ([]string, error) {
 // parseFastlyRanges parses Fastly IP list JSON
 func (u *Updater) parseFastlyRanges(data []byte) ([]string, error) {
 	var fastlyData struct {
-		Addresses []string `json:"addresses"`
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
 	}
 
 	if err := json.Unmarshal(data, &fastlyData); err != nil {
 		return nil, err
 	}
 
-	return fastlyData.Addresses, nil
+	return append(fastlyData.Addresses, fastlyData.IPv6Addresses...), nil
 }
 
 // NeedsUpdate checks if the database needs updating based on last update time
@@ -37,6 +37,38 @@ func TestRangeSet_AddProvider(t *testing.T) {
 	}
 }
 
+// Test FindProvider with IPv6 ranges
+func TestRangeSet_FindProvider_IPv6(t *testing.T) {
+	rs := NewRangeSet()
+	if err := rs.AddProvider(&Provider{
+		ID:     "cloudflare",
+		Ranges: []string{"104.16.0.0/13", "2606:4700::/32"},
+	}); err != nil {
+		t.Fatalf("AddProvider() error: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"2606:4700::6810:84e5", true},
+		{"2606:4701::1", false},
+		{"104.16.1.1", true},
+		{"::ffff:104.16.1.1", true}, // IPv4-mapped form matches the IPv4 range
+		{"2001:db8::1", false},
+	}
+
+	for _, tt := range tests {
+		provider, found := rs.FindProvider(net.ParseIP(tt.ip))
+		if found != tt.want {
+			t.Errorf("FindProvider(%s) found = %v, want %v", tt.ip, found, tt.want)
+		}
+		if found && provider != "cloudflare" {
+			t.Errorf("FindProvider(%s) provider = %s, want cloudflare", tt.ip, provider)
+		}
+	}
+}
+
 // Test AddProvider with nil
 func TestRangeSet_AddProviderNil(t *testing.T) {
 	rs := NewRangeSet()
@@ -467,6 +499,10 @@ func TestUpdater_ParseAWSRanges(t *testing.T) {
 			{"ip_prefix": "192.0.2.0/24", "service": "CLOUDFRONT"},
 			{"ip_prefix": "198.51.100.0/24", "service": "EC2"},
 			{"ip_prefix": "203.0.113.0/24", "service": "CLOUDFRONT"}
+		],
+		"ipv6_prefixes": [
+			{"ipv6_prefix": "2600:9000::/28", "service": "CLOUDFRONT"},
+			{"ipv6_prefix": "2600:1f00::/24", "service": "EC2"}
 		]
 	}`)
 
@@ -475,8 +511,8 @@ func TestUpdater_ParseAWSRanges(t *testing.T) {
 		t.Fatalf("parseAWSRanges() error: %v", err)
 	}
 
-	if len(ranges) != 2 {
-		t.Errorf("parseAWSRanges() count = %d, want 2", len(ranges))
+	if len(ranges) != 3 {
+		t.Errorf("parseAWSRanges() count = %d, want 3", len(ranges))
 	}
 }
 
@@ -485,7 +521,8 @@ func TestUpdater_ParseFastlyRanges(t *testing.T) {
 	u := &Updater{}
 
 	data := []byte(`{
-		"addresses": ["192.0.2.0/24", "198.51.100.0/24"]
+		"addresses": ["192.0.2.0/24", "198.51.100.0/24"],
+		"ipv6_addresses": ["2a04:4e40::/32"]
 	}`)
 
 	ranges, err := u.parseFastlyRanges(data)
@@ -493,8 +530,8 @@ func TestUpdater_ParseFastlyRanges(t *testing.T) {
 		t.Fatalf("parseFastlyRanges() error: %v", err)
 	}
 
-	if len(ranges) != 2 {
-		t.Errorf("parseFastlyRanges() count = %d, want 2", len(ranges))
+	if len(ranges) != 3 {
+		t.Errorf("parseFastlyRanges() count = %d, want 3", len(ranges))
 	}
 }
 